@@ -0,0 +1,30 @@
+package clientdemo
+
+import "time"
+
+// now returns the current time. It is a variable so that unit tests can fix the time against which
+// the age of a repository is measured.
+var now = time.Now
+
+// Age returns how long ago the repository was created.
+func (r *RepoData) Age() time.Duration {
+	return now().Sub(r.CreatedAt)
+}
+
+// DaysSinceLastCommit returns the number of whole days that have passed since the most recent of the
+// repository's RecentCommits was made, and true, or zero and false if there are no commits with a
+// usable time stamp, e.g. if the RepoData came from GetRepoMetadata(...).
+func (r *RepoData) DaysSinceLastCommit() (int, bool) {
+
+	// The commits should be in order, most recent first, but it costs little to be sure
+	var latest time.Time
+	for _, c := range r.RecentCommits {
+		if c.CommittedAt.After(latest) {
+			latest = c.CommittedAt
+		}
+	}
+	if latest.IsZero() {
+		return 0, false
+	}
+	return int(now().Sub(latest).Hours() / 24), true
+}
@@ -0,0 +1,42 @@
+package clientdemo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the repository age calculations
+
+// TestRepoDataAge confirms the age and staleness of a repository at a fixed time.
+func TestRepoDataAge(t *testing.T) {
+
+	// Fix the time
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC) }
+
+	repo := &RepoData{
+		CreatedAt: time.Date(2019, 6, 1, 19, 7, 6, 0, time.UTC),
+		RecentCommits: []RepoCommit{
+			{CommittedAt: time.Date(2024, 6, 20, 9, 0, 0, 0, time.UTC), Headline: "Second newest"},
+			{CommittedAt: time.Date(2024, 6, 21, 13, 0, 0, 0, time.UTC), Headline: "Newest, out of order"},
+			{Headline: "Unparseable time stamp"},
+		},
+	}
+	assert.Equal(t, time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC).Sub(repo.CreatedAt), repo.Age())
+	assert.Equal(t, 1856*24*time.Hour+16*time.Hour+52*time.Minute+54*time.Second, repo.Age())
+
+	days, ok := repo.DaysSinceLastCommit()
+	assert.True(t, ok, "There should have been a last commit")
+	assert.Equal(t, 9, days, "Nine whole days and 23 hours should count as nine days")
+
+	// Without commits there is nothing to say
+	repo.RecentCommits = []RepoCommit{{Headline: "Unparseable time stamp"}}
+	days, ok = repo.DaysSinceLastCommit()
+	assert.False(t, ok)
+	assert.Equal(t, 0, days)
+	repo.RecentCommits = nil
+	_, ok = repo.DaysSinceLastCommit()
+	assert.False(t, ok)
+}
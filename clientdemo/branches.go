@@ -0,0 +1,65 @@
+package clientdemo
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// The Graphql query we use to retrieve a page of the branches of a repository
+var getBranchesQuery = `query FetchBranches($owner: String!, $name: String!, $first: Int!, $after: String) {
+	repository(owner: $owner, name: $name) {
+		refs(refPrefix: "refs/heads/", first: $first, after: $after) {
+			pageInfo {
+				endCursor
+				hasNextPage
+			}
+			nodes {
+				name
+			}
+		}
+	}
+}`
+
+// GetBranchesResponse is a JSON annotated structure used to parse each page of the response from the GraphQL call into
+type GetBranchesResponse struct {
+	Repository struct {
+		Refs struct {
+			PageInfo gqlclient.PageInfo `json:"pageInfo"`
+			Nodes    []struct {
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"refs"`
+	} `json:"repository"`
+}
+
+// branchPageSize is the number of branches requested per page, the most that github allows
+const branchPageSize = 100
+
+// GetBranches illustrates paging through a GraphQL connection by retrieving the names of every
+// branch of a given repository, however many pages that takes. An empty repository, having no
+// branches at all, gives an empty list.
+func GetBranches(githubAPIURL string, githubToken string, owner string, repoName string) ([]string, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Let gqlclient.Paginate(...) follow the cursors from page to page for us
+	return gqlclient.Paginate(context.Background(), client, gqlclient.PaginateRequest[string]{
+		QueryStr:   getBranchesQuery,
+		BaseParams: map[string]interface{}{"owner": owner, "name": repoName},
+		PageSize:   branchPageSize,
+		ExtractPage: func(response *gqlclient.QueryResponse) ([]string, *gqlclient.PageInfo, error) {
+			var page GetBranchesResponse
+			if err := json.Unmarshal(*response.Data.(*json.RawMessage), &page); err != nil {
+				return nil, nil, err
+			}
+			names := []string{}
+			for _, node := range page.Repository.Refs.Nodes {
+				names = append(names, node.Name)
+			}
+			return names, &page.Repository.Refs.PageInfo, nil
+		},
+	})
+}
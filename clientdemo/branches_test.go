@@ -0,0 +1,60 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the branch listing demonstration
+
+// TestGetBranches confirms that the real gogql repository includes its default branch.
+func TestGetBranches(t *testing.T) {
+
+	// This test needs a real token
+	if len(os.Getenv("GITHUB_TOKEN")) == 0 {
+		t.Skip("GITHUB_TOKEN environment variable is not set")
+	}
+
+	branches, err := GetBranches(githubAPIURL, getAuthorization(t), "mikebway", "gogql")
+	assert.Nil(t, err, "github graphql invocation should not have failed")
+	assert.Contains(t, branches, "master", "The default branch should have been listed")
+}
+
+// TestGetBranchesPaging confirms that every page of branches is fetched, following the cursors.
+func TestGetBranchesPaging(t *testing.T) {
+
+	// Serve two pages, the second only when asked for with the first page's cursor
+	var cursors []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		cursors = append(cursors, body.Variables["after"])
+		if body.Variables["after"] == "page1" {
+			w.Write([]byte(`{"data":{"repository":{"refs":{"pageInfo":{"endCursor":"page2","hasNextPage":false},
+				"nodes":[{"name":"feature-b"}]}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"refs":{"pageInfo":{"endCursor":"page1","hasNextPage":true},
+			"nodes":[{"name":"master"},{"name":"feature-a"}]}}}}`))
+	}))
+	defer server.Close()
+
+	branches, err := GetBranches(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Branches query should not have failed")
+	assert.Equal(t, []string{"master", "feature-a", "feature-b"}, branches)
+	assert.Equal(t, []interface{}{nil, "page1"}, cursors, "The second page should have been requested with the first page's cursor")
+
+	// An empty repository has no branches at all
+	server = serveFixture(`{"data":{"repository":{"refs":{"pageInfo":{"hasNextPage":false},"nodes":[]}}}}`)
+	defer server.Close()
+	branches, err = GetBranches(server.URL, "token test", "mikebway", "empty")
+	assert.Nil(t, err, "Branches query should not have failed")
+	assert.Equal(t, []string{}, branches)
+}
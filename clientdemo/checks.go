@@ -0,0 +1,121 @@
+package clientdemo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// CheckRun is a structure type that represents a single check run, e.g. a CI build, reported
+// against a commit.
+type CheckRun struct {
+	Name        string     // The name of the check
+	Status      string     // The current status, e.g. QUEUED, IN_PROGRESS or COMPLETED
+	Conclusion  string     // The outcome once completed, e.g. SUCCESS or FAILURE; empty until then
+	StartedAt   time.Time  // The date and time at which the check started
+	CompletedAt *time.Time // The date and time at which the check completed, nil if it has not
+	URL         string     // The URL of the check run on github
+}
+
+// The Graphql query we use to retrieve the check runs of a commit. The object(...) field can return
+// any kind of git object, so an inline fragment is needed to select the Commit fields. The nodes of
+// checkSuites are always CheckSuite objects, so their fields are selected directly; a fragment is
+// only needed where a field's type is an interface or union.
+var getCommitChecksQuery = `query FetchCommitChecks($owner: String!, $name: String!, $sha: GitObjectID!) {
+	repository(owner: $owner, name: $name) {
+		object(oid: $sha) {
+			... on Commit {
+				checkSuites(first: 20) {
+					nodes {
+						checkRuns(first: 50) {
+							nodes {
+								name
+								status
+								conclusion
+								startedAt
+								completedAt
+								url
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetCommitChecksResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// Inline fragments do not appear in the response JSON: the fields they select are simply merged into the
+// enclosing object, so the structure follows the fields alone.
+type GetCommitChecksResponse struct {
+	Repository struct {
+		Object *struct {
+			CheckSuites struct {
+				Nodes []struct {
+					CheckRuns struct {
+						Nodes []struct {
+							Name        string     `json:"name"`
+							Status      string     `json:"status"`
+							Conclusion  string     `json:"conclusion"`
+							StartedAt   time.Time  `json:"startedAt"`
+							CompletedAt *time.Time `json:"completedAt"`
+							URL         string     `json:"url"`
+						} `json:"nodes"`
+					} `json:"checkRuns"`
+				} `json:"nodes"`
+			} `json:"checkSuites"`
+		} `json:"object"`
+	} `json:"repository"`
+}
+
+// GetCommitChecks illustrates the use of inline fragments by retrieving the check runs reported
+// against a given commit of a repository, across all of its check suites.
+func GetCommitChecks(githubAPIURL string, githubToken string, owner string, repoName string, sha string) ([]CheckRun, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	queryParms["sha"] = &sha
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetCommitChecksResponse)}
+
+	// Run the query
+	err := client.Query(&getCommitChecksQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, flatten the check runs of all the suites into a single list
+	checksResponse, ok := response.Data.(*GetCommitChecksResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	if checksResponse.Repository.Object == nil {
+		return nil, errors.New("commit not found: " + sha)
+	}
+	result := []CheckRun{}
+	for _, suite := range checksResponse.Repository.Object.CheckSuites.Nodes {
+		for _, run := range suite.CheckRuns.Nodes {
+			result = append(result, CheckRun{
+				Name:        run.Name,
+				Status:      run.Status,
+				Conclusion:  run.Conclusion,
+				StartedAt:   run.StartedAt,
+				CompletedAt: run.CompletedAt,
+				URL:         run.URL,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package clientdemo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the commit check run demonstration
+
+// TestGetCommitChecks confirms that check runs are flattened out of their suites, including a
+// run that has yet to complete.
+func TestGetCommitChecks(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"object":{"checkSuites":{"nodes":[
+		{"checkRuns":{"nodes":[
+			{"name":"build","status":"COMPLETED","conclusion":"SUCCESS","startedAt":"2019-06-02T10:00:00Z",
+			 "completedAt":"2019-06-02T10:05:30Z","url":"https://github.com/mikebway/gogql/runs/1"}]}},
+		{"checkRuns":{"nodes":[
+			{"name":"lint","status":"IN_PROGRESS","conclusion":null,"startedAt":"2019-06-02T10:01:00Z",
+			 "completedAt":null,"url":"https://github.com/mikebway/gogql/runs/2"}]}}]}}}}}`)
+	defer server.Close()
+
+	runs, err := GetCommitChecks(server.URL, "token test", "mikebway", "gogql", "eb655db")
+	assert.Nil(t, err, "Check run query should not have failed")
+	assert.Equal(t, 2, len(runs), "There should have been two check runs")
+
+	// The completed run
+	startedAt, _ := time.Parse(time.RFC3339, "2019-06-02T10:00:00Z")
+	completedAt, _ := time.Parse(time.RFC3339, "2019-06-02T10:05:30Z")
+	assert.Equal(t, "build", runs[0].Name)
+	assert.Equal(t, "COMPLETED", runs[0].Status)
+	assert.Equal(t, "SUCCESS", runs[0].Conclusion)
+	assert.True(t, startedAt.Equal(runs[0].StartedAt), "Start time does not match")
+	assert.NotNil(t, runs[0].CompletedAt, "Completion time should have been populated")
+	assert.True(t, completedAt.Equal(*runs[0].CompletedAt), "Completion time does not match")
+	assert.Equal(t, "https://github.com/mikebway/gogql/runs/1", runs[0].URL)
+
+	// The run still in progress
+	assert.Equal(t, "lint", runs[1].Name)
+	assert.Equal(t, "IN_PROGRESS", runs[1].Status)
+	assert.Equal(t, "", runs[1].Conclusion)
+	assert.Nil(t, runs[1].CompletedAt, "An incomplete run should have no completion time")
+}
+
+// TestGetCommitChecksNotFound confirms that an unknown commit is reported.
+func TestGetCommitChecksNotFound(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"object":null}}}`)
+	defer server.Close()
+
+	_, err := GetCommitChecks(server.URL, "token test", "mikebway", "gogql", "0000000")
+	assert.NotNil(t, err, "An unknown commit should have been reported")
+}
@@ -0,0 +1,99 @@
+package clientdemo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// The Graphql query we use to retrieve a single commit. The object may be of any git object type, so
+// we ask for its type name to be able to tell whether it was a commit that we found.
+var getCommitQuery = `query FetchCommit($owner: String!, $name: String!, $oid: GitObjectID!) {
+	repository(owner: $owner, name: $name) {
+		object(oid: $oid) {
+			__typename
+			... on Commit {
+				oid
+				committedDate
+				messageHeadline
+				author {
+					name
+				}
+			}
+		}
+	}
+}`
+
+// GetCommitResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The repository is null if it could not be found, the object is null if the repository has no object
+// with the requested OID, and the author is null if the commit did not record one.
+type GetCommitResponse struct {
+	Repository *struct {
+		Object *struct {
+			Typename        string    `json:"__typename"`
+			OID             string    `json:"oid"`
+			CommittedDate   time.Time `json:"committedDate"`
+			MessageHeadline string    `json:"messageHeadline"`
+			Author          *struct {
+				Name string `json:"name"`
+			} `json:"author"`
+		} `json:"object"`
+	} `json:"repository"`
+}
+
+// GetCommit illustrates the use of an inline fragment to pick out one type of a polymorphic result by
+// retrieving a single commit of a given repository by its OID, the full SHA of the commit.
+func GetCommit(githubAPIURL string, githubToken string, owner string, repoName string, oid string) (*RepoCommit, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	queryParms["oid"] = &oid
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetCommitResponse)}
+
+	// Run the query
+	err := client.Query(&getCommitQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, but make sure that what we found was a commit
+	commitResponse, ok := response.Data.(*GetCommitResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	if commitResponse.Repository == nil {
+		return nil, fmt.Errorf("repository not found: %s/%s", owner, repoName)
+	}
+	object := commitResponse.Repository.Object
+	if object == nil {
+		return nil, fmt.Errorf("commit not found: %s", oid)
+	}
+	if object.Typename != "Commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", oid, object.Typename)
+	}
+
+	// Translate the commit into our simpler result structure
+	result := &RepoCommit{
+		OID:         object.OID,
+		CommittedAt: object.CommittedDate,
+		Headline:    object.MessageHeadline,
+	}
+	if object.Author != nil {
+		result.Author = object.Author.Name
+	}
+	return result, nil
+}
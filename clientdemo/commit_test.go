@@ -0,0 +1,81 @@
+package clientdemo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// This file defines unit tests for the single commit demonstration
+
+// TestGetCommitLive fetches one of the recent commits of the gogql repository from github by its SHA.
+func TestGetCommitLive(t *testing.T) {
+
+	// This test needs a real token
+	if len(os.Getenv("GITHUB_TOKEN")) == 0 {
+		t.Skip("GITHUB_TOKEN environment variable is not set")
+	}
+	authToken := getAuthorization(t)
+
+	// Find the SHA of a recent commit, then fetch that commit on its own
+	repo, err := GetRepoData(githubAPIURL, authToken, "mikebway", "gogql")
+	require.NoError(t, err, "github graphql invocation should not have failed")
+	require.NotEmpty(t, repo.RecentCommits, "There should have been recent commits")
+	expected := repo.RecentCommits[0]
+	assert.Len(t, expected.OID, 40, "The commit SHA should have been obtained")
+
+	commit, err := GetCommit(githubAPIURL, authToken, "mikebway", "gogql", expected.OID)
+	require.NoError(t, err, "The commit should have been found")
+	assert.Equal(t, expected, *commit, "The commit should match the one in the history")
+}
+
+// TestGetCommit confirms that a commit is translated, including one with no recorded author.
+func TestGetCommit(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"object":{"__typename":"Commit",
+		"oid":"0123456789abcdef0123456789abcdef01234567","committedDate":"2019-06-02T10:00:00Z",
+		"messageHeadline":"Initial commit","author":{"name":"The Octocat"}}}}}`)
+	defer server.Close()
+
+	commit, err := GetCommit(server.URL, "token test", "mikebway", "gogql", "0123456789abcdef0123456789abcdef01234567")
+	assert.Nil(t, err, "Commit query should not have failed")
+	assert.Equal(t, &RepoCommit{
+		OID:         "0123456789abcdef0123456789abcdef01234567",
+		CommittedAt: time.Date(2019, 6, 2, 10, 0, 0, 0, time.UTC),
+		Headline:    "Initial commit",
+		Author:      "The Octocat",
+	}, commit)
+
+	server = serveFixture(`{"data":{"repository":{"object":{"__typename":"Commit",
+		"oid":"0123456789abcdef0123456789abcdef01234567","committedDate":"2019-06-02T10:00:00Z",
+		"messageHeadline":"Imported","author":null}}}}`)
+	defer server.Close()
+
+	commit, err = GetCommit(server.URL, "token test", "mikebway", "gogql", "0123456789abcdef0123456789abcdef01234567")
+	assert.Nil(t, err, "A commit without an author should not fail the request")
+	assert.Equal(t, "", commit.Author)
+}
+
+// TestGetCommitNotFound confirms that missing objects, and objects that are not commits, are reported.
+func TestGetCommitNotFound(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"object":null}}}`)
+	defer server.Close()
+	commit, err := GetCommit(server.URL, "token test", "mikebway", "gogql", "0000000000000000000000000000000000000000")
+	assert.Nil(t, commit)
+	assert.EqualError(t, err, "commit not found: 0000000000000000000000000000000000000000")
+
+	server = serveFixture(`{"data":{"repository":{"object":{"__typename":"Tree"}}}}`)
+	defer server.Close()
+	commit, err = GetCommit(server.URL, "token test", "mikebway", "gogql", "1111111111111111111111111111111111111111")
+	assert.Nil(t, commit)
+	assert.EqualError(t, err, "object 1111111111111111111111111111111111111111 is a Tree, not a commit")
+
+	server = serveFixture(`{"data":{"repository":null}}`)
+	defer server.Close()
+	_, err = GetCommit(server.URL, "token test", "mikebway", "i-dont-exist", "1111111111111111111111111111111111111111")
+	assert.EqualError(t, err, "repository not found: mikebway/i-dont-exist")
+}
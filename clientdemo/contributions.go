@@ -0,0 +1,126 @@
+package clientdemo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// ContributionSummary is a structure used to return a summary of a github user's contributions
+// over a period of time.
+type ContributionSummary struct {
+	TotalCommits     int      // The number of commits made
+	TotalPRs         int      // The number of pull requests opened
+	TotalIssues      int      // The number of issues opened
+	ContributedRepos []string // The owner/name of each repository contributed to, each listed once
+}
+
+// The Graphql query we use to retrieve a summary of a user's contributions. The DateTime scalar
+// variables are supplied as RFC3339 strings.
+var getUserContributionsQuery = `query FetchUserContributions($login: String!, $from: DateTime!, $to: DateTime!) {
+	user(login: $login) {
+		contributionsCollection(from: $from, to: $to) {
+			totalCommitContributions
+			totalPullRequestContributions
+			totalIssueContributions
+			commitContributionsByRepository(maxRepositories: 100) {
+				repository {
+					nameWithOwner
+				}
+			}
+			pullRequestContributionsByRepository(maxRepositories: 100) {
+				repository {
+					nameWithOwner
+				}
+			}
+			issueContributionsByRepository(maxRepositories: 100) {
+				repository {
+					nameWithOwner
+				}
+			}
+		}
+	}
+}`
+
+// repositoryContributions is the JSON annotated structure shared by the three by-repository lists
+// in the GetUserContributionsResponse.
+type repositoryContributions []struct {
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+}
+
+// GetUserContributionsResponse is a JSON annotated structure used to parse the response from the GraphQL call into
+type GetUserContributionsResponse struct {
+	User struct {
+		ContributionsCollection struct {
+			TotalCommitContributions             int                     `json:"totalCommitContributions"`
+			TotalPullRequestContributions        int                     `json:"totalPullRequestContributions"`
+			TotalIssueContributions              int                     `json:"totalIssueContributions"`
+			CommitContributionsByRepository      repositoryContributions `json:"commitContributionsByRepository"`
+			PullRequestContributionsByRepository repositoryContributions `json:"pullRequestContributionsByRepository"`
+			IssueContributionsByRepository       repositoryContributions `json:"issueContributionsByRepository"`
+		} `json:"contributionsCollection"`
+	} `json:"user"`
+}
+
+// GetUserContributions illustrates the use of date range variables by summarizing the contributions
+// that a github user made between the from and to times. Note that github limits the range to at
+// most one year.
+func GetUserContributions(githubAPIURL string, githubToken string, username string, from, to time.Time) (*ContributionSummary, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map. GraphQL has no native time type, github's DateTime
+	// scalar expects an ISO-8601 string.
+	queryParms := make(map[string]interface{})
+	queryParms["login"] = &username
+	queryParms["from"] = gqlclient.DateTimeVar(from)
+	queryParms["to"] = gqlclient.DateTimeVar(to)
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetUserContributionsResponse)}
+
+	// Run the query
+	err := client.Query(&getUserContributionsQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	contributionsResponse, ok := response.Data.(*GetUserContributionsResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	collection := contributionsResponse.User.ContributionsCollection
+	result := &ContributionSummary{
+		TotalCommits:     collection.TotalCommitContributions,
+		TotalPRs:         collection.TotalPullRequestContributions,
+		TotalIssues:      collection.TotalIssueContributions,
+		ContributedRepos: []string{},
+	}
+
+	// The same repository may appear in more than one of the lists, only list it once
+	seen := make(map[string]bool)
+	for _, list := range []repositoryContributions{
+		collection.CommitContributionsByRepository,
+		collection.PullRequestContributionsByRepository,
+		collection.IssueContributionsByRepository,
+	} {
+		for _, c := range list {
+			name := c.Repository.NameWithOwner
+			if !seen[name] {
+				seen[name] = true
+				result.ContributedRepos = append(result.ContributedRepos, name)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the user contributions demonstration
+
+// TestGetUserContributions confirms that the date range is sent as RFC3339 strings and that the
+// counts and repository names are picked out of the response.
+func TestGetUserContributions(t *testing.T) {
+
+	// Record the variables that the server receives
+	var variables map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		variables = body.Variables
+		w.Write([]byte(`{"data":{"user":{"contributionsCollection":{
+			"totalCommitContributions":42,"totalPullRequestContributions":7,"totalIssueContributions":3,
+			"commitContributionsByRepository":[{"repository":{"nameWithOwner":"mikebway/gogql"}},{"repository":{"nameWithOwner":"mikebway/other"}}],
+			"pullRequestContributionsByRepository":[{"repository":{"nameWithOwner":"mikebway/gogql"}}],
+			"issueContributionsByRepository":[{"repository":{"nameWithOwner":"golang/go"}}]}}}}`))
+	}))
+	defer server.Close()
+
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC)
+	summary, err := GetUserContributions(server.URL, "token test", "mikebway", from, to)
+	assert.Nil(t, err, "Contributions query should not have failed")
+
+	// Check what was sent
+	assert.Equal(t, "mikebway", variables["login"])
+	assert.Equal(t, "2019-01-01T00:00:00Z", variables["from"])
+	assert.Equal(t, "2019-12-31T23:59:59Z", variables["to"])
+
+	// And what came back
+	assert.Equal(t, 42, summary.TotalCommits)
+	assert.Equal(t, 7, summary.TotalPRs)
+	assert.Equal(t, 3, summary.TotalIssues)
+	assert.Equal(t, []string{"mikebway/gogql", "mikebway/other", "golang/go"}, summary.ContributedRepos)
+}
@@ -0,0 +1,187 @@
+package clientdemo
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// ContributorWeekStats is a structure used to return the weekly activity of a single contributor to
+// a github repository.
+type ContributorWeekStats struct {
+	Login string     // The github login of the contributor
+	Weeks []WeekData // The weeks in which the contributor made commits, in date order
+}
+
+// WeekData is a structure used to return the activity of a contributor during a single week.
+type WeekData struct {
+	Week      time.Time // The start of the week, midnight UTC on the Sunday
+	Additions int       // The number of lines added by the contributor's commits that week
+	Deletions int       // The number of lines deleted by the contributor's commits that week
+	Commits   int       // The number of commits made by the contributor that week
+}
+
+// The Graphql query we use to retrieve a page of the commit history of a repository's default branch
+// within a date range, with the size of each commit and the github user who wrote it
+var getContributorStatsQuery = `query FetchContributorStats($owner: String!, $name: String!, $since: GitTimestamp!, $until: GitTimestamp!, $first: Int!, $after: String) {
+	repository(owner: $owner, name: $name) {
+		defaultBranchRef {
+			target {
+				... on Commit {
+					history(since: $since, until: $until, first: $first, after: $after) {
+						pageInfo {
+							endCursor
+							hasNextPage
+						}
+						nodes {
+							committedDate
+							additions
+							deletions
+							author {
+								user {
+									login
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetContributorStatsResponse is a JSON annotated structure used to parse each page of the response from the
+// GraphQL call into. The default branch is null for an empty repository, and the author's user is null
+// for commits whose author cannot be matched to a github account.
+type GetContributorStatsResponse struct {
+	Repository struct {
+		DefaultBranchRef *struct {
+			Target struct {
+				History struct {
+					PageInfo gqlclient.PageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						CommittedDate time.Time `json:"committedDate"`
+						Additions     int       `json:"additions"`
+						Deletions     int       `json:"deletions"`
+						Author        struct {
+							User *struct {
+								Login string `json:"login"`
+							} `json:"user"`
+						} `json:"author"`
+					} `json:"nodes"`
+				} `json:"history"`
+			} `json:"target"`
+		} `json:"defaultBranchRef"`
+	} `json:"repository"`
+}
+
+// contributorCommit is a single commit of the history, attributed to a github user.
+type contributorCommit struct {
+	login     string
+	week      time.Time
+	additions int
+	deletions int
+}
+
+// contributorStatsPageSize is the number of commits requested per page, the most that github allows
+const contributorStatsPageSize = 100
+
+// secondsPerDay is the number of seconds in a day, leap seconds being ignored by Unix time stamps
+const secondsPerDay = 24 * 60 * 60
+
+// GetContributorStats illustrates combining a paged connection with date arithmetic and aggregation
+// by summarizing, for each github user who committed to the default branch of a repository during
+// the given calendar year (in UTC), the lines added and deleted and the commits made each week.
+// Weeks start on Sunday, as they do in github's own contributor statistics, so the first week of the
+// year may start in December of the year before. Weeks without commits are left out, as are commits
+// whose author cannot be matched to a github account. Contributors are listed in login order.
+func GetContributorStats(githubAPIURL string, githubToken string, owner string, repoName string, year int) ([]ContributorWeekStats, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Let gqlclient.Paginate(...) follow the history from page to page for us, putting each commit
+	// in its week as it goes
+	since := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	commits, err := gqlclient.Paginate(context.Background(), client, gqlclient.PaginateRequest[contributorCommit]{
+		QueryStr: getContributorStatsQuery,
+		BaseParams: map[string]interface{}{
+			"owner": owner,
+			"name":  repoName,
+			"since": gqlclient.DateTimeVar(since),
+			"until": gqlclient.DateTimeVar(until),
+		},
+		PageSize: contributorStatsPageSize,
+		ExtractPage: func(response *gqlclient.QueryResponse) ([]contributorCommit, *gqlclient.PageInfo, error) {
+			var page GetContributorStatsResponse
+			if err := json.Unmarshal(*response.Data.(*json.RawMessage), &page); err != nil {
+				return nil, nil, err
+			}
+			ref := page.Repository.DefaultBranchRef
+			if ref == nil {
+				return nil, nil, nil
+			}
+			commits := []contributorCommit{}
+			for _, node := range ref.Target.History.Nodes {
+				if node.Author.User == nil {
+					continue
+				}
+				commits = append(commits, contributorCommit{
+					login:     node.Author.User.Login,
+					week:      weekStart(node.CommittedDate),
+					additions: node.Additions,
+					deletions: node.Deletions,
+				})
+			}
+			return commits, &ref.Target.History.PageInfo, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Total up the commits of each contributor, week by week
+	weeks := make(map[string]map[int64]*WeekData)
+	for _, c := range commits {
+		if weeks[c.login] == nil {
+			weeks[c.login] = make(map[int64]*WeekData)
+		}
+		week := weeks[c.login][c.week.Unix()]
+		if week == nil {
+			week = &WeekData{Week: c.week}
+			weeks[c.login][c.week.Unix()] = week
+		}
+		week.Additions += c.additions
+		week.Deletions += c.deletions
+		week.Commits++
+	}
+
+	// And put everything in order
+	result := []ContributorWeekStats{}
+	for login, byWeek := range weeks {
+		stats := ContributorWeekStats{Login: login}
+		for _, week := range byWeek {
+			stats.Weeks = append(stats.Weeks, *week)
+		}
+		sort.Slice(stats.Weeks, func(i, j int) bool { return stats.Weeks[i].Week.Before(stats.Weeks[j].Week) })
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Login < result[j].Login })
+	return result, nil
+}
+
+// weekStart returns the start of the week in which the given time falls: midnight UTC on the Sunday
+// before, or of the same day if it is a Sunday. The arithmetic is done on the Unix time stamp, day
+// zero of which, 1st January 1970, was a Thursday.
+func weekStart(t time.Time) time.Time {
+	days := t.Unix() / secondsPerDay
+	if t.Unix() < 0 && t.Unix()%secondsPerDay != 0 {
+		days-- // Division truncates towards zero, but we want the day on which the time falls
+	}
+	weekday := ((days+4)%7 + 7) % 7
+	return time.Unix((days-weekday)*secondsPerDay, 0).UTC()
+}
@@ -0,0 +1,80 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the contributor statistics demonstration
+
+// TestGetContributorStats confirms that the commits of the year are totalled by contributor and week.
+func TestGetContributorStats(t *testing.T) {
+
+	// Record the variables that the server receives, and serve a year's worth of history on one page
+	var variables map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		variables = body.Variables
+		w.Write([]byte(`{"data":{"repository":{"defaultBranchRef":{"target":{"history":{
+			"pageInfo":{"endCursor":"c5","hasNextPage":false},
+			"nodes":[
+				{"committedDate":"2024-07-06T23:59:59Z","additions":10,"deletions":2,"author":{"user":{"login":"octocat"}}},
+				{"committedDate":"2024-07-03T12:00:00Z","additions":5,"deletions":1,"author":{"user":{"login":"octocat"}}},
+				{"committedDate":"2024-06-30T00:00:00Z","additions":1,"deletions":0,"author":{"user":{"login":"octocat"}}},
+				{"committedDate":"2024-06-29T18:00:00Z","additions":40,"deletions":30,"author":{"user":{"login":"octocat"}}},
+				{"committedDate":"2024-07-02T08:00:00Z","additions":7,"deletions":7,"author":{"user":{"login":"hubot"}}},
+				{"committedDate":"2024-07-02T09:00:00Z","additions":99,"deletions":99,"author":{"user":null}}]}}}}}}`))
+	}))
+	defer server.Close()
+
+	stats, err := GetContributorStats(server.URL, "token test", "mikebway", "gogql", 2024)
+	assert.Nil(t, err, "Contributor stats query should not have failed")
+	assert.Equal(t, "2024-01-01T00:00:00Z", variables["since"], "The year should start on the 1st of January")
+	assert.Equal(t, "2025-01-01T00:00:00Z", variables["until"], "The year should end at the start of the next")
+
+	// The week starting Sunday 30th June holds three of octocat's commits, the Saturday before another
+	sunday := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []ContributorWeekStats{
+		{Login: "hubot", Weeks: []WeekData{{Week: sunday, Additions: 7, Deletions: 7, Commits: 1}}},
+		{Login: "octocat", Weeks: []WeekData{
+			{Week: sunday.AddDate(0, 0, -7), Additions: 40, Deletions: 30, Commits: 1},
+			{Week: sunday, Additions: 16, Deletions: 3, Commits: 3},
+		}},
+	}, stats, "Unattributed commits should have been left out")
+	week := stats[1].Weeks[1]
+	assert.Equal(t, 19, week.Additions+week.Deletions, "The lines changed that week should have been totalled")
+
+	// An empty repository has no default branch and so no contributors
+	server = serveFixture(`{"data":{"repository":{"defaultBranchRef":null}}}`)
+	defer server.Close()
+	stats, err = GetContributorStats(server.URL, "token test", "mikebway", "empty", 2024)
+	assert.Nil(t, err, "An empty repository should not fail the request")
+	assert.Equal(t, []ContributorWeekStats{}, stats)
+}
+
+// TestWeekStart confirms that times are placed in the week starting on the Sunday before.
+func TestWeekStart(t *testing.T) {
+
+	tests := map[time.Time]time.Time{
+		time.Date(2024, 7, 3, 12, 0, 0, 0, time.UTC):    time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC):    time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 6, 29, 23, 59, 59, 0, time.UTC): time.Date(2024, 6, 23, 0, 0, 0, 0, time.UTC),
+		time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC):     time.Date(1969, 12, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(1969, 12, 27, 6, 0, 0, 0, time.UTC):   time.Date(1969, 12, 21, 0, 0, 0, 0, time.UTC),
+	}
+	for when, expected := range tests {
+		assert.Equal(t, expected, weekStart(when), "Wrong week for %v", when)
+	}
+
+	// Time zones make no difference to the instant
+	pacific := time.FixedZone("PDT", -7*60*60)
+	assert.Equal(t, time.Date(2024, 7, 7, 0, 0, 0, 0, time.UTC), weekStart(time.Date(2024, 7, 6, 20, 0, 0, 0, pacific)))
+}
@@ -0,0 +1,188 @@
+package clientdemo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// Dependency is a structure type that represents a single package dependency declared by one of
+// the manifest files, e.g. go.mod or package.json, of a github repository.
+type Dependency struct {
+	PackageName        string // The name of the package depended upon
+	Version            string // The version requirement, e.g. "= 1.9.0" or "^4.17.1"
+	Manager            string // The package manager, e.g. GO or NPM
+	IsDirectDependency bool   // true if the manifest depends on the package directly rather than transitively
+	Manifest           string // The path of the manifest file that declared the dependency
+}
+
+// The Graphql query we use to retrieve a page of the dependency manifests of a repository along
+// with the first page of the dependencies of each
+var getDependencyManifestsQuery = `query FetchDependencyManifests($owner: String!, $name: String!, $first: Int!, $after: String) {
+	repository(owner: $owner, name: $name) {
+		dependencyGraphManifests(first: $first, after: $after) {
+			pageInfo {
+				endCursor
+				hasNextPage
+			}
+			nodes {
+				id
+				filename
+				dependencies(first: 100) {
+					pageInfo {
+						endCursor
+						hasNextPage
+					}
+					nodes {
+						packageName
+						requirements
+						packageManager
+						relationship
+					}
+				}
+			}
+		}
+	}
+}`
+
+// The Graphql query we use to retrieve the subsequent pages of the dependencies of a single manifest
+var getManifestDependenciesQuery = `query FetchManifestDependencies($id: ID!, $first: Int!, $after: String) {
+	node(id: $id) {
+		... on DependencyGraphManifest {
+			dependencies(first: $first, after: $after) {
+				pageInfo {
+					endCursor
+					hasNextPage
+				}
+				nodes {
+					packageName
+					requirements
+					packageManager
+					relationship
+				}
+			}
+		}
+	}
+}`
+
+// dependencyConnection is the JSON annotated structure of a page of the dependencies of a manifest,
+// shared by both of the dependency queries.
+type dependencyConnection struct {
+	PageInfo gqlclient.PageInfo `json:"pageInfo"`
+	Nodes    []struct {
+		PackageName    string `json:"packageName"`
+		Requirements   string `json:"requirements"`
+		PackageManager string `json:"packageManager"`
+		Relationship   string `json:"relationship"`
+	} `json:"nodes"`
+}
+
+// dependencyManifest is the JSON annotated structure of a single manifest.
+type dependencyManifest struct {
+	ID           string               `json:"id"`
+	Filename     string               `json:"filename"`
+	Dependencies dependencyConnection `json:"dependencies"`
+}
+
+// GetDependencyManifestsResponse is a JSON annotated structure used to parse each page of manifests into
+type GetDependencyManifestsResponse struct {
+	Repository struct {
+		DependencyGraphManifests struct {
+			PageInfo gqlclient.PageInfo   `json:"pageInfo"`
+			Nodes    []dependencyManifest `json:"nodes"`
+		} `json:"dependencyGraphManifests"`
+	} `json:"repository"`
+}
+
+// GetManifestDependenciesResponse is a JSON annotated structure used to parse each further page of the
+// dependencies of a manifest into
+type GetManifestDependenciesResponse struct {
+	Node struct {
+		Dependencies dependencyConnection `json:"dependencies"`
+	} `json:"node"`
+}
+
+// The number of manifests and dependencies requested per page. Each page of manifests also brings
+// the first 100 dependencies of each, so the manifest pages are kept small.
+const (
+	manifestPageSize   = 10
+	dependencyPageSize = 100
+)
+
+// GetRepositoryDependencies illustrates two levels of pagination by retrieving every dependency
+// declared by every manifest of a given repository from github's dependency graph. The manifests
+// are paged through first, each page bringing the first page of the dependencies of each manifest.
+// Any manifest with more dependencies than that then has the rest of its dependencies paged through
+// separately. The result is flattened into a single list.
+func GetRepositoryDependencies(githubAPIURL string, githubToken string, owner string, repoName string) ([]Dependency, error) {
+
+	// Construct a GraphQL client. The dependency graph has been a preview feature of the github
+	// API, only available to requests that ask for it with a custom media type.
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken, gqlclient.WithRequestHook(func(req *http.Request) error {
+		req.Header.Set("Accept", "application/vnd.github.hawkgirl-preview+json")
+		return nil
+	}))
+
+	// Page through the manifests
+	ctx := context.Background()
+	manifests, err := gqlclient.Paginate(ctx, client, gqlclient.PaginateRequest[dependencyManifest]{
+		QueryStr:   getDependencyManifestsQuery,
+		BaseParams: map[string]interface{}{"owner": owner, "name": repoName},
+		PageSize:   manifestPageSize,
+		ExtractPage: func(response *gqlclient.QueryResponse) ([]dependencyManifest, *gqlclient.PageInfo, error) {
+			var page GetDependencyManifestsResponse
+			if err := json.Unmarshal(*response.Data.(*json.RawMessage), &page); err != nil {
+				return nil, nil, err
+			}
+			return page.Repository.DependencyGraphManifests.Nodes, &page.Repository.DependencyGraphManifests.PageInfo, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Flatten the dependencies of each manifest into our result, fetching any that did not fit on
+	// the first page as we go
+	result := []Dependency{}
+	for _, manifest := range manifests {
+		result = appendDependencies(result, manifest.Filename, manifest.Dependencies)
+		if !manifest.Dependencies.PageInfo.HasNextPage {
+			continue
+		}
+		more, err := gqlclient.Paginate(ctx, client, gqlclient.PaginateRequest[Dependency]{
+			QueryStr:   getManifestDependenciesQuery,
+			BaseParams: map[string]interface{}{"id": manifest.ID, "after": manifest.Dependencies.PageInfo.EndCursor},
+			PageSize:   dependencyPageSize,
+			ExtractPage: func(response *gqlclient.QueryResponse) ([]Dependency, *gqlclient.PageInfo, error) {
+				var page GetManifestDependenciesResponse
+				if err := json.Unmarshal(*response.Data.(*json.RawMessage), &page); err != nil {
+					return nil, nil, err
+				}
+				return appendDependencies(nil, manifest.Filename, page.Node.Dependencies), &page.Node.Dependencies.PageInfo, nil
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, more...)
+	}
+	return result, nil
+}
+
+// appendDependencies translates a page of the dependencies of the named manifest into our simpler
+// result structure, appending them to the given list.
+func appendDependencies(deps []Dependency, manifest string, page dependencyConnection) []Dependency {
+	for _, d := range page.Nodes {
+		deps = append(deps, Dependency{
+			PackageName:        d.PackageName,
+			Version:            d.Requirements,
+			Manager:            d.PackageManager,
+			IsDirectDependency: strings.EqualFold(d.Relationship, "direct"),
+			Manifest:           manifest,
+		})
+	}
+	return deps
+}
@@ -0,0 +1,82 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the dependency graph demonstration
+
+// TestGetRepositoryDependencies confirms that the dependencies of every manifest are flattened
+// into a single list.
+func TestGetRepositoryDependencies(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"dependencyGraphManifests":{
+		"pageInfo":{"hasNextPage":false},
+		"nodes":[
+			{"id":"M1","filename":"go.mod","dependencies":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"packageName":"github.com/stretchr/testify","requirements":"= 1.9.0","packageManager":"GO","relationship":"direct"},
+				{"packageName":"gopkg.in/yaml.v3","requirements":"= 3.0.1","packageManager":"GO","relationship":"indirect"}]}},
+			{"id":"M2","filename":"web/package.json","dependencies":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"packageName":"express","requirements":"^4.17.1","packageManager":"NPM","relationship":"direct"},
+				{"packageName":"lodash","requirements":"^4.17.21","packageManager":"NPM","relationship":"direct"}]}}]}}}}`)
+	defer server.Close()
+
+	deps, err := GetRepositoryDependencies(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Dependencies query should not have failed")
+	assert.Equal(t, 4, len(deps), "All four dependencies should have been returned")
+
+	names := []string{}
+	for _, d := range deps {
+		names = append(names, d.PackageName)
+	}
+	assert.Equal(t, []string{"github.com/stretchr/testify", "gopkg.in/yaml.v3", "express", "lodash"}, names)
+	assert.Equal(t, Dependency{
+		PackageName:        "github.com/stretchr/testify",
+		Version:            "= 1.9.0",
+		Manager:            "GO",
+		IsDirectDependency: true,
+		Manifest:           "go.mod",
+	}, deps[0])
+	assert.False(t, deps[1].IsDirectDependency, "yaml.v3 is an indirect dependency")
+	assert.Equal(t, "web/package.json", deps[3].Manifest)
+}
+
+// TestGetRepositoryDependenciesPaging confirms that both the manifests and the dependencies of a
+// manifest are paged through.
+func TestGetRepositoryDependenciesPaging(t *testing.T) {
+
+	// Serve two pages of manifests, the first of which has a second page of dependencies
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch {
+		case body.Variables["id"] == "M1":
+			w.Write([]byte(`{"data":{"node":{"dependencies":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"packageName":"b","packageManager":"GO"}]}}}}`))
+		case body.Variables["after"] == "manifests1":
+			w.Write([]byte(`{"data":{"repository":{"dependencyGraphManifests":{"pageInfo":{"hasNextPage":false},"nodes":[
+				{"id":"M2","filename":"package.json","dependencies":{"pageInfo":{"hasNextPage":false},"nodes":[
+					{"packageName":"c","packageManager":"NPM"}]}}]}}}}`))
+		default:
+			w.Write([]byte(`{"data":{"repository":{"dependencyGraphManifests":{"pageInfo":{"endCursor":"manifests1","hasNextPage":true},"nodes":[
+				{"id":"M1","filename":"go.mod","dependencies":{"pageInfo":{"endCursor":"deps1","hasNextPage":true},"nodes":[
+					{"packageName":"a","packageManager":"GO"}]}}]}}}}`))
+		}
+	}))
+	defer server.Close()
+
+	deps, err := GetRepositoryDependencies(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Dependencies query should not have failed")
+	names := []string{}
+	for _, d := range deps {
+		names = append(names, d.Manifest+":"+d.PackageName)
+	}
+	assert.Equal(t, []string{"go.mod:a", "go.mod:b", "package.json:c"}, names)
+}
@@ -0,0 +1,56 @@
+package clientdemo
+
+import (
+	"fmt"
+	"time"
+)
+
+// DiffRepoData compares two snapshots of the same repository, as returned by GetRepoData(...) at
+// different times, and returns human readable descriptions of what has changed between them: the
+// name, owner, description, primary language, privacy and any commits that have appeared. An empty
+// list is returned if nothing has changed. Either snapshot may be nil, in which case the repository
+// is reported as having appeared or disappeared. Changes in disk usage and warnings are ignored as
+// noise.
+func DiffRepoData(before, after *RepoData) []string {
+
+	// Deal with missing snapshots first
+	changes := []string{}
+	switch {
+	case before == nil && after == nil:
+		return changes
+	case before == nil:
+		return append(changes, fmt.Sprintf("repository %s/%s appeared", after.Owner, after.Name))
+	case after == nil:
+		return append(changes, fmt.Sprintf("repository %s/%s disappeared", before.Owner, before.Name))
+	}
+
+	// Compare the simple fields
+	describe := func(field, was, now string) {
+		if was != now {
+			changes = append(changes, fmt.Sprintf("%s changed from %q to %q", field, was, now))
+		}
+	}
+	describe("name", before.Name, after.Name)
+	describe("owner", before.Owner, after.Owner)
+	describe("description", before.Description, after.Description)
+	describe("primary language", before.PrimaryLanguage, after.PrimaryLanguage)
+	if before.IsPrivate != after.IsPrivate {
+		if after.IsPrivate {
+			changes = append(changes, "repository made private")
+		} else {
+			changes = append(changes, "repository made public")
+		}
+	}
+
+	// Report any commits that were not in the earlier snapshot
+	seen := make(map[RepoCommit]bool, len(before.RecentCommits))
+	for _, commit := range before.RecentCommits {
+		seen[commit] = true
+	}
+	for _, commit := range after.RecentCommits {
+		if !seen[commit] {
+			changes = append(changes, fmt.Sprintf("new commit at %s: %s", commit.CommittedAt.Format(time.RFC3339), commit.Headline))
+		}
+	}
+	return changes
+}
@@ -0,0 +1,60 @@
+package clientdemo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the repository snapshot comparison
+
+// TestDiffRepoData confirms that changed fields and new commits are described.
+func TestDiffRepoData(t *testing.T) {
+
+	first := RepoCommit{CommittedAt: time.Date(2019, 6, 2, 10, 0, 0, 0, time.UTC), Headline: "First commit"}
+	second := RepoCommit{CommittedAt: time.Date(2019, 6, 3, 11, 0, 0, 0, time.UTC), Headline: "Second commit"}
+	before := &RepoData{
+		Name:            "gogql",
+		Owner:           "mikebway",
+		Description:     "A GraphQL client",
+		PrimaryLanguage: "Go",
+		DiskUsage:       100,
+		RecentCommits:   []RepoCommit{first},
+	}
+	after := &RepoData{
+		Name:            "gogql",
+		Owner:           "mikebway",
+		Description:     "A small GraphQL client",
+		PrimaryLanguage: "Go",
+		DiskUsage:       120,
+		IsPrivate:       true,
+		RecentCommits:   []RepoCommit{second, first},
+	}
+
+	assert.Equal(t, []string{
+		`description changed from "A GraphQL client" to "A small GraphQL client"`,
+		"repository made private",
+		"new commit at 2019-06-03T11:00:00Z: Second commit",
+	}, DiffRepoData(before, after))
+
+	// And back again, with the language changing too
+	before.PrimaryLanguage = "Shell"
+	assert.Equal(t, []string{
+		`description changed from "A small GraphQL client" to "A GraphQL client"`,
+		`primary language changed from "Go" to "Shell"`,
+		"repository made public",
+	}, DiffRepoData(after, before))
+
+	// Identical snapshots have nothing to report
+	assert.Equal(t, []string{}, DiffRepoData(after, after))
+}
+
+// TestDiffRepoDataNil confirms that missing snapshots are handled.
+func TestDiffRepoDataNil(t *testing.T) {
+
+	repo := &RepoData{Name: "gogql", Owner: "mikebway"}
+	assert.Equal(t, []string{}, DiffRepoData(nil, nil))
+	assert.Equal(t, []string{"repository mikebway/gogql appeared"}, DiffRepoData(nil, repo))
+	assert.Equal(t, []string{"repository mikebway/gogql disappeared"}, DiffRepoData(repo, nil))
+}
@@ -0,0 +1,128 @@
+package clientdemo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// maxFileContentSize is the size, in bytes, of the largest file whose content GetRepositoryFiles(...)
+// will return.
+const maxFileContentSize = 100 * 1024
+
+// RepositoryFile is a structure type that represents a single entry of a directory in a repository.
+type RepositoryFile struct {
+	Name    string  // The name of the file or directory
+	Type    string  // "blob" for a file, "tree" for a directory or "commit" for a submodule
+	Size    int     // The size of a file in bytes, zero for anything else
+	Content *string // The text of a file under 100KB, nil for directories, binary files and larger files
+}
+
+// The Graphql query we use to retrieve the entries of a directory. The expression names a git object
+// by ref and path, e.g. "main:gqlclient", and the object found may be of any git object type, so
+// inline fragments are needed to select the fields of a directory and, within that, of each file.
+var getRepositoryFilesQuery = `query FetchRepositoryFiles($owner: String!, $name: String!, $expression: String!) {
+	repository(owner: $owner, name: $name) {
+		object(expression: $expression) {
+			__typename
+			... on Tree {
+				entries {
+					name
+					type
+					size
+					object {
+						... on Blob {
+							byteSize
+							isBinary
+							text
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetRepositoryFilesResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The repository is null if it could not be found and the object is null if nothing exists at the path.
+type GetRepositoryFilesResponse struct {
+	Repository *struct {
+		Object *struct {
+			Typename string `json:"__typename"`
+			Entries  []struct {
+				Name   string `json:"name"`
+				Type   string `json:"type"`
+				Size   int    `json:"size"`
+				Object *struct {
+					ByteSize int     `json:"byteSize"`
+					IsBinary bool    `json:"isBinary"`
+					Text     *string `json:"text"`
+				} `json:"object"`
+			} `json:"entries"`
+		} `json:"object"`
+	} `json:"repository"`
+}
+
+// GetRepositoryFiles illustrates the use of a git object expression and nested inline fragments by
+// retrieving the files and directories found at a path of a repository as of the given ref, e.g. a
+// branch name, tag or commit SHA. An empty path lists the root of the repository. The text of each
+// file under 100KB is included; GraphQL cannot select a field conditionally, so the text of larger
+// files is fetched along with the rest but then discarded.
+func GetRepositoryFiles(githubAPIURL string, githubToken string, owner string, repoName string, path string, ref string) ([]RepositoryFile, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map, combining the ref and path into a git object expression
+	expression := ref + ":" + strings.Trim(path, "/")
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	queryParms["expression"] = &expression
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetRepositoryFilesResponse)}
+
+	// Run the query
+	err := client.Query(&getRepositoryFilesQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, but make sure that what we found was a directory
+	filesResponse, ok := response.Data.(*GetRepositoryFilesResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	if filesResponse.Repository == nil {
+		return nil, fmt.Errorf("repository not found: %s/%s", owner, repoName)
+	}
+	object := filesResponse.Repository.Object
+	if object == nil {
+		return nil, fmt.Errorf("path not found: %s", expression)
+	}
+	if object.Typename != "Tree" {
+		return nil, fmt.Errorf("%s is a %s, not a directory", expression, object.Typename)
+	}
+
+	// Translate the entries into our simpler result structure, keeping the text of small files only
+	files := make([]RepositoryFile, 0, len(object.Entries))
+	for _, entry := range object.Entries {
+		file := RepositoryFile{Name: entry.Name, Type: entry.Type}
+		if entry.Type == "blob" {
+			file.Size = entry.Size
+			if blob := entry.Object; blob != nil && !blob.IsBinary && blob.Text != nil && blob.ByteSize < maxFileContentSize {
+				file.Content = blob.Text
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
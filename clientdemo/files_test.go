@@ -0,0 +1,83 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the repository files demonstration
+
+// TestGetRepositoryFiles confirms that directory entries are translated and that only files are
+// given content.
+func TestGetRepositoryFiles(t *testing.T) {
+
+	// Capture the expression asked for while serving a directory holding a file and a subdirectory
+	var expression string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		expression, _ = body.Variables["expression"].(string)
+		w.Write([]byte(`{"data":{"repository":{"object":{"__typename":"Tree","entries":[
+			{"name":"README.md","type":"blob","size":12,"object":{"byteSize":12,"isBinary":false,"text":"# gogql demo"}},
+			{"name":"gqlclient","type":"tree","size":0,"object":{}}]}}}}`))
+	}))
+	defer server.Close()
+
+	files, err := GetRepositoryFiles(server.URL, "token test", "mikebway", "gogql", "/", "main")
+	assert.Nil(t, err, "Files query should not have failed")
+	assert.Equal(t, "main:", expression, "The root of the ref should have been asked for")
+	assert.Equal(t, 2, len(files), "Both entries should have been returned")
+	assert.Equal(t, "README.md", files[0].Name)
+	assert.Equal(t, "blob", files[0].Type)
+	assert.Equal(t, 12, files[0].Size)
+	assert.NotNil(t, files[0].Content, "The file should have had content")
+	assert.Equal(t, "# gogql demo", *files[0].Content)
+	assert.Equal(t, "gqlclient", files[1].Name)
+	assert.Equal(t, "tree", files[1].Type)
+	assert.Nil(t, files[1].Content, "The directory should not have had content")
+}
+
+// TestGetRepositoryFilesLarge confirms that the content of large and binary files is left out.
+func TestGetRepositoryFilesLarge(t *testing.T) {
+
+	large := strings.Repeat("x", maxFileContentSize)
+	server := serveFixture(`{"data":{"repository":{"object":{"__typename":"Tree","entries":[
+		{"name":"big.txt","type":"blob","size":102400,"object":{"byteSize":102400,"isBinary":false,"text":"` + large + `"}},
+		{"name":"logo.png","type":"blob","size":2048,"object":{"byteSize":2048,"isBinary":true,"text":null}}]}}}}`)
+	defer server.Close()
+
+	files, err := GetRepositoryFiles(server.URL, "token test", "mikebway", "gogql", "docs", "main")
+	assert.Nil(t, err, "Files query should not have failed")
+	assert.Equal(t, 2, len(files), "Both entries should have been returned")
+	assert.Equal(t, 102400, files[0].Size)
+	assert.Nil(t, files[0].Content, "The content of a large file should have been left out")
+	assert.Nil(t, files[1].Content, "The content of a binary file should have been left out")
+}
+
+// TestGetRepositoryFilesNotFound confirms that missing paths, and paths that are not directories, are reported.
+func TestGetRepositoryFilesNotFound(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"object":null}}}`)
+	defer server.Close()
+	files, err := GetRepositoryFiles(server.URL, "token test", "mikebway", "gogql", "nowhere/", "main")
+	assert.Nil(t, files)
+	assert.EqualError(t, err, "path not found: main:nowhere")
+
+	server = serveFixture(`{"data":{"repository":{"object":{"__typename":"Blob"}}}}`)
+	defer server.Close()
+	files, err = GetRepositoryFiles(server.URL, "token test", "mikebway", "gogql", "README.md", "main")
+	assert.Nil(t, files)
+	assert.EqualError(t, err, "main:README.md is a Blob, not a directory")
+
+	server = serveFixture(`{"data":{"repository":null}}`)
+	defer server.Close()
+	_, err = GetRepositoryFiles(server.URL, "token test", "mikebway", "i-dont-exist", "", "main")
+	assert.EqualError(t, err, "repository not found: mikebway/i-dont-exist")
+}
@@ -5,7 +5,7 @@ package clientdemo
 
 import (
 	"errors"
-	"strings"
+	"fmt"
 	"time"
 
 	"github.com/mikebway/gogql/gqlclient"
@@ -13,8 +13,10 @@ import (
 
 // RepoCommit is a structure type that represents a single commit to a github repository
 type RepoCommit struct {
+	OID         string    // The SHA of the commit
 	CommittedAt time.Time // The data and time at which the commit was made
 	Headline    string    // The headlin explanation of why the commit was made
+	Author      string    // The name of the author of the commit, empty if none was recorded
 }
 
 // RepoData is a structure used to return information about a single github repository.
@@ -26,7 +28,12 @@ type RepoData struct {
 	PrimaryLanguage string       // The language used for most of the code in the repository
 	DiskUsage       int          // The amount of storage required for the project in kilobytes
 	IsPrivate       bool         // true if the repository is private to the owner
+	IsFork          bool         // true if the repository is a fork of another
+	ParentRepo      string       // The owner/name of the repository this one was forked from, empty if not a fork
+	IsTemplate      bool         // true if the repository is a template for creating others
+	TemplateRepo    string       // The owner/name of the template this repository was created from, if any
 	RecentCommits   []RepoCommit // A list of the most recent commits (if any)
+	Warnings        []string     // Descriptions of any minor problems found in the response data
 }
 
 // The Graphql query we use to retrieve some data about a given repository
@@ -43,14 +50,26 @@ var getRepoDataQuery = `query FetchRepoInfo($owner: String!, $name: String!) {
 	  }
 	  diskUsage
 	  isPrivate
+	  isFork
+	  parent {
+			nameWithOwner
+	  }
+	  isTemplate
+	  templateRepository {
+			nameWithOwner
+	  }
 	  ref(qualifiedName: "master") {
 			target {
 		  	... on Commit {
 					history(first: 5) {
 						edges {
 							node {
+								oid
 								committedDate
 								messageHeadline
+								author {
+									name
+								}
 							}
 						}
 					}
@@ -60,7 +79,33 @@ var getRepoDataQuery = `query FetchRepoInfo($owner: String!, $name: String!) {
 	}
 }`
 
-// GetRepoDataResponse is a JSON annotated structure used to parse the response from the GraphQL call into
+// The lighter Graphql query used by GetRepoMetadata(...), omitting the commit history
+var getRepoMetadataQuery = `query FetchRepoMetadata($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) {
+	  name
+	  owner {
+			login
+	  }
+	  description
+	  createdAt
+	  primaryLanguage {
+			name
+	  }
+	  diskUsage
+	  isPrivate
+	  isFork
+	  parent {
+			nameWithOwner
+	  }
+	  isTemplate
+	  templateRepository {
+			nameWithOwner
+	  }
+	}
+}`
+
+// GetRepoDataResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The parent and template repository are null unless the repository was forked or generated from one.
 type GetRepoDataResponse struct {
 	Repository struct {
 		Name  string `json:"name"`
@@ -74,13 +119,25 @@ type GetRepoDataResponse struct {
 		} `json:"primaryLanguage"`
 		DiskUsage int  `json:"diskUsage"`
 		IsPrivate bool `json:"isPrivate"`
-		Ref       struct {
+		IsFork    bool `json:"isFork"`
+		Parent    *struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		} `json:"parent"`
+		IsTemplate         bool `json:"isTemplate"`
+		TemplateRepository *struct {
+			NameWithOwner string `json:"nameWithOwner"`
+		} `json:"templateRepository"`
+		Ref struct {
 			Target struct {
 				History struct {
 					Edges []struct {
 						Node struct {
+							OID             string `json:"oid"`
 							CommittedDate   string `json:"committedDate"`
 							MessageHeadline string `json:"messageHeadline"`
+							Author          *struct {
+								Name string `json:"name"`
+							} `json:"author"`
 						} `json:"node"`
 					} `json:"edges"`
 				} `json:"history"`
@@ -91,11 +148,27 @@ type GetRepoDataResponse struct {
 
 // GetRepoData serves the dual purpose of illustrating the use of the GraphQL
 // client and getting line coverage up when called from a unit test by retrieving
-// a few bits of data about a given repository.
-func GetRepoData(githubAPIURL string, githubToken string, owner string, repoName string) (*RepoData, error) {
+// a few bits of data about a given repository. Any ClientOption values are passed
+// on to the GraphQL client, e.g. to log the requests that it sends.
+func GetRepoData(githubAPIURL string, githubToken string, owner string, repoName string, opts ...gqlclient.ClientOption) (*RepoData, error) {
+	return fetchRepoData(githubAPIURL, githubToken, owner, repoName, &getRepoDataQuery, opts...)
+}
+
+// GetRepoMetadata retrieves the same information as GetRepoData(...) with the exception of the
+// recent commits. Walking the commit history is the most expensive part of the GetRepoData(...)
+// query so callers that only need the repository metadata can save on their API rate limit by
+// calling this instead. The RecentCommits field of the result will always be nil.
+func GetRepoMetadata(githubAPIURL string, githubToken string, owner string, repoName string) (*RepoData, error) {
+	return fetchRepoData(githubAPIURL, githubToken, owner, repoName, &getRepoMetadataQuery)
+}
+
+// fetchRepoData does the work for GetRepoData(...) and GetRepoMetadata(...), running the given
+// query and translating the response into a RepoData structure. Any part of the response that the
+// query did not ask for is simply left empty.
+func fetchRepoData(githubAPIURL string, githubToken string, owner string, repoName string, queryStr *string, opts ...gqlclient.ClientOption) (*RepoData, error) {
 
 	// Construct a GraphQL client
-	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken, opts...)
 
 	// Assemble the query parameters into a map
 	queryParms := make(map[string]interface{})
@@ -106,24 +179,14 @@ func GetRepoData(githubAPIURL string, githubToken string, owner string, repoName
 	response := gqlclient.QueryResponse{Data: new(GetRepoDataResponse)}
 
 	// Run the query
-	err := client.Query(&getRepoDataQuery, &queryParms, &response)
+	err := client.Query(queryStr, &queryParms, &response)
 	if err != nil {
 		return nil, err
 	}
 
 	// Were there any errors reported by the GraphQL service itself?
-	if response.Errors != nil {
-
-		// 	Assemble the error messages into a single string
-		var sb strings.Builder
-		sb.WriteString("Errors found in GraphQL Response:\n\n")
-		for _, e := range response.Errors {
-			sb.WriteString(e.Message)
-			sb.WriteString("\n")
-		}
-
-		// Report this back to the caller
-		return nil, errors.New(sb.String())
+	if err := response.Err(); err != nil {
+		return nil, err
 	}
 
 	// All is well, translate the query response into our simpler result structure
@@ -139,18 +202,41 @@ func GetRepoData(githubAPIURL string, githubToken string, owner string, repoName
 		PrimaryLanguage: repository.PrimaryLanguage.Name,
 		DiskUsage:       repository.DiskUsage,
 		IsPrivate:       repository.IsPrivate,
+		IsFork:          repository.IsFork,
+		IsTemplate:      repository.IsTemplate,
 	}
 
-	// The other stuff is more fiddly: parse the repo creation time
-	result.CreatedAt, _ = time.Parse(time.RFC3339, repository.CreatedAt)
+	// The parent and template are null unless the repository was forked or generated from one
+	if repository.Parent != nil {
+		result.ParentRepo = repository.Parent.NameWithOwner
+	}
+	if repository.TemplateRepository != nil {
+		result.TemplateRepo = repository.TemplateRepository.NameWithOwner
+	}
+
+	// The other stuff is more fiddly: parse the repo creation time. If we can't make sense of
+	// that then something is badly wrong with the response.
+	result.CreatedAt, err = time.Parse(time.RFC3339, repository.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse repository createdAt time: %v", err)
+	}
 
-	// Loop over the commit messages
+	// Loop over the commit messages. A commit with a bad time stamp is not worth failing the
+	// whole request for but we do let the caller know about it.
 	for _, c := range repository.Ref.Target.History.Edges {
-		committedDate, _ := time.Parse(time.RFC3339, c.Node.CommittedDate)
-		result.RecentCommits = append(result.RecentCommits, RepoCommit{
+		committedDate, err := time.Parse(time.RFC3339, c.Node.CommittedDate)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("could not parse committedDate of commit %q: %v", c.Node.MessageHeadline, err))
+		}
+		commit := RepoCommit{
+			OID:         c.Node.OID,
 			CommittedAt: committedDate,
 			Headline:    c.Node.MessageHeadline,
-		})
+		}
+		if c.Node.Author != nil {
+			commit.Author = c.Node.Author.Name
+		}
+		result.RecentCommits = append(result.RecentCommits, commit)
 	}
 
 	// And we are all done, return the result
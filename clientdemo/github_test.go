@@ -5,10 +5,15 @@ Package clientdemo illustrates how gqlclient can be used to access a github Grap
 package clientdemo
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/mikebway/gogql/gqlclient"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -84,4 +89,116 @@ func TestFailedQuery(t *testing.T) {
 	_, err := GetRepoData(githubAPIURL, authToken, "mikebway", "i-dont-exist")
 	assert.NotEmpty(t, err, "GetRepoData should have failed")
 	assert.Contains(t, err.Error(), "Errors found in GraphQL Response:", err.Error(), "GetRepoData should have reported GraphQL errors")
-}
\ No newline at end of file
+}
+
+// serveFixture returns a fake GraphQL server that responds to every request with the given JSON.
+func serveFixture(fixture string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(fixture))
+	}))
+}
+
+// TestMalformedTimestamps confirms that unparseable time stamps are reported rather than being
+// silently replaced by zero times.
+func TestMalformedTimestamps(t *testing.T) {
+
+	// A bad commit time should be reported as a warning
+	server := serveFixture(`{"data":{"repository":{"name":"gogql","createdAt":"2019-06-01T19:07:06Z",
+		"ref":{"target":{"history":{"edges":[
+			{"node":{"committedDate":"2019-06-02T10:00:00Z","messageHeadline":"Good commit"}},
+			{"node":{"committedDate":"yesterday","messageHeadline":"Bad commit"}}]}}}}}}`)
+	defer server.Close()
+
+	result, err := GetRepoData(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "A bad commit time should not fail the request")
+	assert.Equal(t, 2, len(result.RecentCommits), "Both commits should have been returned")
+	assert.Equal(t, 1, len(result.Warnings), "The bad commit time should have been reported")
+	assert.Contains(t, result.Warnings[0], "Bad commit", "The warning should identify the commit")
+
+	// A bad creation time should be an error
+	server = serveFixture(`{"data":{"repository":{"name":"gogql","createdAt":"June 1st"}}}`)
+	defer server.Close()
+
+	_, err = GetRepoData(server.URL, "token test", "mikebway", "gogql")
+	assert.NotNil(t, err, "A bad creation time should have been reported as an error")
+	assert.Contains(t, err.Error(), "createdAt", "The error should identify the field")
+}
+
+// TestStructuredGraphQLErrors confirms that GraphQL reported errors are returned in a form that
+// can be both read as text and examined programmatically.
+func TestStructuredGraphQLErrors(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":null},"errors":[{"message":"Could not resolve to a Repository with the name 'i-dont-exist'."}]}`)
+	defer server.Close()
+
+	_, err := GetRepoData(server.URL, "token test", "mikebway", "i-dont-exist")
+	assert.NotNil(t, err, "GetRepoData should have failed")
+	assert.Contains(t, err.Error(), "Errors found in GraphQL Response:", "The error text should be unchanged")
+	assert.Contains(t, err.Error(), "i-dont-exist", "The error text should include the GraphQL message")
+
+	// The structured errors should be available too
+	var multiErr *gqlclient.MultiGraphQLError
+	assert.True(t, errors.As(err, &multiErr), "Should have been able to unwrap a MultiGraphQLError")
+	assert.Equal(t, 1, len(multiErr.Errors), "There should have been a single GraphQL error")
+
+	var gqlErr *gqlclient.GraphQLError
+	assert.True(t, errors.As(err, &gqlErr), "Should have been able to unwrap a GraphQLError")
+	assert.Contains(t, gqlErr.Message, "Could not resolve to a Repository")
+}
+
+// TestGetRepoMetadata confirms that the lighter metadata query populates everything but the commits.
+func TestGetRepoMetadata(t *testing.T) {
+
+	// Record the query that the server receives
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"},
+			"description":"A basic GraphQL client library for Go","createdAt":"2019-06-01T19:07:06Z",
+			"primaryLanguage":{"name":"Go"},"diskUsage":123,"isPrivate":false}}}`))
+	}))
+	defer server.Close()
+
+	result, err := GetRepoMetadata(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Metadata query should not have failed")
+	assert.NotContains(t, received, "history", "The commit history should not have been requested")
+
+	// Check that the metadata is all present and correct
+	assert.Equal(t, "gogql", result.Name)
+	assert.Equal(t, "mikebway", result.Owner)
+	assert.Equal(t, "A basic GraphQL client library for Go", result.Description)
+	expectedCreatedAt, _ := time.Parse(time.RFC3339, "2019-06-01T19:07:06Z")
+	assert.Equal(t, expectedCreatedAt, result.CreatedAt)
+	assert.Equal(t, "Go", result.PrimaryLanguage)
+	assert.Equal(t, 123, result.DiskUsage)
+	assert.Nil(t, result.RecentCommits, "There should be no commits")
+}
+
+// TestGetRepoDataProvenance confirms that fork and template provenance is decoded, and that the null
+// parent and template of an original repository are handled.
+func TestGetRepoDataProvenance(t *testing.T) {
+
+	// A fork of a repository that was itself generated from a template
+	server := serveFixture(`{"data":{"repository":{"name":"gogql","owner":{"login":"octocat"},
+		"createdAt":"2019-06-01T19:07:06Z","isFork":true,"parent":{"nameWithOwner":"mikebway/gogql"},
+		"isTemplate":false,"templateRepository":{"nameWithOwner":"mikebway/go-template"}}}}`)
+	defer server.Close()
+	result, err := GetRepoData(server.URL, "token test", "octocat", "gogql")
+	assert.Nil(t, err, "Query should not have failed")
+	assert.True(t, result.IsFork)
+	assert.Equal(t, "mikebway/gogql", result.ParentRepo)
+	assert.False(t, result.IsTemplate)
+	assert.Equal(t, "mikebway/go-template", result.TemplateRepo)
+
+	// An original template repository has neither parent nor template
+	server = serveFixture(`{"data":{"repository":{"name":"go-template","owner":{"login":"mikebway"},
+		"createdAt":"2019-06-01T19:07:06Z","isFork":false,"parent":null,"isTemplate":true,"templateRepository":null}}}`)
+	defer server.Close()
+	result, err = GetRepoData(server.URL, "token test", "mikebway", "go-template")
+	assert.Nil(t, err, "Query should not have failed")
+	assert.False(t, result.IsFork)
+	assert.Empty(t, result.ParentRepo)
+	assert.True(t, result.IsTemplate)
+	assert.Empty(t, result.TemplateRepo)
+}
@@ -0,0 +1,92 @@
+package clientdemo
+
+import (
+	"errors"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// ErrMissingRepositoryID is returned by CreateIssue(...) when no repository ID is given.
+var ErrMissingRepositoryID = errors.New("the node ID of the repository in which to create the issue is required")
+
+// CreatedIssue is a structure type that describes an issue created by CreateIssue(...).
+type CreatedIssue struct {
+	ID     string // The node ID of the new issue
+	Number int    // The issue number within its repository
+	URL    string // The URL of the issue on github
+	Title  string // The title of the issue
+}
+
+// The Graphql mutation we use to create an issue. Note that mutations take their arguments as a
+// single input object.
+var createIssueMutation = `mutation CreateIssue($input: CreateIssueInput!) {
+	createIssue(input: $input) {
+		issue {
+			id
+			number
+			url
+			title
+		}
+	}
+}`
+
+// CreateIssueResponse is a JSON annotated structure used to parse the response from the GraphQL call into
+type CreateIssueResponse struct {
+	CreateIssue struct {
+		Issue struct {
+			ID     string `json:"id"`
+			Number int    `json:"number"`
+			URL    string `json:"url"`
+			Title  string `json:"title"`
+		} `json:"issue"`
+	} `json:"createIssue"`
+}
+
+// CreateIssue demonstrates a mutation by creating an issue in a repository, identified by its node
+// ID, i.e. the id field of the repository in the GraphQL API, with the given title, body and labels,
+// the latter also given as node IDs. The labels may be nil. Mutations are sent in exactly the same way as
+// queries; only the operation type in the query text differs. The token must have been granted the
+// repo scope, or public_repo for public repositories.
+func CreateIssue(githubAPIURL string, githubToken string, repositoryID string, title string, body string, labelIDs []string) (*CreatedIssue, error) {
+
+	// There is no point troubling github if we don't know where the issue is to go
+	if repositoryID == "" {
+		return nil, ErrMissingRepositoryID
+	}
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the mutation input into a map, leaving out the labels if there are none
+	input := map[string]interface{}{
+		"repositoryId": repositoryID,
+		"title":        title,
+		"body":         body,
+	}
+	if len(labelIDs) > 0 {
+		input["labelIds"] = labelIDs
+	}
+	queryParms := map[string]interface{}{"input": input}
+
+	// Establish a place to recieve the results of the mutation
+	response := gqlclient.QueryResponse{Data: new(CreateIssueResponse)}
+
+	// Run the mutation
+	err := client.Query(&createIssueMutation, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, return the details of the new issue
+	issueResponse, ok := response.Data.(*CreateIssueResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	issue := issueResponse.CreateIssue.Issue
+	return &CreatedIssue{ID: issue.ID, Number: issue.Number, URL: issue.URL, Title: issue.Title}, nil
+}
@@ -0,0 +1,60 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the issue creation demonstration
+
+// TestCreateIssue confirms that the mutation input is sent and the new issue returned.
+func TestCreateIssue(t *testing.T) {
+
+	// Record what we are sent
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&request)
+		w.Write([]byte(`{"data":{"createIssue":{"issue":{"id":"I_kwDOAbc","number":42,
+			"url":"https://github.com/mikebway/gogql/issues/42","title":"Something is broken"}}}}`))
+	}))
+	defer server.Close()
+
+	issue, err := CreateIssue(server.URL, "token test", "R_kgDOAbc", "Something is broken", "Details here", []string{"LA_bug"})
+	assert.Nil(t, err, "Issue creation should not have failed")
+	assert.Equal(t, &CreatedIssue{ID: "I_kwDOAbc", Number: 42, URL: "https://github.com/mikebway/gogql/issues/42", Title: "Something is broken"}, issue)
+
+	assert.Contains(t, request.Query, "mutation CreateIssue($input: CreateIssueInput!)", "A mutation should have been sent")
+	assert.Equal(t, map[string]interface{}{
+		"repositoryId": "R_kgDOAbc",
+		"title":        "Something is broken",
+		"body":         "Details here",
+		"labelIds":     []interface{}{"LA_bug"},
+	}, request.Variables["input"])
+
+	// Without labels, the labelIds field should be left out
+	_, err = CreateIssue(server.URL, "token test", "R_kgDOAbc", "Something is broken", "", nil)
+	assert.Nil(t, err, "Issue creation should not have failed")
+	assert.NotContains(t, request.Variables["input"], "labelIds")
+}
+
+// TestCreateIssueMissingRepository confirms that no request is made without a repository ID.
+func TestCreateIssueMissingRepository(t *testing.T) {
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+
+	issue, err := CreateIssue(server.URL, "token test", "", "Something is broken", "", nil)
+	assert.Nil(t, issue)
+	assert.Equal(t, ErrMissingRepositoryID, err)
+	assert.Equal(t, 0, calls, "No request should have been made")
+}
@@ -0,0 +1,124 @@
+package clientdemo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// MilestoneState selects which milestones GetRepoMilestones(...) should return.
+type MilestoneState string
+
+// The milestone states that may be requested
+const (
+	MilestoneStateOpen   MilestoneState = "OPEN"   // Only milestones that are still open
+	MilestoneStateClosed MilestoneState = "CLOSED" // Only milestones that have been closed
+	MilestoneStateAll    MilestoneState = "ALL"    // All milestones, open or closed
+)
+
+// Milestone is a structure type that represents a single github repository milestone.
+type Milestone struct {
+	Title        string     // The milestone title
+	Description  string     // The milestone description
+	DueOn        *time.Time // The date on which the milestone is due, nil if it has no due date
+	ClosedAt     *time.Time // The date and time at which the milestone was closed, nil if it is still open
+	OpenIssues   int        // The number of open issues associated with the milestone
+	ClosedIssues int        // The number of closed issues associated with the milestone
+}
+
+// The Graphql query we use to retrieve the milestones of a repository. The states variable is a
+// list of GraphQL enum values; passing null rather than a list returns milestones in every state.
+var getRepoMilestonesQuery = `query FetchRepoMilestones($owner: String!, $name: String!, $states: [MilestoneState!]) {
+	repository(owner: $owner, name: $name) {
+		milestones(first: 100, states: $states) {
+			nodes {
+				title
+				description
+				dueOn
+				closedAt
+				openIssues: issues(states: OPEN) {
+					totalCount
+				}
+				closedIssues: issues(states: CLOSED) {
+					totalCount
+				}
+			}
+		}
+	}
+}`
+
+// GetRepoMilestonesResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// Note that the nullable date fields are declared as pointers so that a JSON null leaves them nil rather than
+// being confused with a real time.
+type GetRepoMilestonesResponse struct {
+	Repository struct {
+		Milestones struct {
+			Nodes []struct {
+				Title       string     `json:"title"`
+				Description string     `json:"description"`
+				DueOn       *time.Time `json:"dueOn"`
+				ClosedAt    *time.Time `json:"closedAt"`
+				OpenIssues  struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"openIssues"`
+				ClosedIssues struct {
+					TotalCount int `json:"totalCount"`
+				} `json:"closedIssues"`
+			} `json:"nodes"`
+		} `json:"milestones"`
+	} `json:"repository"`
+}
+
+// GetRepoMilestones illustrates the use of GraphQL enum arguments and nullable fields by retrieving
+// up to 100 milestones of a given repository, filtered by state.
+func GetRepoMilestones(githubAPIURL string, githubToken string, owner string, repoName string, state MilestoneState) ([]Milestone, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map. Enum values are passed as plain strings.
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	switch state {
+	case MilestoneStateOpen, MilestoneStateClosed:
+		queryParms["states"] = []MilestoneState{state}
+	case MilestoneStateAll:
+		queryParms["states"] = nil
+	default:
+		return nil, errors.New("unrecognized milestone state: " + string(state))
+	}
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetRepoMilestonesResponse)}
+
+	// Run the query
+	err := client.Query(&getRepoMilestonesQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	milestonesResponse, ok := response.Data.(*GetRepoMilestonesResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	result := []Milestone{}
+	for _, m := range milestonesResponse.Repository.Milestones.Nodes {
+		result = append(result, Milestone{
+			Title:        m.Title,
+			Description:  m.Description,
+			DueOn:        m.DueOn,
+			ClosedAt:     m.ClosedAt,
+			OpenIssues:   m.OpenIssues.TotalCount,
+			ClosedIssues: m.ClosedIssues.TotalCount,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package clientdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the milestone demonstration
+
+// TestGetRepoMilestones confirms that milestones are translated correctly, including nullable dates.
+func TestGetRepoMilestones(t *testing.T) {
+
+	// Fake a response containing an open milestone with no due date and a closed one with one
+	var variables map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		variables = body.Variables
+		w.Write([]byte(`{"data":{"repository":{"milestones":{"nodes":[
+			{"title":"v1.0","description":"First release","dueOn":null,"closedAt":null,
+			 "openIssues":{"totalCount":3},"closedIssues":{"totalCount":7}},
+			{"title":"v0.9","description":"Beta","dueOn":"2019-07-01T00:00:00Z","closedAt":"2019-06-28T12:30:00Z",
+			 "openIssues":{"totalCount":0},"closedIssues":{"totalCount":12}}]}}}}`))
+	}))
+	defer server.Close()
+
+	milestones, err := GetRepoMilestones(server.URL, "token test", "mikebway", "gogql", MilestoneStateOpen)
+	assert.Nil(t, err, "Milestone query should not have failed")
+	assert.Equal(t, []interface{}{"OPEN"}, variables["states"], "The state should have been sent as an enum list")
+	assert.Equal(t, 2, len(milestones), "There should have been two milestones")
+
+	// The first milestone has no dates at all
+	assert.Equal(t, "v1.0", milestones[0].Title)
+	assert.Equal(t, "First release", milestones[0].Description)
+	assert.Nil(t, milestones[0].DueOn, "A null due date should be nil")
+	assert.Nil(t, milestones[0].ClosedAt, "A null closed time should be nil")
+	assert.Equal(t, 3, milestones[0].OpenIssues)
+	assert.Equal(t, 7, milestones[0].ClosedIssues)
+
+	// The second has both
+	dueOn, _ := time.Parse(time.RFC3339, "2019-07-01T00:00:00Z")
+	assert.NotNil(t, milestones[1].DueOn, "The due date should have been populated")
+	assert.True(t, dueOn.Equal(*milestones[1].DueOn), "The due date does not match")
+	assert.NotNil(t, milestones[1].ClosedAt, "The closed time should have been populated")
+	assert.Equal(t, 12, milestones[1].ClosedIssues)
+
+	// Asking for all milestones should send a null list of states
+	_, err = GetRepoMilestones(server.URL, "token test", "mikebway", "gogql", MilestoneStateAll)
+	assert.Nil(t, err, "Milestone query should not have failed")
+	state, present := variables["states"]
+	assert.True(t, present, "The states variable should have been sent")
+	assert.Nil(t, state, "The states variable should have been null")
+
+	// And nonsense should be rejected
+	_, err = GetRepoMilestones(server.URL, "token test", "mikebway", "gogql", MilestoneState("OPENISH"))
+	assert.NotNil(t, err, "An unrecognized state should have been rejected")
+}
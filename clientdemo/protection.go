@@ -0,0 +1,109 @@
+package clientdemo
+
+import (
+	"errors"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// repoScope is the OAuth scope that a token must have been granted to read branch protection rules,
+// which also requires the user to have admin access to the repository
+const repoScope = "repo"
+
+// BranchProtection is a structure type that represents the protection rule applied to a branch of a
+// github repository.
+type BranchProtection struct {
+	RequiresStatusChecks         bool     // true if status checks must pass before merging
+	RequiresPullRequestReviews   bool     // true if pull requests must be approved before merging
+	DismissesStaleReviews        bool     // true if new commits dismiss existing approvals
+	RequiredApprovingReviewCount int      // The number of approvals required before merging
+	RequiredStatusCheckContexts  []string // The names of the status checks that must pass
+}
+
+// The Graphql query we use to retrieve the protection rule of a branch
+var getBranchProtectionQuery = `query FetchBranchProtection($owner: String!, $name: String!, $branch: String!) {
+	repository(owner: $owner, name: $name) {
+		ref(qualifiedName: $branch) {
+			branchProtectionRule {
+				requiresStatusChecks
+				requiresApprovingReviews
+				dismissesStaleReviews
+				requiredApprovingReviewCount
+				requiredStatusCheckContexts
+			}
+		}
+	}
+}`
+
+// GetBranchProtectionResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The ref is null if the branch does not exist, and the rule is null if the branch is not protected.
+type GetBranchProtectionResponse struct {
+	Repository struct {
+		Ref *struct {
+			BranchProtectionRule *struct {
+				RequiresStatusChecks         bool     `json:"requiresStatusChecks"`
+				RequiresApprovingReviews     bool     `json:"requiresApprovingReviews"`
+				DismissesStaleReviews        bool     `json:"dismissesStaleReviews"`
+				RequiredApprovingReviewCount int      `json:"requiredApprovingReviewCount"`
+				RequiredStatusCheckContexts  []string `json:"requiredStatusCheckContexts"`
+			} `json:"branchProtectionRule"`
+		} `json:"ref"`
+	} `json:"repository"`
+}
+
+// GetBranchProtection illustrates a permission sensitive query by retrieving the protection rule of
+// a given branch of a repository. Branch protection rules can only be read by repository admins
+// using a token that has been granted the repo scope; github reports a FORBIDDEN GraphQL error
+// otherwise, which is returned as a *ScopeMissingError. If the branch is not protected, nil is
+// returned without an error.
+func GetBranchProtection(githubAPIURL string, githubToken string, owner string, repoName string, branchName string) (*BranchProtection, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	queryParms["branch"] = "refs/heads/" + branchName
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetBranchProtectionResponse)}
+
+	// Run the query
+	err := client.Query(&getBranchProtectionQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself? A FORBIDDEN error means that
+	// we are not allowed to see the rule.
+	if err := scopeError(&response, repoScope); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	protectionResponse, ok := response.Data.(*GetBranchProtectionResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	ref := protectionResponse.Repository.Ref
+	if ref == nil {
+		return nil, errors.New("branch not found: " + branchName)
+	}
+	rule := ref.BranchProtectionRule
+	if rule == nil {
+		return nil, nil
+	}
+	contexts := rule.RequiredStatusCheckContexts
+	if contexts == nil {
+		contexts = []string{}
+	}
+	return &BranchProtection{
+		RequiresStatusChecks:         rule.RequiresStatusChecks,
+		RequiresPullRequestReviews:   rule.RequiresApprovingReviews,
+		DismissesStaleReviews:        rule.DismissesStaleReviews,
+		RequiredApprovingReviewCount: rule.RequiredApprovingReviewCount,
+		RequiredStatusCheckContexts:  contexts,
+	}, nil
+}
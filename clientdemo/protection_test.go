@@ -0,0 +1,61 @@
+package clientdemo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the branch protection demonstration
+
+// TestGetBranchProtection confirms that a protection rule is translated.
+func TestGetBranchProtection(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"ref":{"branchProtectionRule":{
+		"requiresStatusChecks":true,"requiresApprovingReviews":true,"dismissesStaleReviews":false,
+		"requiredApprovingReviewCount":2,"requiredStatusCheckContexts":["ci/build","ci/test"]}}}}}`)
+	defer server.Close()
+
+	protection, err := GetBranchProtection(server.URL, "token test", "mikebway", "gogql", "master")
+	assert.Nil(t, err, "Branch protection query should not have failed")
+	assert.Equal(t, &BranchProtection{
+		RequiresStatusChecks:         true,
+		RequiresPullRequestReviews:   true,
+		DismissesStaleReviews:        false,
+		RequiredApprovingReviewCount: 2,
+		RequiredStatusCheckContexts:  []string{"ci/build", "ci/test"},
+	}, protection)
+}
+
+// TestGetBranchProtectionMissing confirms the handling of unprotected and unknown branches.
+func TestGetBranchProtectionMissing(t *testing.T) {
+
+	// An unprotected branch has no rule, and that is not an error
+	server := serveFixture(`{"data":{"repository":{"ref":{"branchProtectionRule":null}}}}`)
+	defer server.Close()
+	protection, err := GetBranchProtection(server.URL, "token test", "mikebway", "gogql", "master")
+	assert.Nil(t, err, "An unprotected branch should not be an error")
+	assert.Nil(t, protection, "An unprotected branch should have no protection")
+
+	// An unknown branch is
+	server = serveFixture(`{"data":{"repository":{"ref":null}}}`)
+	defer server.Close()
+	protection, err = GetBranchProtection(server.URL, "token test", "mikebway", "gogql", "no-such-branch")
+	assert.Nil(t, protection)
+	assert.Equal(t, "branch not found: no-such-branch", err.Error())
+}
+
+// TestGetBranchProtectionForbidden confirms that a FORBIDDEN error is reported as a missing scope.
+func TestGetBranchProtectionForbidden(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"ref":{"branchProtectionRule":null}}},"errors":[{"type":"FORBIDDEN",
+		"path":["repository","ref","branchProtectionRule"],"message":"Resource not accessible by integration"}]}`)
+	defer server.Close()
+
+	protection, err := GetBranchProtection(server.URL, "token test", "mikebway", "gogql", "master")
+	assert.Nil(t, protection, "No protection should have been returned")
+	var scopeErr *ScopeMissingError
+	assert.True(t, errors.As(err, &scopeErr), "A missing scope error should have been returned")
+	assert.Equal(t, "repo", scopeErr.Scope)
+}
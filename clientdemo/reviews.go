@@ -0,0 +1,145 @@
+package clientdemo
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// Review is a structure type that represents a single review of a github pull request.
+type Review struct {
+	Author      string          // The login of the reviewer, empty if their account has been deleted
+	State       string          // The outcome of the review, e.g. "APPROVED" or "CHANGES_REQUESTED"
+	SubmittedAt time.Time       // The date and time at which the review was submitted, zero if it is still pending
+	Comments    []ReviewComment // The comments made on the code as part of the review
+}
+
+// ReviewComment is a structure type that represents a single comment made on the code of a pull
+// request as part of a review.
+type ReviewComment struct {
+	Body string // The text of the comment
+	Path string // The path of the file commented on
+	Line int    // The line of the file commented on, zero if the comment is outdated
+}
+
+// The Graphql query we use to retrieve the reviews of a pull request, each with its comments
+var getPullRequestReviewsQuery = `query FetchPullRequestReviews($owner: String!, $name: String!, $number: Int!) {
+	repository(owner: $owner, name: $name) {
+		pullRequest(number: $number) {
+			reviews(first: 50) {
+				edges {
+					node {
+						author {
+							login
+						}
+						state
+						submittedAt
+						comments(first: 50) {
+							edges {
+								node {
+									body
+									path
+									line
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetPullRequestReviewsResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The pull request is null if the repository has no pull request with the requested number, the author
+// is null for deleted accounts, the submission time is null for pending reviews, and the line is null
+// for comments on code that has since changed.
+type GetPullRequestReviewsResponse struct {
+	Repository struct {
+		PullRequest *struct {
+			Reviews struct {
+				Edges []struct {
+					Node struct {
+						Author *struct {
+							Login string `json:"login"`
+						} `json:"author"`
+						State       string     `json:"state"`
+						SubmittedAt *time.Time `json:"submittedAt"`
+						Comments    struct {
+							Edges []struct {
+								Node struct {
+									Body string `json:"body"`
+									Path string `json:"path"`
+									Line *int   `json:"line"`
+								} `json:"node"`
+							} `json:"edges"`
+						} `json:"comments"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"reviews"`
+		} `json:"pullRequest"`
+	} `json:"repository"`
+}
+
+// GetPullRequestReviews illustrates a query of nested connections, a connection within each node of
+// another, by retrieving up to 50 reviews of a given pull request, each with up to 50 of its comments.
+func GetPullRequestReviews(githubAPIURL string, githubToken string, owner string, repoName string, prNumber int) ([]Review, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+	queryParms["number"] = prNumber
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetPullRequestReviewsResponse)}
+
+	// Run the query
+	err := client.Query(&getPullRequestReviewsQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	reviewsResponse, ok := response.Data.(*GetPullRequestReviewsResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	pullRequest := reviewsResponse.Repository.PullRequest
+	if pullRequest == nil {
+		return nil, fmt.Errorf("pull request not found: #%d", prNumber)
+	}
+	result := []Review{}
+	for _, reviewEdge := range pullRequest.Reviews.Edges {
+		r := reviewEdge.Node
+		review := Review{State: r.State, Comments: []ReviewComment{}}
+		if r.Author != nil {
+			review.Author = r.Author.Login
+		}
+		if r.SubmittedAt != nil {
+			review.SubmittedAt = *r.SubmittedAt
+		}
+
+		// Then the next level down, the comments of the review
+		for _, commentEdge := range r.Comments.Edges {
+			c := commentEdge.Node
+			comment := ReviewComment{Body: c.Body, Path: c.Path}
+			if c.Line != nil {
+				comment.Line = *c.Line
+			}
+			review.Comments = append(review.Comments, comment)
+		}
+		result = append(result, review)
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package clientdemo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the pull request reviews demonstration
+
+// TestGetPullRequestReviews confirms that reviews and their nested comments are translated.
+func TestGetPullRequestReviews(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"pullRequest":{"reviews":{"edges":[
+		{"node":{"author":{"login":"octocat"},"state":"CHANGES_REQUESTED","submittedAt":"2024-07-01T09:30:00Z",
+			"comments":{"edges":[
+				{"node":{"body":"Please check the error here","path":"gqlclient/gqlclient.go","line":42}},
+				{"node":{"body":"This no longer applies","path":"README.md","line":null}}]}}},
+		{"node":{"author":null,"state":"PENDING","submittedAt":null,"comments":{"edges":[]}}}]}}}}}`)
+	defer server.Close()
+
+	reviews, err := GetPullRequestReviews(server.URL, "token test", "mikebway", "gogql", 7)
+	assert.Nil(t, err, "Reviews query should not have failed")
+	assert.Equal(t, []Review{
+		{
+			Author:      "octocat",
+			State:       "CHANGES_REQUESTED",
+			SubmittedAt: time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC),
+			Comments: []ReviewComment{
+				{Body: "Please check the error here", Path: "gqlclient/gqlclient.go", Line: 42},
+				{Body: "This no longer applies", Path: "README.md", Line: 0},
+			},
+		},
+		{State: "PENDING", Comments: []ReviewComment{}},
+	}, reviews)
+}
+
+// TestGetPullRequestReviewsMissing confirms that an unknown pull request is reported.
+func TestGetPullRequestReviewsMissing(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"pullRequest":null}},"errors":[]}`)
+	defer server.Close()
+
+	reviews, err := GetPullRequestReviews(server.URL, "token test", "mikebway", "gogql", 9999)
+	assert.Nil(t, reviews)
+	assert.EqualError(t, err, "pull request not found: #9999")
+}
@@ -0,0 +1,85 @@
+package clientdemo
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// ErrScopesNotReported is returned by CheckTokenScopes(...) when github does not report the scopes
+// of the token, as is the case for fine-grained personal access tokens and GitHub App tokens.
+var ErrScopesNotReported = errors.New("github did not report the OAuth scopes of the access token")
+
+// impliedScopes lists, for each github OAuth scope that includes others, the scopes that it includes.
+var impliedScopes = map[string][]string{
+	"repo":             {"repo:status", "repo_deployment", "public_repo", "repo:invite", "security_events"},
+	"admin:org":        {"write:org", "read:org"},
+	"write:org":        {"read:org"},
+	"admin:public_key": {"write:public_key", "read:public_key"},
+	"write:public_key": {"read:public_key"},
+	"admin:repo_hook":  {"write:repo_hook", "read:repo_hook"},
+	"write:repo_hook":  {"read:repo_hook"},
+	"admin:gpg_key":    {"write:gpg_key", "read:gpg_key"},
+	"write:gpg_key":    {"read:gpg_key"},
+	"user":             {"read:user", "user:email", "user:follow"},
+	"write:packages":   {"read:packages"},
+	"project":          {"read:project"},
+}
+
+// CheckTokenScopes confirms that a github access token has been granted the OAuth scopes that the
+// caller is going to need, e.g. "repo" or "security_events", giving early and actionable feedback
+// rather than a confusing permission error later on. A lightweight query is made and the scopes
+// reported by github in the X-OAuth-Scopes response header are compared against those required,
+// allowing for scopes such as repo that include others. The required scopes that the token lacks
+// are returned, an empty list meaning that all is well.
+//
+// If github rejects the token altogether, ErrTokenRejected is returned. If github does not report
+// the token's scopes, ErrScopesNotReported is returned.
+func CheckTokenScopes(githubAPIURL string, githubToken string, required []string) ([]string, error) {
+
+	// Construct a GraphQL client that keeps an eye out for the scopes header
+	var scopesHeader []string
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken, gqlclient.WithAfterResponse(
+		func(req *http.Request, resp *http.Response, body []byte) {
+			scopesHeader = resp.Header.Values("X-OAuth-Scopes")
+		}))
+
+	// Run the smallest query we know, translating an authorization failure into something more helpful
+	response := gqlclient.QueryResponse{Data: new(GetViewerResponse)}
+	err := client.Query(&getViewerQuery, nil, &response)
+	if err != nil {
+		if errors.As(err, &gqlclient.AuthError{}) {
+			return nil, ErrTokenRejected
+		}
+		return nil, err
+	}
+	if scopesHeader == nil {
+		return nil, ErrScopesNotReported
+	}
+
+	// Collect the scopes that we have, including those that come with them
+	granted := make(map[string]bool)
+	for _, value := range scopesHeader {
+		for _, scope := range strings.Split(value, ",") {
+			scope = strings.TrimSpace(scope)
+			if scope == "" {
+				continue
+			}
+			granted[scope] = true
+			for _, implied := range impliedScopes[scope] {
+				granted[implied] = true
+			}
+		}
+	}
+
+	// And work out what is missing
+	missing := []string{}
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	return missing, nil
+}
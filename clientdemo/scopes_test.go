@@ -0,0 +1,67 @@
+package clientdemo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the token scope check
+
+// newScopesServer returns a fake github server that reports the given scopes header, if any.
+func newScopesServer(scopes *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes != nil {
+			w.Header().Set("X-OAuth-Scopes", *scopes)
+		}
+		w.Write([]byte(`{"data":{"viewer":{"login":"mikebway"}}}`))
+	}))
+}
+
+// TestCheckTokenScopes confirms that missing scopes are detected, allowing for implied scopes.
+func TestCheckTokenScopes(t *testing.T) {
+
+	scopes := "repo, read:user, admin:org"
+	server := newScopesServer(&scopes)
+	defer server.Close()
+
+	// Directly granted, implied and missing scopes
+	missing, err := CheckTokenScopes(server.URL, "token test", []string{"repo", "security_events", "read:org", "gist", "workflow"})
+	assert.Nil(t, err, "Scope check should not have failed")
+	assert.Equal(t, []string{"gist", "workflow"}, missing)
+
+	// Everything present
+	missing, err = CheckTokenScopes(server.URL, "token test", []string{"read:user", "public_repo"})
+	assert.Nil(t, err, "Scope check should not have failed")
+	assert.Equal(t, []string{}, missing)
+}
+
+// TestCheckTokenScopesNotReported confirms that tokens whose scopes github does not report, and
+// tokens with no scopes at all, are told apart.
+func TestCheckTokenScopesNotReported(t *testing.T) {
+
+	server := newScopesServer(nil)
+	defer server.Close()
+	_, err := CheckTokenScopes(server.URL, "token test", []string{"repo"})
+	assert.Equal(t, ErrScopesNotReported, err)
+
+	noScopes := ""
+	server = newScopesServer(&noScopes)
+	defer server.Close()
+	missing, err := CheckTokenScopes(server.URL, "token test", []string{"repo"})
+	assert.Nil(t, err, "A token with no scopes is still a valid token")
+	assert.Equal(t, []string{"repo"}, missing)
+}
+
+// TestCheckTokenScopesRejected confirms that a rejected token is reported as such.
+func TestCheckTokenScopesRejected(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	_, err := CheckTokenScopes(server.URL, "token test", []string{"repo"})
+	assert.Equal(t, ErrTokenRejected, err)
+}
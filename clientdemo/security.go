@@ -0,0 +1,138 @@
+package clientdemo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// securityEventsScope is the OAuth scope that a token must have been granted to read vulnerability alerts
+const securityEventsScope = "security_events"
+
+// SecurityAlert is a structure type that represents a single dependency vulnerability alert raised
+// against a github repository.
+type SecurityAlert struct {
+	PackageName          string     // The name of the vulnerable package
+	AffectedVersionRange string     // The range of package versions that are vulnerable, e.g. "< 1.2.3"
+	FixedIn              string     // The first version in which the vulnerability was fixed, empty if there is none yet
+	Severity             string     // The severity of the vulnerability, e.g. "LOW" or "CRITICAL"
+	DismissedAt          *time.Time // The date and time at which the alert was dismissed, nil if it is still active
+}
+
+// ScopeMissingError is the error returned when the github token supplied to a demonstration function
+// has not been granted an OAuth scope that the query requires.
+type ScopeMissingError struct {
+	Scope   string // The name of the OAuth scope that the token lacks
+	Message string // The message reported by the GraphQL service
+}
+
+// Error describes the missing scope.
+func (e *ScopeMissingError) Error() string {
+	return "github token lacks the " + e.Scope + " OAuth scope: " + e.Message
+}
+
+// The Graphql query we use to retrieve the vulnerability alerts of a repository
+var getRepoSecurityAlertsQuery = `query FetchRepoSecurityAlerts($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) {
+		vulnerabilityAlerts(first: 100) {
+			nodes {
+				dismissedAt
+				securityVulnerability {
+					package {
+						name
+					}
+					vulnerableVersionRange
+					firstPatchedVersion {
+						identifier
+					}
+					severity
+				}
+			}
+		}
+	}
+}`
+
+// GetRepoSecurityAlertsResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The first patched version is null if no fix has been released, hence the pointer.
+type GetRepoSecurityAlertsResponse struct {
+	Repository struct {
+		VulnerabilityAlerts struct {
+			Nodes []struct {
+				DismissedAt           *time.Time `json:"dismissedAt"`
+				SecurityVulnerability struct {
+					Package struct {
+						Name string `json:"name"`
+					} `json:"package"`
+					VulnerableVersionRange string `json:"vulnerableVersionRange"`
+					FirstPatchedVersion    *struct {
+						Identifier string `json:"identifier"`
+					} `json:"firstPatchedVersion"`
+					Severity string `json:"severity"`
+				} `json:"securityVulnerability"`
+			} `json:"nodes"`
+		} `json:"vulnerabilityAlerts"`
+	} `json:"repository"`
+}
+
+// GetRepositorySecurityAlerts illustrates the handling of queries that need more than the default
+// OAuth scopes by retrieving up to 100 vulnerability alerts of a given repository. Reading alerts
+// requires a token that has been granted the security_events scope; github reports a FORBIDDEN
+// GraphQL error if it has not, which is returned as a *ScopeMissingError.
+func GetRepositorySecurityAlerts(githubAPIURL string, githubToken string, owner string, repoName string) ([]SecurityAlert, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetRepoSecurityAlertsResponse)}
+
+	// Run the query
+	err := client.Query(&getRepoSecurityAlertsQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself? A FORBIDDEN error means that
+	// our token was not granted the scope we need.
+	if err := scopeError(&response, securityEventsScope); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	alertsResponse, ok := response.Data.(*GetRepoSecurityAlertsResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	result := []SecurityAlert{}
+	for _, a := range alertsResponse.Repository.VulnerabilityAlerts.Nodes {
+		alert := SecurityAlert{
+			PackageName:          a.SecurityVulnerability.Package.Name,
+			AffectedVersionRange: a.SecurityVulnerability.VulnerableVersionRange,
+			Severity:             a.SecurityVulnerability.Severity,
+			DismissedAt:          a.DismissedAt,
+		}
+		if a.SecurityVulnerability.FirstPatchedVersion != nil {
+			alert.FixedIn = a.SecurityVulnerability.FirstPatchedVersion.Identifier
+		}
+		result = append(result, alert)
+	}
+	return result, nil
+}
+
+// scopeError returns a *ScopeMissingError naming the given scope if the GraphQL service reported a
+// FORBIDDEN or INSUFFICIENT_SCOPES error in the response, or any other errors it reported as returned
+// by response.Err().
+func scopeError(response *gqlclient.QueryResponse, scope string) error {
+	for _, ge := range response.Errors {
+		if ge.Type == "FORBIDDEN" || ge.Code() == "FORBIDDEN" || ge.Type == "INSUFFICIENT_SCOPES" {
+			return &ScopeMissingError{Scope: scope, Message: ge.Message}
+		}
+	}
+	return response.Err()
+}
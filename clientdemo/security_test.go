@@ -0,0 +1,47 @@
+package clientdemo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the repository security alerts demonstration
+
+// TestGetRepositorySecurityAlerts confirms that alerts are translated, including those with no fix
+// and those that have been dismissed.
+func TestGetRepositorySecurityAlerts(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"vulnerabilityAlerts":{"nodes":[
+		{"dismissedAt":null,"securityVulnerability":{"package":{"name":"lodash"},
+			"vulnerableVersionRange":"< 4.17.21","firstPatchedVersion":{"identifier":"4.17.21"},"severity":"HIGH"}},
+		{"dismissedAt":"2021-03-04T05:06:07Z","securityVulnerability":{"package":{"name":"left-pad"},
+			"vulnerableVersionRange":">= 0","firstPatchedVersion":null,"severity":"LOW"}}]}}}}`)
+	defer server.Close()
+
+	alerts, err := GetRepositorySecurityAlerts(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Security alerts query should not have failed")
+	assert.Equal(t, 2, len(alerts), "Both alerts should have been returned")
+	assert.Equal(t, SecurityAlert{PackageName: "lodash", AffectedVersionRange: "< 4.17.21", FixedIn: "4.17.21", Severity: "HIGH"}, alerts[0])
+	dismissed := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.Equal(t, "left-pad", alerts[1].PackageName)
+	assert.Equal(t, "", alerts[1].FixedIn, "An unfixed vulnerability should have no fixed version")
+	assert.True(t, dismissed.Equal(*alerts[1].DismissedAt), "Dismissal time should have been decoded")
+}
+
+// TestGetRepositorySecurityAlertsForbidden confirms that a FORBIDDEN error is reported as a missing scope.
+func TestGetRepositorySecurityAlertsForbidden(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"vulnerabilityAlerts":null}},"errors":[{"type":"FORBIDDEN",
+		"path":["repository","vulnerabilityAlerts"],"message":"Resource not accessible by integration"}]}`)
+	defer server.Close()
+
+	alerts, err := GetRepositorySecurityAlerts(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, alerts, "No alerts should have been returned")
+	var scopeErr *ScopeMissingError
+	assert.True(t, errors.As(err, &scopeErr), "A missing scope error should have been returned")
+	assert.Equal(t, "security_events", scopeErr.Scope)
+	assert.Equal(t, "github token lacks the security_events OAuth scope: Resource not accessible by integration", err.Error())
+}
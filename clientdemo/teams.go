@@ -0,0 +1,106 @@
+package clientdemo
+
+import (
+	"errors"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// readOrgScope is the OAuth scope that a token must have been granted to read organization teams
+const readOrgScope = "read:org"
+
+// TeamMember is a structure type that represents a single member of a github organization team.
+type TeamMember struct {
+	Login     string // The github login of the member
+	Name      string // The member's name, empty if they have not given one
+	Role      string // The member's role within the team, "MEMBER" or "MAINTAINER"
+	AvatarURL string // The URL of the member's avatar image
+}
+
+// The Graphql query we use to retrieve the members of a team
+var getTeamMembersQuery = `query FetchTeamMembers($org: String!, $slug: String!) {
+	organization(login: $org) {
+		team(slug: $slug) {
+			members(first: 100) {
+				edges {
+					role
+					node {
+						login
+						name
+						avatarUrl
+					}
+				}
+			}
+		}
+	}
+}`
+
+// GetTeamMembersResponse is a JSON annotated structure used to parse the response from the GraphQL call into.
+// The team is null if the organization has no team with the requested slug. The role of a member is
+// a property of their membership of the team, and so is found on the edge rather than the node.
+type GetTeamMembersResponse struct {
+	Organization struct {
+		Team *struct {
+			Members struct {
+				Edges []struct {
+					Role string `json:"role"`
+					Node struct {
+						Login     string `json:"login"`
+						Name      string `json:"name"`
+						AvatarURL string `json:"avatarUrl"`
+					} `json:"node"`
+				} `json:"edges"`
+			} `json:"members"`
+		} `json:"team"`
+	} `json:"organization"`
+}
+
+// GetTeamMembers illustrates a query of organization data by retrieving up to 100 members of a
+// given team of a github organization. Reading teams requires a token that has been granted the
+// read:org scope; github reports an INSUFFICIENT_SCOPES GraphQL error if it has not, which is
+// returned as a *ScopeMissingError.
+func GetTeamMembers(githubAPIURL string, githubToken string, orgName string, teamSlug string) ([]TeamMember, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["org"] = &orgName
+	queryParms["slug"] = &teamSlug
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetTeamMembersResponse)}
+
+	// Run the query
+	err := client.Query(&getTeamMembersQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself? An INSUFFICIENT_SCOPES error
+	// means that our token was not granted the scope we need.
+	if err := scopeError(&response, readOrgScope); err != nil {
+		return nil, err
+	}
+
+	// All is well, translate the query response into our simpler result structure
+	teamResponse, ok := response.Data.(*GetTeamMembersResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	team := teamResponse.Organization.Team
+	if team == nil {
+		return nil, errors.New("team not found: " + teamSlug)
+	}
+	result := []TeamMember{}
+	for _, edge := range team.Members.Edges {
+		result = append(result, TeamMember{
+			Login:     edge.Node.Login,
+			Name:      edge.Node.Name,
+			Role:      edge.Role,
+			AvatarURL: edge.Node.AvatarURL,
+		})
+	}
+	return result, nil
+}
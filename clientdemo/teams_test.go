@@ -0,0 +1,48 @@
+package clientdemo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the team members demonstration
+
+// TestGetTeamMembers confirms that team members are translated.
+func TestGetTeamMembers(t *testing.T) {
+
+	server := serveFixture(`{"data":{"organization":{"team":{"members":{"edges":[
+		{"role":"MAINTAINER","node":{"login":"mikebway","name":"Mike Broadway","avatarUrl":"https://avatars.example.com/u/1"}},
+		{"role":"MEMBER","node":{"login":"octocat","name":null,"avatarUrl":"https://avatars.example.com/u/2"}}]}}}}}`)
+	defer server.Close()
+
+	members, err := GetTeamMembers(server.URL, "token test", "gogql-org", "maintainers")
+	assert.Nil(t, err, "Team members query should not have failed")
+	assert.Equal(t, []TeamMember{
+		{Login: "mikebway", Name: "Mike Broadway", Role: "MAINTAINER", AvatarURL: "https://avatars.example.com/u/1"},
+		{Login: "octocat", Name: "", Role: "MEMBER", AvatarURL: "https://avatars.example.com/u/2"},
+	}, members)
+
+	// An unknown team is an error
+	server = serveFixture(`{"data":{"organization":{"team":null}}}`)
+	defer server.Close()
+	members, err = GetTeamMembers(server.URL, "token test", "gogql-org", "no-such-team")
+	assert.Nil(t, members)
+	assert.Equal(t, "team not found: no-such-team", err.Error())
+}
+
+// TestGetTeamMembersScope confirms that an INSUFFICIENT_SCOPES error is reported as a missing scope.
+func TestGetTeamMembersScope(t *testing.T) {
+
+	server := serveFixture(`{"errors":[{"type":"INSUFFICIENT_SCOPES","locations":[{"line":2,"column":2}],
+		"message":"Your token has not been granted the required scopes to execute this query."}]}`)
+	defer server.Close()
+
+	members, err := GetTeamMembers(server.URL, "token test", "gogql-org", "maintainers")
+	assert.Nil(t, members, "No members should have been returned")
+	var scopeErr *ScopeMissingError
+	assert.True(t, errors.As(err, &scopeErr), "A missing scope error should have been returned")
+	assert.Equal(t, "read:org", scopeErr.Scope)
+	assert.Contains(t, scopeErr.Error(), "required scopes")
+}
@@ -0,0 +1,72 @@
+package clientdemo
+
+import (
+	"errors"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// The Graphql query we use to retrieve the topics of a repository
+var getRepoTopicsQuery = `query FetchRepoTopics($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) {
+		repositoryTopics(first: 100) {
+			nodes {
+				topic {
+					name
+				}
+			}
+		}
+	}
+}`
+
+// GetRepoTopicsResponse is a JSON annotated structure used to parse the response from the GraphQL call into
+type GetRepoTopicsResponse struct {
+	Repository struct {
+		RepositoryTopics struct {
+			Nodes []struct {
+				Topic struct {
+					Name string `json:"name"`
+				} `json:"topic"`
+			} `json:"nodes"`
+		} `json:"repositoryTopics"`
+	} `json:"repository"`
+}
+
+// GetRepoTopics is about as simple as a list query can be: it retrieves the names of up to 100 topics
+// that a repository has been tagged with, flattening the nested node structure of the response into
+// a plain list of strings.
+func GetRepoTopics(githubAPIURL string, githubToken string, owner string, repoName string) ([]string, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Assemble the query parameters into a map
+	queryParms := make(map[string]interface{})
+	queryParms["owner"] = &owner
+	queryParms["name"] = &repoName
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetRepoTopicsResponse)}
+
+	// Run the query
+	err := client.Query(&getRepoTopicsQuery, &queryParms, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return nil, err
+	}
+
+	// All is well, pull the topic names out of their nodes
+	topicsResponse, ok := response.Data.(*GetRepoTopicsResponse)
+	if !ok {
+		return nil, errors.New("Response did not contain the expected structure")
+	}
+	topics := []string{}
+	for _, node := range topicsResponse.Repository.RepositoryTopics.Nodes {
+		topics = append(topics, node.Topic.Name)
+	}
+	return topics, nil
+}
@@ -0,0 +1,28 @@
+package clientdemo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the repository topics demonstration
+
+// TestGetRepoTopics confirms that topic names are flattened out of their nodes.
+func TestGetRepoTopics(t *testing.T) {
+
+	server := serveFixture(`{"data":{"repository":{"repositoryTopics":{"nodes":[
+		{"topic":{"name":"graphql"}},{"topic":{"name":"go"}},{"topic":{"name":"github-api"}}]}}}}`)
+	defer server.Close()
+
+	topics, err := GetRepoTopics(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Topics query should not have failed")
+	assert.Equal(t, []string{"graphql", "go", "github-api"}, topics)
+
+	// A repository with no topics should give an empty list rather than nil
+	server = serveFixture(`{"data":{"repository":{"repositoryTopics":{"nodes":[]}}}}`)
+	defer server.Close()
+	topics, err = GetRepoTopics(server.URL, "token test", "mikebway", "gogql")
+	assert.Nil(t, err, "Topics query should not have failed")
+	assert.Equal(t, []string{}, topics)
+}
@@ -0,0 +1,58 @@
+package clientdemo
+
+import (
+	"errors"
+
+	"github.com/mikebway/gogql/gqlclient"
+)
+
+// ErrTokenRejected is returned by GetViewer(...) when github refuses the access token.
+var ErrTokenRejected = errors.New("github access token is invalid or lacks the required scope")
+
+// The Graphql query we use to identify the owner of the access token. This is about as small as a
+// useful query can be.
+var getViewerQuery = `query {
+	viewer {
+		login
+	}
+}`
+
+// GetViewerResponse is a JSON annotated structure used to parse the response from the GraphQL call into
+type GetViewerResponse struct {
+	Viewer struct {
+		Login string `json:"login"`
+	} `json:"viewer"`
+}
+
+// GetViewer returns the login of the github user that the access token belongs to. This makes a
+// convenient sanity check that a token works before attempting anything more ambitious. If github
+// rejects the token, ErrTokenRejected is returned.
+func GetViewer(githubAPIURL string, githubToken string) (string, error) {
+
+	// Construct a GraphQL client
+	client := gqlclient.CreateClient(githubAPIURL, &githubToken)
+
+	// Establish a place to recieve the results of the query
+	response := gqlclient.QueryResponse{Data: new(GetViewerResponse)}
+
+	// Run the query, translating an authorization failure into something more helpful
+	err := client.Query(&getViewerQuery, nil, &response)
+	if err != nil {
+		if errors.As(err, &gqlclient.AuthError{}) {
+			return "", ErrTokenRejected
+		}
+		return "", err
+	}
+
+	// Were there any errors reported by the GraphQL service itself?
+	if err := response.Err(); err != nil {
+		return "", err
+	}
+
+	// All is well, return the login
+	viewerResponse, ok := response.Data.(*GetViewerResponse)
+	if !ok {
+		return "", errors.New("Response did not contain the expected structure")
+	}
+	return viewerResponse.Viewer.Login, nil
+}
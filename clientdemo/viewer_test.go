@@ -0,0 +1,48 @@
+package clientdemo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the viewer demonstration
+
+// TestGetViewer confirms that the real github API identifies the owner of our token.
+func TestGetViewer(t *testing.T) {
+
+	// This test needs a real token
+	if len(os.Getenv("GITHUB_TOKEN")) == 0 {
+		t.Skip("GITHUB_TOKEN environment variable is not set")
+	}
+
+	login, err := GetViewer(githubAPIURL, getAuthorization(t))
+	assert.Nil(t, err, "github graphql invocation should not have failed")
+	assert.NotEmpty(t, login, "The viewer login should have been returned")
+}
+
+// TestGetViewerFake confirms that the login is extracted from a fake response.
+func TestGetViewerFake(t *testing.T) {
+
+	server := serveFixture(`{"data":{"viewer":{"login":"mikebway"}}}`)
+	defer server.Close()
+
+	login, err := GetViewer(server.URL, "token test")
+	assert.Nil(t, err, "Viewer query should not have failed")
+	assert.Equal(t, "mikebway", login)
+}
+
+// TestGetViewerRejected confirms that a 401 response is reported as a rejected token.
+func TestGetViewerRejected(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := GetViewer(server.URL, "token this-aint-no-party")
+	assert.Equal(t, ErrTokenRejected, err, "A 401 should have been reported as a rejected token")
+}
@@ -5,16 +5,23 @@ package main
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/mikebway/gogql/clientdemo"
+	"github.com/mikebway/gogql/gqlclient"
 )
 
+// Path of a JSON file providing values for the other flags; set by command line flag
+var configFile string
+
 // URL of the github service GraphQL API; set by command line flag
 var githubURL string
 
@@ -30,6 +37,12 @@ var repoOwner string
 // The name of the repository to be evaluated
 var repoName string
 
+// True if to print the body of each GraphQL request before it is sent; set by command line flag
+var dumpRequest bool
+
+// Where request bodies are printed, overridable by unit tests
+var dumpWriter io.Writer = os.Stderr
+
 // We allow unti testing to override program exit handling
 var exitDemo = func(code int) {
 	os.Exit(code)
@@ -40,11 +53,7 @@ var exitDemo = func(code int) {
 func main() {
 
 	// Declare our command line flags
-	flag.StringVar(&githubURL, "github", "https://api.github.com/graphql", "URL of the github service GraphQL API")
-	flag.StringVar(&tokenVarName, "token-env", "GITHUB_TOKEN", "The name of the environment variable that provides the github access token")
-	flag.StringVar(&repoOwner, "owner", "mikebway", "The organization or user that owns the repository to be evaluated")
-	flag.StringVar(&repoName, "name", "gogql", "The name of the repository to be evaluated")
-	flag.BoolVar(&disableCertificateVerification, "skipverify", false, "Use to to skip SSL certificate verification")
+	declareFlags()
 	defaultUsage := flag.Usage
 	flag.Usage = func() {
 		defaultUsage()
@@ -57,16 +66,29 @@ func main() {
 		fmt.Println("You can use the -token-env command line flag to override the name of the")
 		fmt.Println("envvironment variable and so support more than one token value for multiple")
 		fmt.Println("github services (i.e. public and corporate).")
+		fmt.Println()
+		fmt.Println("Any of the other flags may instead be given in a JSON file named by the")
+		fmt.Println("-config flag, e.g. {\"owner\": \"mikebway\", \"skipverify\": true}. Flags given")
+		fmt.Println("on the command line take precedence over values from the file.")
 	}
 
 	// Parse the command line. Note that we have to pass the arguments because we are
 	// not useing the default flags.Parse() function.
 	flag.Parse()
 
+	// Fill in anything not given on the command line from the config file, if there is one
+	err := applyConfigFile(configFile)
+	if err != nil {
+		fmt.Printf("GraphQL Client Demo FAILED:\n\n %v\n\n", err)
+		flag.Usage()
+		exitDemo(2)
+		return
+	}
+
 	// For the sake of easier unit testing, separate the actual work of the demo into
 	// parameterized function. Likewise, we don't use os.Exit(n) directly so that
 	// unit tests can oveeride that behavior
-	err := runDemo(githubURL, repoOwner, repoName, disableCertificateVerification)
+	err = runDemo(githubURL, repoOwner, repoName, disableCertificateVerification)
 	if err != nil {
 		fmt.Printf("GraphQL Client Demo FAILED:\n\n %v\n\n", err)
 		flag.Usage()
@@ -77,6 +99,75 @@ func main() {
 	}
 }
 
+// Declare the command line flags, binding them to our package variables
+func declareFlags() {
+	flag.StringVar(&configFile, "config", "", "The path of a JSON file providing values for any of the other flags")
+	flag.StringVar(&githubURL, "github", "https://api.github.com/graphql", "URL of the github service GraphQL API")
+	flag.StringVar(&tokenVarName, "token-env", "GITHUB_TOKEN", "The name of the environment variable that provides the github access token")
+	flag.StringVar(&repoOwner, "owner", "mikebway", "The organization or user that owns the repository to be evaluated")
+	flag.StringVar(&repoName, "name", "gogql", "The name of the repository to be evaluated")
+	flag.BoolVar(&disableCertificateVerification, "skipverify", false, "Use to to skip SSL certificate verification")
+	flag.BoolVar(&dumpRequest, "dump-request", false, "Print the JSON body of the GraphQL request to stderr before it is sent")
+}
+
+// demoConfig is the structure of the JSON config file named by the -config flag. Its field
+// names match the command line flags; fields left out of the file are nil.
+type demoConfig struct {
+	Github      *string `json:"github"`
+	TokenEnv    *string `json:"token-env"`
+	Owner       *string `json:"owner"`
+	Name        *string `json:"name"`
+	SkipVerify  *bool   `json:"skipverify"`
+	DumpRequest *bool   `json:"dump-request"`
+}
+
+// Load the JSON config file at the given path, if the path is not empty, and apply its values to
+// our package variables for any flags that were not explicitly set on the command line
+func applyConfigFile(path string) error {
+
+	// Nothing to do if we have not been given a file
+	if path == "" {
+		return nil
+	}
+
+	// Load and parse the file
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %v", err)
+	}
+	var config demoConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("could not parse config file %s: %v", path, err)
+	}
+
+	// Find out which flags were given on the command line; these win
+	setOnCommandLine := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	// Apply the file values for everything else
+	if config.Github != nil && !setOnCommandLine["github"] {
+		githubURL = *config.Github
+	}
+	if config.TokenEnv != nil && !setOnCommandLine["token-env"] {
+		tokenVarName = *config.TokenEnv
+	}
+	if config.Owner != nil && !setOnCommandLine["owner"] {
+		repoOwner = *config.Owner
+	}
+	if config.Name != nil && !setOnCommandLine["name"] {
+		repoName = *config.Name
+	}
+	if config.SkipVerify != nil && !setOnCommandLine["skipverify"] {
+		disableCertificateVerification = *config.SkipVerify
+	}
+	if config.DumpRequest != nil && !setOnCommandLine["dump-request"] {
+		dumpRequest = *config.DumpRequest
+	}
+	return nil
+}
+
 // Do the actual work of the demo as a function that can be more easily unit tested
 func runDemo(githubURL, repoOwner, repoName string, disableCertificateVerification bool) error {
 
@@ -100,8 +191,17 @@ func runDemo(githubURL, repoOwner, repoName string, disableCertificateVerificati
 		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
 
+	// If asked to, show the exact JSON that is sent. The token travels in a header, not the body,
+	// so there is no danger of it being printed.
+	var opts []gqlclient.ClientOption
+	if dumpRequest {
+		opts = append(opts, gqlclient.WithRequestBodyLogger(func(body []byte) {
+			fmt.Fprintf(dumpWriter, "GraphQL request body:\n%s\n", body)
+		}))
+	}
+
 	// Have our client demonstration package do the real work
-	result, err := clientdemo.GetRepoData(githubURL, githubAuthorization, repoOwner, repoName)
+	result, err := clientdemo.GetRepoData(githubURL, githubAuthorization, repoOwner, repoName, opts...)
 	if err != nil {
 		return err
 	}
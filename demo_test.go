@@ -4,11 +4,17 @@ Package main demonstrates how gqlclient can be used to access a GrapghQL Query A
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
 	"flag"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -158,3 +164,69 @@ func setTLSClientConfig(newConfig *tls.Config) *tls.Config {
 	// Return the orinal status, true if verification was previously disabled
 	return original
 }
+
+// TestConfigFile confirms that values from a config file take effect where no flag was given,
+// and that flags given on the command line take precedence.
+func TestConfigFile(t *testing.T) {
+
+	// Override exit handling from the main() function, restoring after we are done
+	overrideFlagsAndExitHandling()
+	defer restoreExitHandling()
+
+	// Write a config file
+	path := filepath.Join(t.TempDir(), "demo.json")
+	config := `{"github": "https://github.example.com/api/graphql", "token-env": "CORP_TOKEN",
+		"owner": "someone", "name": "something", "skipverify": true}`
+	assert.Nil(t, ioutil.WriteFile(path, []byte(config), 0600), "Could not write config file")
+
+	// Parse a command line that names the file and overrides the repository name
+	declareFlags()
+	err := flag.CommandLine.Parse([]string{"-config", path, "-name", "gogql"})
+	assert.Nil(t, err, "Command line should have parsed")
+	err = applyConfigFile(configFile)
+	assert.Nil(t, err, "Config file should have been applied")
+
+	// The file values should have been used, except where the flag was given
+	assert.Equal(t, "https://github.example.com/api/graphql", githubURL)
+	assert.Equal(t, "CORP_TOKEN", tokenVarName)
+	assert.Equal(t, "someone", repoOwner)
+	assert.Equal(t, "gogql", repoName, "The command line flag should have taken precedence")
+	assert.True(t, disableCertificateVerification)
+
+	// A broken file should be reported
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{not json"), 0600), "Could not write config file")
+	assert.NotNil(t, applyConfigFile(path), "A malformed config file should have been reported")
+	assert.NotNil(t, applyConfigFile(path+".missing"), "A missing config file should have been reported")
+
+	// Put the token variable name back the way the other tests expect
+	tokenVarName = "GITHUB_TOKEN"
+}
+
+// TestDumpRequest confirms that the -dump-request flag prints the body of the GraphQL request,
+// holding the packed query and its variables but not the token.
+func TestDumpRequest(t *testing.T) {
+
+	// Serve a canned repository in place of github
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"},"createdAt":"2019-06-01T19:07:06Z"}}}`))
+	}))
+	defer server.Close()
+
+	// Capture the dump, putting everything back the way it was afterwards
+	var dumped bytes.Buffer
+	defer func(w io.Writer, dump bool) { dumpWriter, dumpRequest = w, dump }(dumpWriter, dumpRequest)
+	dumpWriter, dumpRequest = &dumped, true
+	defer os.Setenv("DUMP_TEST_TOKEN", os.Getenv("DUMP_TEST_TOKEN"))
+	os.Setenv("DUMP_TEST_TOKEN", "not-a-real-secret")
+	defer func(name string) { tokenVarName = name }(tokenVarName)
+	tokenVarName = "DUMP_TEST_TOKEN"
+
+	err := runDemo(server.URL, testOwner, testRepoName, false)
+	assert.Nil(t, err, "Should not have been an error running the demo")
+	output := dumped.String()
+	assert.True(t, strings.HasPrefix(output, "GraphQL request body:\n"), "The dump should have been labelled")
+	assert.Contains(t, output, `"query":"query FetchRepoInfo($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { name`)
+	assert.Contains(t, output, `"owner":"mikebway"`)
+	assert.Contains(t, output, `"name":"gogql"`)
+	assert.NotContains(t, output, "not-a-real-secret", "The token should not have been dumped")
+}
@@ -0,0 +1,56 @@
+package gqlclient
+
+import (
+	"context"
+	"sync"
+)
+
+// AuthRefreshFunc is a function that obtains a fresh authorization header value, e.g. a new
+// "Bearer ..." token, to replace one that the GraphQL server has rejected.
+type AuthRefreshFunc func(ctx context.Context) (*string, error)
+
+// WithAuthRefresh is a ClientOption that allows a client to recover from an expired token. When the
+// GraphQL server responds with a 401 UNAUTHORIZED, refreshFn is called to obtain a new authorization
+// header value, which replaces the one held by the client for this and all subsequent requests, and
+// the query is tried again exactly once. Should the new token also be refused, the AuthError is
+// returned to the caller. An error returned by refreshFn is returned as is.
+//
+// Refreshing is safe for a client shared between goroutines. Multipart UploadQuery(...) requests
+// cannot be replayed, so while a 401 in response to an upload still refreshes the token, the upload
+// itself is not retried.
+func WithAuthRefresh(refreshFn AuthRefreshFunc) ClientOption {
+	return func(gc *gqlClient) {
+		gc.authRefresh = refreshFn
+		if gc.authMutex == nil {
+			gc.authMutex = new(sync.RWMutex)
+		}
+	}
+}
+
+// authHeader returns the authorization header value that should accompany the next request.
+func (gc *gqlClient) authHeader() *string {
+	if gc.authMutex == nil {
+		return gc.authorization
+	}
+	gc.authMutex.RLock()
+	defer gc.authMutex.RUnlock()
+	return gc.authorization
+}
+
+// refreshAuth replaces the authorization header value with a new one obtained from the refresh
+// function, provided that no other goroutine has done so already since the rejected value was sent.
+func (gc *gqlClient) refreshAuth(ctx context.Context, rejected *string) error {
+	gc.authMutex.Lock()
+	defer gc.authMutex.Unlock()
+
+	// If someone else got in first, there is no need to refresh again
+	if gc.authorization != rejected {
+		return nil
+	}
+	authorization, err := gc.authRefresh(ctx)
+	if err != nil {
+		return err
+	}
+	gc.authorization = authorization
+	return nil
+}
@@ -0,0 +1,95 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for authorization refresh.
+
+// newAuthServer returns a fake GraphQL server that accepts only the given authorization value,
+// counting the requests it receives.
+func newAuthServer(accept string, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if r.Header.Get("Authorization") != accept {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+}
+
+// TestAuthRefresh confirms that a rejected token is refreshed and the query retried with the new one.
+func TestAuthRefresh(t *testing.T) {
+
+	var calls int32
+	server := newAuthServer("Bearer fresh", &calls)
+	defer server.Close()
+
+	var refreshes int32
+	expired := "Bearer expired"
+	client := CreateClient(server.URL, &expired, WithAuthRefresh(func(ctx context.Context) (*string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		fresh := "Bearer fresh"
+		return &fresh, nil
+	}))
+
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should have succeeded with the refreshed token")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "The token should have been refreshed exactly once")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "The server should have been called twice")
+
+	// The fresh token should be used from now on without further refreshes
+	err = client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Second query should have succeeded")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "The token should not have been refreshed again")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "The second query should have needed one call")
+}
+
+// TestAuthRefreshRejected confirms that a refreshed token that is also refused gives an AuthError.
+func TestAuthRefreshRejected(t *testing.T) {
+
+	var calls int32
+	server := newAuthServer("Bearer never", &calls)
+	defer server.Close()
+
+	var refreshes int32
+	expired := "Bearer expired"
+	client := CreateClient(server.URL, &expired, WithRetry(3), WithAuthRefresh(func(ctx context.Context) (*string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		stale := "Bearer also-expired"
+		return &stale, nil
+	}))
+
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	var authErr AuthError
+	assert.True(t, errors.As(err, &authErr), "An AuthError should have been returned")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes), "The token should have been refreshed exactly once")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "The query should have been tried exactly twice")
+}
+
+// TestAuthRefreshFailure confirms that an error from the refresh function is returned to the caller.
+func TestAuthRefreshFailure(t *testing.T) {
+
+	var calls int32
+	server := newAuthServer("Bearer fresh", &calls)
+	defer server.Close()
+
+	refreshErr := errors.New("identity provider unavailable")
+	client := CreateClient(server.URL, nil, WithAuthRefresh(func(ctx context.Context) (*string, error) {
+		return nil, refreshErr
+	}))
+
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Equal(t, refreshErr, err, "The refresh error should have been returned")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "The query should not have been retried")
+}
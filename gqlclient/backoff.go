@@ -0,0 +1,131 @@
+package gqlclient
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Backoff is an interface implemented by retry pacing strategies. Between failed attempts, the
+// client asks its Backoff how long it should wait before trying again.
+type Backoff interface {
+	// NextDelay returns the time to wait before the next attempt, given the number of the
+	// attempt that has just failed (1 for the first attempt, 2 for the second, and so on).
+	NextDelay(attempt int) time.Duration
+}
+
+// FixedBackoff is a Backoff strategy that waits the same length of time between every attempt.
+type FixedBackoff struct {
+	Delay time.Duration // The time to wait between attempts
+}
+
+// NextDelay returns the fixed delay regardless of the attempt number.
+func (b FixedBackoff) NextDelay(attempt int) time.Duration {
+	return b.Delay
+}
+
+// ExponentialBackoff is a Backoff strategy that multiplies the delay by a constant factor after
+// each failed attempt, up to a maximum.
+type ExponentialBackoff struct {
+	Initial    time.Duration // The delay following the first failed attempt
+	Max        time.Duration // The upper limit on any delay; zero means no limit
+	Multiplier float64       // The factor applied for each subsequent attempt; defaults to 2 if less than 1
+}
+
+// NextDelay returns Initial * Multiplier^(attempt-1), capped at Max.
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+
+	// Fall back to doubling if no sensible multiplier has been given
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	// Do the arithmetic in floating point so that we can spot overflow before it happens
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// DecorrelatedJitterBackoff is a Backoff strategy that picks a random delay between Base and
+// three times the previous delay, capped at Max. Spreading retries out this way avoids many clients
+// hammering a recovering server in lock step. See
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/ for the background.
+//
+// Because each delay depends on the one before, a DecorrelatedJitterBackoff must be used by
+// reference, and a fresh instance should be obtained from NewDecorrelatedJitterBackoff(...).
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration // The minimum delay
+	Max  time.Duration // The maximum delay
+
+	mutex sync.Mutex    // Guards the fields below
+	prev  time.Duration // The previous delay returned
+	rand  *rand.Rand    // Our own source of randomness
+}
+
+// NewDecorrelatedJitterBackoff returns a DecorrelatedJitterBackoff with the given minimum and
+// maximum delays.
+func NewDecorrelatedJitterBackoff(base, max time.Duration) *DecorrelatedJitterBackoff {
+	return &DecorrelatedJitterBackoff{
+		Base: base,
+		Max:  max,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NextDelay returns a random delay between Base and three times the previous delay, capped at Max.
+// The first attempt restarts the sequence.
+func (b *DecorrelatedJitterBackoff) NextDelay(attempt int) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Start afresh with the first attempt of each operation
+	if attempt <= 1 || b.prev < b.Base {
+		b.prev = b.Base
+	}
+	if b.rand == nil {
+		b.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	// Pick a delay somewhere between the base and three times the previous delay
+	delay := b.Base
+	if span := int64(b.prev*3 - b.Base); span > 0 {
+		delay += time.Duration(b.rand.Int63n(span))
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	b.prev = delay
+	return delay
+}
+
+// defaultBackoff returns the Backoff strategy used when WithRetry(...) is given without WithBackoff(...).
+func defaultBackoff() Backoff {
+	return ExponentialBackoff{Initial: 100 * time.Millisecond, Max: 5 * time.Second, Multiplier: 2}
+}
+
+// WithRetry is a ClientOption that allows a query to be retried up to maxRetries times after the
+// first attempt fails with a network error, a 429 TOO MANY REQUESTS, or a 5xx server error. Errors
+// that would recur on every attempt, such as a 401 UNAUTHORIZED, are not retried.
+func WithRetry(maxRetries int) ClientOption {
+	return func(gc *gqlClient) {
+		gc.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff is a ClientOption that sets the strategy used to pace the attempts permitted by
+// WithRetry(...). By default an ExponentialBackoff starting at 100 milliseconds is used.
+func WithBackoff(b Backoff) ClientOption {
+	return func(gc *gqlClient) {
+		gc.backoff = b
+	}
+}
@@ -0,0 +1,110 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the retry backoff strategies.
+
+// TestFixedBackoff confirms that the fixed strategy always returns the same delay.
+func TestFixedBackoff(t *testing.T) {
+
+	b := FixedBackoff{Delay: 250 * time.Millisecond}
+	for attempt := 1; attempt <= 5; attempt++ {
+		assert.Equal(t, 250*time.Millisecond, b.NextDelay(attempt), "Fixed delay should not vary")
+	}
+}
+
+// TestExponentialBackoff confirms the doubling sequence and the cap on the maximum delay.
+func TestExponentialBackoff(t *testing.T) {
+
+	b := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+	for i, want := range expected {
+		assert.Equal(t, want, b.NextDelay(i+1), "Unexpected delay for attempt %d", i+1)
+	}
+
+	// A missing multiplier should default to doubling
+	b = ExponentialBackoff{Initial: time.Millisecond}
+	assert.Equal(t, 8*time.Millisecond, b.NextDelay(4), "Default multiplier should double the delay")
+}
+
+// TestDecorrelatedJitterBackoff confirms that every delay falls between the base and three times
+// the previous delay, never exceeding the maximum.
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+
+	base := 10 * time.Millisecond
+	max := 500 * time.Millisecond
+	b := NewDecorrelatedJitterBackoff(base, max)
+
+	prev := base
+	for attempt := 1; attempt <= 50; attempt++ {
+		delay := b.NextDelay(attempt)
+		assert.True(t, delay >= base, "Delay %v should not be less than the base", delay)
+		assert.True(t, delay <= max, "Delay %v should not exceed the maximum", delay)
+		assert.True(t, delay <= prev*3, "Delay %v should not exceed three times the previous %v", delay, prev)
+		prev = delay
+	}
+}
+
+// TestRetryWithBackoff confirms that a query is retried following a 503 response, pacing the
+// attempts with the configured backoff strategy.
+func TestRetryWithBackoff(t *testing.T) {
+
+	// Fail twice then succeed
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithRetry(3), WithBackoff(FixedBackoff{Delay: time.Millisecond}))
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should have succeeded on the third attempt")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls), "Server should have been called three times")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+
+	// Without retries, the first failure should be the last
+	atomic.StoreInt32(&calls, 0)
+	client = CreateClient(server.URL, nil)
+	err = client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.NotNil(t, err, "Query should have failed without retries")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Server should have been called only once")
+}
+
+// TestRetryRespectsContext confirms that a cancelled context cuts a backoff delay short.
+func TestRetryRespectsContext(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithRetry(5), WithBackoff(FixedBackoff{Delay: time.Minute}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Equal(t, context.DeadlineExceeded, err, "Context deadline should have ended the retries")
+	assert.True(t, time.Since(start) < 10*time.Second, "Query should not have waited out the backoff")
+}
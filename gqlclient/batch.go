@@ -0,0 +1,46 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// SplitBatchResponse splits the body of a response to a batch of queries, a JSON array holding one
+// GraphQL response for each query, into the raw JSON of each response, in order, ready to be decoded
+// into a QueryResponse of its own. The array is read with a streaming decoder, so that each response
+// is copied out as it stands rather than being decoded into maps and slices along the way, which
+// matters for very large batches. An empty array gives an empty list. An error is returned if the
+// body is not a single well formed JSON array.
+func SplitBatchResponse(raw []byte) ([][]byte, error) {
+
+	// The body must open an array
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("batch response is not a JSON array")
+	}
+
+	// Copy out each element of the array in turn
+	responses := [][]byte{}
+	for decoder.More() {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return nil, err
+		}
+		responses = append(responses, element)
+	}
+
+	// Then the array must close, with nothing following it
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the batch response array")
+	}
+	return responses, nil
+}
@@ -0,0 +1,59 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for splitting batch responses.
+
+// TestSplitBatchResponse confirms that each response of a batch is split out intact.
+func TestSplitBatchResponse(t *testing.T) {
+
+	raw := `[
+		{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}},
+		{"data":null,"errors":[{"message":"not found","path":["repository"]}]},
+		{"data":{"viewer":{"login":"octocat"}}},
+		{"data":{"nodes":[1,2,3]}} ,
+		{"data":{"text":"a ] in a string"}}
+	]`
+	responses, err := SplitBatchResponse([]byte(raw))
+	assert.Nil(t, err, "The batch should have been split")
+	assert.Equal(t, 5, len(responses))
+	for i, response := range responses {
+		assert.True(t, json.Valid(response), "Response %d should be valid JSON: %s", i, response)
+	}
+	assert.Equal(t, `{"data":{"viewer":{"login":"octocat"}}}`, string(responses[2]))
+
+	// Each should decode like any other response
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, json.Unmarshal(responses[0], &response))
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	response = QueryResponse{}
+	assert.Nil(t, json.Unmarshal(responses[1], &response))
+	assert.Equal(t, "repository", response.Errors[0].PathString())
+
+	// An empty batch is no batch at all
+	responses, err = SplitBatchResponse([]byte(" [ ] "))
+	assert.Nil(t, err, "An empty array should have been accepted")
+	assert.Equal(t, [][]byte{}, responses)
+}
+
+// TestSplitBatchResponseErrors confirms that anything other than a single JSON array is refused.
+func TestSplitBatchResponseErrors(t *testing.T) {
+
+	for _, raw := range []string{
+		`{"data":{}}`,
+		`"[]"`,
+		``,
+		`[{"data":{}}`,
+		`[{"data":{}},]`,
+		`[{"data":{}}] [{"data":{}}]`,
+		`[{"data":}]`,
+	} {
+		_, err := SplitBatchResponse([]byte(raw))
+		assert.NotNil(t, err, "%q should have been refused", raw)
+	}
+}
@@ -0,0 +1,98 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// rateLimitSelection is the selection injected into queries to have github report their cost.
+const rateLimitSelection = "rateLimit { cost remaining }"
+
+// fullRateLimitSelection is the selection injected into queries by WithAutoRateLimitField(), to have
+// github report everything it can about the rate limit.
+const fullRateLimitSelection = "rateLimit { limit cost remaining resetAt }"
+
+// RateLimitInfo reports the github GraphQL API rate limit status returned in the rateLimit field of
+// a query response. See https://developer.github.com/v4/guides/resource-limitations/ for details.
+type RateLimitInfo struct {
+	Limit     int       `json:"limit"`     // The number of points allowed in each rate limit window, if requested
+	Cost      int       `json:"cost"`      // The number of points the query cost
+	Remaining int       `json:"remaining"` // The number of points remaining in the current rate limit window
+	ResetAt   time.Time `json:"resetAt"`   // The time at which the current rate limit window resets, if requested
+}
+
+// ErrBudgetExceeded is the error returned by a client configured with WithQueryBudget(...) when
+// the cost reported for a query is greater than the budget. Note that the query has already been
+// executed by the time its cost is known; the response is populated as normal and the error is a
+// signal that the query should be made cheaper before it is run again.
+type ErrBudgetExceeded struct {
+	Budget    int // The configured budget
+	Cost      int // The actual cost of the query
+	Remaining int // The points remaining in the current rate limit window
+}
+
+// Error returns a description of the overspend.
+func (e ErrBudgetExceeded) Error() string {
+	return fmt.Sprintf("query cost %d exceeds budget of %d (%d points remaining)", e.Cost, e.Budget, e.Remaining)
+}
+
+// WithQueryBudget is a ClientOption that holds every query to a maximum github rate limit cost.
+// The selection "rateLimit { cost remaining }" is injected into each query operation that does not
+// already select rateLimit, the result is made available in QueryResponse.RateLimitInfo, and an
+// ErrBudgetExceeded error is returned for any query whose cost exceeds the budget.
+func WithQueryBudget(budget int) ClientOption {
+	return func(gc *gqlClient) {
+		gc.queryBudget = budget
+		gc.transforms = append(gc.transforms, injectRateLimit)
+	}
+}
+
+// WithAutoRateLimitField is a github specific ClientOption that keeps track of the API rate limit
+// quota without every query author having to remember to ask for it. The selection
+// "rateLimit { limit cost remaining resetAt }" is injected into each query operation that does not
+// already select rateLimit and the result is made available in QueryResponse.RateLimitInfo. The
+// injection is made ahead of any other query transforms, so that the full selection is used even if
+// WithQueryBudget(...) is also given.
+func WithAutoRateLimitField() ClientOption {
+	return func(gc *gqlClient) {
+		gc.autoRateLimit = true
+		gc.transforms = append([]QueryTransform{injectFullRateLimit}, gc.transforms...)
+	}
+}
+
+// injectRateLimit is a QueryTransform that adds the rateLimit selection to query operations that do
+// not already have it. Documents that cannot be understood are sent unchanged for the server to judge.
+func injectRateLimit(packed string) (string, error) {
+	result, _ := injectTopLevelField(packed, "rateLimit", rateLimitSelection)
+	return result, nil
+}
+
+// injectFullRateLimit is a QueryTransform that adds the full rateLimit selection to query operations
+// that do not already have it. Documents that cannot be understood are sent unchanged.
+func injectFullRateLimit(packed string) (string, error) {
+	result, _ := injectTopLevelField(packed, "rateLimit", fullRateLimitSelection)
+	return result, nil
+}
+
+// parseRateLimit extracts the rate limit information, if any, from a raw response body.
+func parseRateLimit(body []byte) *RateLimitInfo {
+	var envelope struct {
+		Data struct {
+			RateLimit *RateLimitInfo `json:"rateLimit"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return nil
+	}
+	return envelope.Data.RateLimit
+}
+
+// checkBudget returns an ErrBudgetExceeded error if the query cost exceeds the client's budget, if
+// it has one.
+func (gc *gqlClient) checkBudget(info *RateLimitInfo) error {
+	if gc.queryBudget > 0 && info != nil && info.Cost > gc.queryBudget {
+		return ErrBudgetExceeded{Budget: gc.queryBudget, Cost: info.Cost, Remaining: info.Remaining}
+	}
+	return nil
+}
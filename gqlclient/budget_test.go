@@ -0,0 +1,142 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for query cost budget tracking.
+
+// newCostServer returns a fake github server that reports the given cost for every query and
+// records the query strings it receives.
+func newCostServer(cost int, queries *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		json.NewDecoder(r.Body).Decode(&q)
+		*queries = append(*queries, q.Query)
+		fmt.Fprintf(w, `{"data":{"rateLimit":{"cost":%d,"remaining":4990},"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`, cost)
+	}))
+}
+
+// TestQueryWithinBudget confirms that the rate limit selection is injected and parsed.
+func TestQueryWithinBudget(t *testing.T) {
+
+	var queries []string
+	server := newCostServer(1, &queries)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithQueryBudget(5))
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query within budget should not fail")
+
+	// The server should have been asked for the rate limit
+	assert.Contains(t, queries[0], "{ rateLimit { cost remaining } repository(", "Rate limit selection should have been injected")
+
+	// And the answer should have been passed back
+	assert.NotNil(t, response.RateLimitInfo, "Rate limit information should have been populated")
+	assert.Equal(t, 1, response.RateLimitInfo.Cost)
+	assert.Equal(t, 4990, response.RateLimitInfo.Remaining)
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+}
+
+// TestQueryOverBudget confirms that an expensive query is reported with its cost.
+func TestQueryOverBudget(t *testing.T) {
+
+	var queries []string
+	server := newCostServer(12, &queries)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithQueryBudget(5))
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+
+	var budgetErr ErrBudgetExceeded
+	assert.True(t, errors.As(err, &budgetErr), "Query over budget should have returned ErrBudgetExceeded")
+	assert.Equal(t, ErrBudgetExceeded{Budget: 5, Cost: 12, Remaining: 4990}, budgetErr)
+	assert.Contains(t, err.Error(), "query cost 12 exceeds budget of 5")
+}
+
+// TestBudgetKeepsExistingRateLimit confirms that queries which already ask for the rate limit
+// are not given a second copy of the field.
+func TestBudgetKeepsExistingRateLimit(t *testing.T) {
+
+	var queries []string
+	server := newCostServer(1, &queries)
+	defer server.Close()
+
+	rateQuery := `query { rateLimit { cost remaining resetAt } viewer { login } }`
+	client := CreateClient(server.URL, nil, WithQueryBudget(5))
+	err := client.Query(&rateQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query within budget should not fail")
+	assert.Equal(t, rateQuery, queries[0], "Query that already selects rateLimit should not be changed")
+	assert.Equal(t, 1, strings.Count(queries[0], "rateLimit"))
+}
+
+// TestAutoRateLimitField confirms that the full rate limit selection is injected, ahead of that of a
+// query budget, and that the result is parsed.
+func TestAutoRateLimitField(t *testing.T) {
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		json.NewDecoder(r.Body).Decode(&q)
+		queries = append(queries, q.Query)
+		w.Write([]byte(`{"data":{"rateLimit":{"limit":5000,"cost":1,"remaining":4990,"resetAt":"2021-06-01T12:00:00Z"},` +
+			`"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// Ask for the field alone, and alongside a budget
+	for _, client := range []GqlClient{
+		CreateClient(server.URL, nil, WithAutoRateLimitField()),
+		CreateClient(server.URL, nil, WithQueryBudget(5), WithAutoRateLimitField()),
+	} {
+		queries = nil
+		response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+		err := client.Query(&SimpleRepoDataQuery, nil, &response)
+		assert.Nil(t, err, "Query should not have failed")
+		assert.Contains(t, queries[0], "{ rateLimit { limit cost remaining resetAt } repository(", "Rate limit selection should have been injected")
+		assert.Equal(t, 1, strings.Count(queries[0], "rateLimit"), "Rate limit should only have been selected once")
+		assert.Equal(t, &RateLimitInfo{Limit: 5000, Cost: 1, Remaining: 4990, ResetAt: time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)},
+			response.RateLimitInfo, "Rate limit information should have been parsed")
+		assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	}
+
+	// Without either option, nothing is injected or parsed
+	queries = nil
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := CreateClient(server.URL, nil).Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should not have failed")
+	assert.NotContains(t, queries[0], "rateLimit", "Rate limit selection should not have been injected")
+	assert.Nil(t, response.RateLimitInfo, "Rate limit information should not have been parsed")
+}
+
+// TestInjectTopLevelField exercises the field injection directly with a document containing a
+// mutation, a fragment, and a query with object arguments and string literals.
+func TestInjectTopLevelField(t *testing.T) {
+
+	doc := `query A($f: Filter = {a: "{"}) { search(filter: $f) { ...F } } ` +
+		`mutation B { addStar(input: {starrableId: "x"}) { clientMutationId } } ` +
+		`fragment F on Repo { name } { viewer { login } }`
+	expected := `query A($f: Filter = {a: "{"}) { rateLimit { cost remaining } search(filter: $f) { ...F } } ` +
+		`mutation B { addStar(input: {starrableId: "x"}) { clientMutationId } } ` +
+		`fragment F on Repo { name } { rateLimit { cost remaining } viewer { login } }`
+
+	result, err := injectTopLevelField(doc, "rateLimit", rateLimitSelection)
+	assert.Nil(t, err, "Injection should have succeeded")
+	assert.Equal(t, expected, result)
+
+	// A malformed document should be returned as is along with an error
+	result, err = injectTopLevelField("query { viewer { login }", "rateLimit", rateLimitSelection)
+	assert.NotNil(t, err, "Unbalanced document should be reported")
+	assert.Equal(t, "query { viewer { login }", result)
+}
@@ -0,0 +1,35 @@
+package gqlclient
+
+import "time"
+
+// Clock is an interface through which a client tells the time and waits for it to pass. The real
+// clock is used by default; tests of timing dependent behavior, such as retry backoff, can supply
+// a fake one with WithClock(...) to make time pass instantly.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel on which the time is delivered once the given duration has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock implementation that defers to the time package.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock is a ClientOption that replaces the real clock used to pace retries and interpret
+// Retry-After headers. It is intended for tests.
+func WithClock(c Clock) ClientOption {
+	return func(gc *gqlClient) {
+		gc.clock = c
+	}
+}
@@ -0,0 +1,89 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the pluggable clock.
+
+// fakeClock is a Clock whose time only moves when something waits on it, at which point it jumps
+// straight to the end of the wait. Every wait is recorded.
+type fakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+// Now returns the fake current time.
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// After advances the fake time by the given duration and returns a channel that has already fired.
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// TestFakeClockBackoff confirms that retries are paced through the clock, so that a fake clock can
+// verify the backoff delays without any real waiting.
+func TestFakeClockBackoff(t *testing.T) {
+
+	// Fail three times then succeed
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	clock := &fakeClock{now: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}
+	backoff := ExponentialBackoff{Initial: time.Second, Max: time.Minute, Multiplier: 2}
+	client := CreateClient(server.URL, nil, WithRetry(5), WithBackoff(backoff), WithClock(clock))
+
+	start := time.Now()
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query should have succeeded on the fourth attempt")
+	assert.True(t, time.Since(start) < 5*time.Second, "The fake clock should not have really waited")
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, clock.waits)
+	assert.Equal(t, time.Date(2019, 6, 1, 0, 0, 7, 0, time.UTC), clock.Now(), "Seven seconds should have passed")
+}
+
+// TestFakeClockRetryAfter confirms that a Retry-After date is measured against the client's clock.
+func TestFakeClockRetryAfter(t *testing.T) {
+
+	// Ask for a retry at a fixed time, then succeed
+	clock := &fakeClock{now: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "Sat, 01 Jun 2019 00:01:30 GMT")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithRetry(1), WithBackoff(FixedBackoff{Delay: time.Second}), WithClock(clock))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query should have succeeded on the second attempt")
+	assert.Equal(t, []time.Duration{90 * time.Second}, clock.waits, "The client should have waited until the Retry-After time")
+}
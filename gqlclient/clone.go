@@ -0,0 +1,85 @@
+package gqlclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrNotCloneable is returned by Clone(...) when given a GqlClient that was not obtained from
+// CreateClient(...), for example a mock or a ClientPool.
+var ErrNotCloneable = errors.New("client cannot be cloned: it was not created by gqlclient.CreateClient")
+
+// Clone returns a new GqlClient with the same target URL, authorization and options as the given
+// client, with any override options applied on top. The clone is independent of the original:
+// applying options to one has no effect on the other.
+//
+// For example, to run a sequence of operations with different credentials:
+//
+// 		adminClient, err := gqlclient.Clone(client, gqlclient.WithAuthorization(&adminAuth))
+//
+func Clone(client GqlClient, overrides ...ClientOption) (GqlClient, error) {
+
+	// We can only clone what we know how to take apart
+	original, ok := client.(*gqlClient)
+	if !ok {
+		return nil, ErrNotCloneable
+	}
+
+	// Copy the configuration, taking care that the clone gets its own copies of any slices so that
+	// appending to them cannot disturb the original
+	clone := *original
+	clone.middleware = append([]Middleware(nil), original.middleware...)
+	clone.transforms = append([]QueryTransform(nil), original.transforms...)
+	clone.requestHooks = append([]RequestHook(nil), original.requestHooks...)
+	clone.responseHooks = append([]ResponseHook(nil), original.responseHooks...)
+	clone.afterResponse = append([]AfterResponseHook(nil), original.afterResponse...)
+	clone.validators = append([]ResponseValidator(nil), original.validators...)
+	clone.roundTrip = append([]RoundTripObserver(nil), original.roundTrip...)
+
+	// Likewise, a client with its own HTTP transport needs the clone to have a copy of it, lest
+	// transport options applied to the clone alter the original
+	if original.httpClient != nil {
+		hc := *original.httpClient
+		if t, ok := hc.Transport.(*http.Transport); ok {
+			hc.Transport = t.Clone()
+		}
+		clone.httpClient = &hc
+
+		// The copied transport would still resolve through the original's DNS cache, so give the
+		// clone an empty cache of its own
+		if t, ok := hc.Transport.(*http.Transport); ok && original.dnsCache != nil {
+			clone.dnsCache = original.dnsCache.fresh()
+			t.DialContext = clone.cachedDial
+		}
+	}
+
+	// A client that refreshes its authorization needs its own lock, and a consistent copy of the
+	// current token to go with it
+	if original.authMutex != nil {
+		clone.authorization = original.authHeader()
+		clone.authMutex = new(sync.RWMutex)
+	}
+
+	// A client with several tokens needs the clone to keep its own track of which are resting
+	if original.tokens != nil {
+		clone.tokens = original.tokens.copy()
+	}
+
+	// The clone starts with the queries prepared so far, but prepares any more for itself
+	clone.prepared = original.prepared.copy()
+
+	// Queries in flight belong to the original; the clone keeps track of its own
+	if original.flights != nil {
+		clone.flights = newFlightGroup()
+	}
+
+	// As does any warmup still running; the clone has none of its own for Close() to stop
+	clone.stopWarmup = nil
+
+	// Now apply the overrides
+	for _, opt := range overrides {
+		opt(&clone)
+	}
+	return &clone, nil
+}
@@ -0,0 +1,54 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for client cloning.
+
+// TestClone confirms that a clone can be given different credentials without affecting the original.
+func TestClone(t *testing.T) {
+
+	// Record the authorization headers received
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	userAuth := "token user"
+	adminAuth := "token admin"
+	hookCalls := 0
+	original := CreateClient(server.URL, &userAuth, WithRequestHook(func(req *http.Request) error {
+		hookCalls++
+		return nil
+	}))
+
+	// Clone with different credentials and a hook of its own
+	clone, err := Clone(original, WithAuthorization(&adminAuth), WithRequestHook(func(req *http.Request) error {
+		req.Header.Set("X-Cloned", "yes")
+		return nil
+	}))
+	assert.Nil(t, err, "Clone should have succeeded")
+	assert.Equal(t, server.URL, clone.GetTargetURL(), "Clone should have the same target URL")
+
+	assert.Nil(t, clone.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Nil(t, original.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+
+	assert.Equal(t, []string{"token admin", "token user"}, auths, "Clone and original should use their own credentials")
+	assert.Equal(t, 2, hookCalls, "Both clients should have kept the original hook")
+	assert.Equal(t, 1, len(original.(*gqlClient).requestHooks), "The original should not have gained the clone's hook")
+}
+
+// TestCloneNotCloneable confirms that clients not created by CreateClient cannot be cloned.
+func TestCloneNotCloneable(t *testing.T) {
+
+	pool := NewClientPool(2, "http://localhost", nil)
+	_, err := Clone(pool)
+	assert.Equal(t, ErrNotCloneable, err, "A pool should not be cloneable")
+}
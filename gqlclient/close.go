@@ -0,0 +1,22 @@
+package gqlclient
+
+// Close releases the resources held by the client, for the benefit of long running services that
+// create and discard clients: any warmup queries still running in the background are abandoned and
+// the idle connections of the client's own HTTP transport are closed. Clients that share the package
+// level http.Client leave its connections alone, since other clients may still be using them.
+//
+// Close is idempotent and always returns nil. A closed client remains usable, opening new
+// connections as they are needed, but there is rarely any reason to use one.
+func (gc *gqlClient) Close() error {
+
+	// Stop anything we started in the background
+	if gc.stopWarmup != nil {
+		gc.stopWarmup()
+	}
+
+	// Release the connections that are sitting idle in our pool
+	if gc.httpClient != nil {
+		gc.httpClient.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package gqlclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for closing clients.
+
+// TestClose confirms that closing a client releases its idle connections and that it can be closed
+// more than once.
+func TestClose(t *testing.T) {
+
+	// Keep track of the connections that the server sees closed
+	var mutex sync.Mutex
+	closed := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			mutex.Lock()
+			closed++
+			mutex.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// A client with a transport of its own leaves its connection idle after a query
+	client := CreateClient(server.URL, nil, WithResponseHeaderTimeout(time.Second))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	mutex.Lock()
+	assert.Equal(t, 0, closed, "The connection should have been left open for reuse")
+	mutex.Unlock()
+
+	// Until the client is closed
+	assert.Nil(t, client.Close(), "Close should have succeeded")
+	assert.Nil(t, client.Close(), "Closing again should have been harmless")
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return closed == 1
+	}, 5*time.Second, 10*time.Millisecond, "The idle connection should have been closed")
+}
+
+// TestCloseAbandonsWarmup confirms that closing a client abandons its warmup queries.
+func TestCloseAbandonsWarmup(t *testing.T) {
+
+	// A server that never answers until the request is abandoned
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	logger := &capturingLogger{}
+	client := CreateClient(server.URL, nil, WithLogger(logger), WithWarmup([]WarmupQuery{
+		{QueryStr: "query Warm { viewer { login } }"},
+	}))
+	start := time.Now()
+	assert.Nil(t, client.Close())
+
+	// The failure should be logged well before the warmup would have timed out of its own accord
+	assert.Eventually(t, func() bool {
+		return len(logger.snapshot()) == 1
+	}, warmupTimeout, 10*time.Millisecond, "The abandoned warmup should have been logged")
+	assert.Less(t, time.Since(start), warmupTimeout, "The warmup should have been abandoned promptly")
+	assert.Contains(t, logger.snapshot()[0].keyvals, "operation")
+}
@@ -0,0 +1,70 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+)
+
+// ContentTypeGraphQL is the content type that, given to WithContentType(...), has queries sent as the
+// raw query text rather than wrapped in a JSON object.
+const ContentTypeGraphQL = "application/graphql"
+
+// ErrVariablesNotSupported is the error returned when a query with variables is made by a client
+// configured to send raw application/graphql request bodies, which have no place for them.
+var ErrVariablesNotSupported = errors.New("query variables cannot be sent with an application/graphql request body")
+
+// WithContentType is a ClientOption that sets the Content-Type header of query requests, which is
+// application/json by default. Some servers expect a variation, e.g. "application/json; charset=utf-8",
+// which is sent with the usual JSON body.
+//
+// If the content type is ContentTypeGraphQL, the packed query is sent as the raw request body
+// without the JSON envelope. That leaves no way to send variables or an operation name, so a query
+// made with any variables fails with ErrVariablesNotSupported; values must be written into the query
+// text instead. UploadQuery(...) requests are always sent as multipart/form-data whatever the
+// content type.
+func WithContentType(contentType string) ClientOption {
+	return func(gc *gqlClient) {
+		gc.contentType = contentType
+	}
+}
+
+// requestContentType returns the Content-Type with which query requests should be sent.
+func (gc *gqlClient) requestContentType() string {
+	if gc.contentType == "" {
+		return "application/json"
+	}
+	return gc.contentType
+}
+
+// rawBody returns true if the client is configured to send the raw query text as the request body.
+func (gc *gqlClient) rawBody() bool {
+	mediaType, _, err := mime.ParseMediaType(gc.contentType)
+	return err == nil && mediaType == ContentTypeGraphQL
+}
+
+// encodeQuery returns the request body for the packed query and its parameters: the query wrapped
+// in a JSON object with its variables or, if the client is configured for application/graphql, the
+// bare query text.
+func (gc *gqlClient) encodeQuery(ctx context.Context, packed string, queryParms *map[string]interface{}) ([]byte, error) {
+
+	// Raw bodies are simple, but only if there are no variables to worry about
+	if gc.rawBody() {
+		if queryParms != nil && len(*queryParms) > 0 {
+			return nil, ErrVariablesNotSupported
+		}
+		return []byte(packed), nil
+	}
+
+	// Otherwise wrap the query up in JSON along with everything else it needs
+	operationName, err := gc.operationName(ctx, packed)
+	if err != nil {
+		return nil, err
+	}
+	q := query{Query: packed, OperationName: operationName}
+	if queryParms != nil {
+		q.Variables = *queryParms
+	}
+	return json.Marshal(q)
+}
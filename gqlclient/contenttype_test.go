@@ -0,0 +1,66 @@
+package gqlclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the request content type option.
+
+// newBodyRecordingServer returns a fake server that records the content type and body of each request.
+func newBodyRecordingServer(contentTypes *[]string, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*contentTypes = append(*contentTypes, r.Header.Get("Content-Type"))
+		*bodies = append(*bodies, string(body))
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+}
+
+// TestContentTypeGraphQL confirms that the raw query is sent with the application/graphql content
+// type, and that variables are refused.
+func TestContentTypeGraphQL(t *testing.T) {
+
+	var contentTypes, bodies []string
+	server := newBodyRecordingServer(&contentTypes, &bodies)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithContentType(ContentTypeGraphQL))
+	queryStr := `query {
+		repository(owner: "mikebway", name: "gogql") { name owner { login } }
+	}`
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&queryStr, nil, &response)
+	assert.Nil(t, err, "Raw query should have succeeded")
+	assert.Equal(t, []string{"application/graphql"}, contentTypes)
+	assert.Equal(t, []string{`query { repository(owner: "mikebway", name: "gogql") { name owner { login } } }`}, bodies, "The packed query should have been sent as is")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name, "The response should have been decoded as usual")
+
+	// Variables have nowhere to go
+	queryParms := map[string]interface{}{"owner": "mikebway"}
+	err = client.Query(&SimpleRepoDataQuery, &queryParms, &response)
+	assert.Equal(t, ErrVariablesNotSupported, err, "Variables should have been refused")
+	assert.Equal(t, 1, len(bodies), "The query with variables should not have been sent")
+}
+
+// TestContentTypeJSON confirms that JSON bodies are sent with the default or a custom JSON content type.
+func TestContentTypeJSON(t *testing.T) {
+
+	var contentTypes, bodies []string
+	server := newBodyRecordingServer(&contentTypes, &bodies)
+	defer server.Close()
+
+	queryParms := map[string]interface{}{"owner": "mikebway"}
+	err := CreateClient(server.URL, nil).Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.Nil(t, err, "Default query should have succeeded")
+	err = CreateClient(server.URL, nil, WithContentType("application/json; charset=utf-8")).Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.Nil(t, err, "Custom JSON query should have succeeded")
+
+	assert.Equal(t, []string{"application/json", "application/json; charset=utf-8"}, contentTypes)
+	assert.Equal(t, bodies[0], bodies[1], "Both should have sent the same JSON body")
+	assert.Contains(t, bodies[1], `"variables":{"owner":"mikebway"}`)
+}
@@ -0,0 +1,16 @@
+package gqlclient
+
+import "time"
+
+// DateTimeVar formats a time as an RFC3339 string in UTC, e.g. "2019-06-01T19:07:06Z", for use as
+// the value of a DateTime query variable such as the since: filter of a github commit history.
+//
+// A time.Time placed directly in the variables map is marshalled by encoding/json with fractional
+// seconds and the offset of its location, e.g. "2019-06-01T14:07:06.123456789-05:00", which some
+// servers' DateTime scalars refuse. For example:
+//
+// 		queryParms["since"] = gqlclient.DateTimeVar(time.Now().AddDate(0, 0, -7))
+//
+func DateTimeVar(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,40 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for DateTime variable formatting.
+
+// TestDateTimeVar confirms that times are sent as whole second RFC3339 strings in UTC.
+func TestDateTimeVar(t *testing.T) {
+
+	// Record the variables received
+	var variables map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		variables = body.Variables
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	since := time.Date(2019, 6, 1, 14, 7, 6, 123456789, time.FixedZone("EST", -5*60*60))
+	queryParms := map[string]interface{}{"since": DateTimeVar(since)}
+	client := CreateClient(server.URL, nil)
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{}))
+	assert.Equal(t, "2019-06-01T19:07:06Z", variables["since"], "The time should have been sent in RFC3339 form")
+
+	// And the result should be understood by anyone expecting RFC3339
+	parsed, err := time.Parse(time.RFC3339, variables["since"].(string))
+	assert.Nil(t, err)
+	assert.True(t, parsed.Equal(since.Truncate(time.Second)), "The time should survive the round trip to the second")
+}
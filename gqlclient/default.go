@@ -0,0 +1,52 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNoDefaultClient is returned by NilSafeQuery(...) if SetDefaultClient(...) has not been called.
+var ErrNoDefaultClient = errors.New("no default GraphQL client has been set")
+
+// defaultClient is the client used by NilSafeQuery(...), guarded by defaultClientMutex.
+var (
+	defaultClient      GqlClient
+	defaultClientMutex sync.RWMutex
+)
+
+// SetDefaultClient sets the package level client through which NilSafeQuery(...) sends its queries.
+// Passing nil clears it.
+func SetDefaultClient(client GqlClient) {
+	defaultClientMutex.Lock()
+	defer defaultClientMutex.Unlock()
+	defaultClient = client
+}
+
+// NilSafeQuery sends a query through the client given to SetDefaultClient(...), sparing one-off
+// scripts the ceremony of passing a client around. For example:
+//
+// 		gqlclient.SetDefaultClient(gqlclient.CreateClient("https://api.github.com/graphql", &auth))
+// 		...
+// 		err := gqlclient.NilSafeQuery(ctx, query, map[string]interface{}{"owner": "mikebway"}, &response)
+//
+// Unlike QueryContext(...), the query string and variables are passed by value, and the variables
+// may be a nil map if the query has none. ErrNoDefaultClient is returned if no default client has
+// been set.
+func NilSafeQuery(ctx context.Context, queryStr string, queryParms map[string]interface{}, response *QueryResponse) error {
+
+	// Find the client that we are to use
+	defaultClientMutex.RLock()
+	client := defaultClient
+	defaultClientMutex.RUnlock()
+	if client == nil {
+		return ErrNoDefaultClient
+	}
+
+	// Only pass variables on if there are any
+	var parms *map[string]interface{}
+	if queryParms != nil {
+		parms = &queryParms
+	}
+	return client.QueryContext(ctx, &queryStr, parms, response)
+}
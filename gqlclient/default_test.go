@@ -0,0 +1,46 @@
+package gqlclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the package level default client.
+
+// recordingClient is a GqlClient that records the queries sent through it rather than sending them.
+// Methods other than QueryContext(...) are left to the nil embedded interface and so must not be
+// called.
+type recordingClient struct {
+	GqlClient
+	queries []string
+	parms   []*map[string]interface{}
+}
+
+// QueryContext records the query and its variables.
+func (c *recordingClient) QueryContext(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	c.queries = append(c.queries, *queryStr)
+	c.parms = append(c.parms, queryParms)
+	return nil
+}
+
+// TestNilSafeQuery confirms that queries are sent through the default client, with or without
+// variables, and that ErrNoDefaultClient is returned when there is none.
+func TestNilSafeQuery(t *testing.T) {
+	defer SetDefaultClient(nil)
+
+	// Queries should go to the default client
+	mock := &recordingClient{}
+	SetDefaultClient(mock)
+	assert.Nil(t, NilSafeQuery(context.Background(), SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Nil(t, NilSafeQuery(context.Background(), SimpleRepoDataQuery, map[string]interface{}{"owner": "mikebway"}, &QueryResponse{}))
+	assert.Equal(t, []string{SimpleRepoDataQuery, SimpleRepoDataQuery}, mock.queries, "The mock should have been given both queries")
+	assert.Nil(t, mock.parms[0], "A nil map should have been passed on as no variables")
+	assert.Equal(t, map[string]interface{}{"owner": "mikebway"}, *mock.parms[1])
+
+	// Without a default client there is nothing to send the query through
+	SetDefaultClient(nil)
+	err := NilSafeQuery(context.Background(), SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Equal(t, ErrNoDefaultClient, err)
+}
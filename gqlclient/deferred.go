@@ -0,0 +1,158 @@
+package gqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+)
+
+// deferredAccept is the Accept header sent by QueryDeferred(...), asking for incremental delivery
+// as multipart/mixed but accepting an ordinary JSON response from servers that do not support it.
+const deferredAccept = "multipart/mixed; deferSpec=20220824, application/json"
+
+// incrementalPayload is a single JSON part of an incrementally delivered response. Servers following
+// the 2022 incremental delivery proposal send the parts after the first as a list of incremental
+// results; those following earlier drafts send each result as a part of its own. Both are understood.
+type incrementalPayload struct {
+	Data        json.RawMessage        `json:"data"`
+	Items       json.RawMessage        `json:"items"`
+	Errors      GraphQLErrors          `json:"errors"`
+	Extensions  map[string]interface{} `json:"extensions"`
+	Path        []interface{}          `json:"path"`
+	Incremental []incrementalPayload   `json:"incremental"`
+}
+
+// QueryDeferred sends a GraphQL query that uses the @defer or @stream directives, reading the
+// multipart/mixed response in which the server delivers the results incrementally over a single
+// long-lived HTTP connection. The handler is called for the initial result and for each deferred
+// fragment or streamed list that follows, as they arrive, with a QueryResponse whose Data is a
+// *json.RawMessage holding the raw JSON of the partial data and whose Path locates that data within
+// the full response. If the server does not support incremental delivery and replies with ordinary
+// JSON, the handler is called just once with the whole response.
+//
+// The query is not retried and does not pass through any middleware. If the handler returns an
+// error, the rest of the response is abandoned and the error returned.
+func (gc *gqlClient) QueryDeferred(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, handler func(*QueryResponse) error) error {
+
+	// Build the GraphQL query into JSON that we can POST
+	packed, err := gc.prepareQuery(ctx, queryStr)
+	if err != nil {
+		return err
+	}
+	if err := gc.checkVariables(queryParms); err != nil {
+		return err
+	}
+	operationName, err := gc.operationName(ctx, packed)
+	if err != nil {
+		return err
+	}
+	ctx = gc.roundTripContext(ctx, packed)
+	q := query{Query: packed, OperationName: operationName}
+	if queryParms != nil {
+		q.Variables = *queryParms
+	}
+	queryBytes, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	if err := gc.checkRequestSize(queryBytes); err != nil {
+		return err
+	}
+	if gc.bodyLogger != nil {
+		if err := gc.logRequestBody(queryBytes); err != nil {
+			return err
+		}
+	}
+
+	// Form up the HTTP POST request and send it
+	req, err := http.NewRequest("POST", gc.endpoint(), bytes.NewReader(queryBytes))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", deferredAccept)
+	resp, _, err := gc.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Anything other than a multipart response is handled in the ordinary way, all in one go
+	mediaType, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != 200 || mediaType != "multipart/mixed" {
+		body, _ := ioutil.ReadAll(resp.Body)
+		for _, hook := range gc.afterResponse {
+			hook(req, resp, body)
+		}
+		if _, err := gc.checkStatus(req, resp); err != nil {
+			return err
+		}
+		response := QueryResponse{Data: new(json.RawMessage)}
+		if err := unmarshalResponse(body, &response); err != nil {
+			return err
+		}
+		return handler(&response)
+	}
+
+	// Read each part as it arrives and pass on the results within it
+	parts := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			continue
+		}
+		var payload incrementalPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return err
+		}
+		if err := deliverPayload(&payload, handler); err != nil {
+			return err
+		}
+	}
+}
+
+// deliverPayload passes each result found in an incremental payload to the handler. A payload that
+// carries nothing but the hasNext flag, as the final part often does, has nothing to deliver.
+func deliverPayload(payload *incrementalPayload, handler func(*QueryResponse) error) error {
+
+	// A list of incremental results is delivered one result at a time
+	if len(payload.Incremental) > 0 {
+		for i := range payload.Incremental {
+			if err := deliverPayload(&payload.Incremental[i], handler); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Streamed list items take the place of data
+	data := payload.Data
+	if data == nil {
+		data = payload.Items
+	}
+	if data == nil && payload.Errors == nil {
+		return nil
+	}
+	return handler(&QueryResponse{
+		Data:       &data,
+		Errors:     payload.Errors,
+		Extensions: payload.Extensions,
+		Path:       payload.Path,
+	})
+}
@@ -0,0 +1,103 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for incremental delivery of @defer and @stream results.
+
+// deferredQuery is a query with a deferred fragment
+var deferredQuery = `query FetchRepoInfo {
+	repository(owner: "mikebway", name: "gogql") {
+		name
+		... @defer {
+			description
+		}
+	}
+}`
+
+// newDeferredServer returns a fake GraphQL server that responds with the given multipart body,
+// recording the Accept header of the request.
+func newDeferredServer(accept *string, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", `multipart/mixed; boundary="-"`)
+		w.Write([]byte(body))
+	}))
+}
+
+// TestQueryDeferred confirms that each part of a multipart response is delivered to the handler.
+func TestQueryDeferred(t *testing.T) {
+
+	var accept string
+	server := newDeferredServer(&accept, "\r\n---\r\n"+
+		"Content-Type: application/json; charset=utf-8\r\n\r\n"+
+		`{"data":{"repository":{"name":"gogql"}},"hasNext":true}`+"\r\n---\r\n"+
+		"Content-Type: application/json; charset=utf-8\r\n\r\n"+
+		`{"incremental":[{"data":{"description":"A basic GraphQL client library for Go"},"path":["repository"]}],"hasNext":false}`+
+		"\r\n-----\r\n")
+	defer server.Close()
+
+	var parts []*QueryResponse
+	client := CreateClient(server.URL, nil)
+	err := client.QueryDeferred(context.Background(), &deferredQuery, nil, func(response *QueryResponse) error {
+		parts = append(parts, response)
+		return nil
+	})
+	assert.Nil(t, err, "The deferred query should not have failed")
+	assert.Contains(t, accept, "multipart/mixed", "Incremental delivery should have been asked for")
+
+	// The handler should have been called for the initial result and the deferred fragment
+	assert.Equal(t, 2, len(parts), "The handler should have been called twice")
+	assert.JSONEq(t, `{"repository":{"name":"gogql"}}`, string(*parts[0].Data.(*json.RawMessage)))
+	assert.Nil(t, parts[0].Path)
+	assert.JSONEq(t, `{"description":"A basic GraphQL client library for Go"}`, string(*parts[1].Data.(*json.RawMessage)))
+	assert.Equal(t, []interface{}{"repository"}, parts[1].Path)
+}
+
+// TestQueryDeferredHandlerError confirms that a handler error abandons the rest of the response.
+func TestQueryDeferredHandlerError(t *testing.T) {
+
+	var accept string
+	server := newDeferredServer(&accept, "\r\n---\r\n"+
+		"Content-Type: application/json\r\n\r\n"+`{"data":{"repository":{"name":"gogql"}},"hasNext":true}`+"\r\n---\r\n"+
+		"Content-Type: application/json\r\n\r\n"+`{"data":{"description":"late"},"path":["repository"],"hasNext":false}`+"\r\n-----\r\n")
+	defer server.Close()
+
+	stop := errors.New("seen enough")
+	calls := 0
+	client := CreateClient(server.URL, nil)
+	err := client.QueryDeferred(context.Background(), &deferredQuery, nil, func(response *QueryResponse) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err, "The handler error should have been returned")
+	assert.Equal(t, 1, calls, "The handler should not have been called again")
+}
+
+// TestQueryDeferredPlainJSON confirms that servers without incremental delivery are understood.
+func TestQueryDeferredPlainJSON(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","description":"A basic GraphQL client library for Go"}}}`))
+	}))
+	defer server.Close()
+
+	var parts []*QueryResponse
+	client := CreateClient(server.URL, nil)
+	err := client.QueryDeferred(context.Background(), &deferredQuery, nil, func(response *QueryResponse) error {
+		parts = append(parts, response)
+		return nil
+	})
+	assert.Nil(t, err, "The query should not have failed")
+	assert.Equal(t, 1, len(parts), "The whole response should have been delivered at once")
+	assert.Contains(t, string(*parts[0].Data.(*json.RawMessage)), "A basic GraphQL client library for Go")
+}
@@ -0,0 +1,85 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// EachOption is a function that adjusts how QueryEach(...) runs its queries.
+type EachOption func(*eachConfig)
+
+// eachConfig holds the settings made by EachOption values.
+type eachConfig struct {
+	concurrency int // The maximum number of queries in flight at once
+}
+
+// WithEachConcurrency is an EachOption that allows QueryEach(...) to run up to n queries at once.
+// By default the queries are run one at a time.
+func WithEachConcurrency(n int) EachOption {
+	return func(c *eachConfig) {
+		c.concurrency = n
+	}
+}
+
+// QueryEach runs the same query once for each of the inputs, e.g. a list of repository owner and
+// name pairs, building the variables of each query from its input with buildVars and decoding each
+// response with decode. The decode function is given the response with its Data field set to a
+// *json.RawMessage holding the raw JSON of the data, and is not called if the query fails or the
+// GraphQL service reports errors.
+//
+// The results and errors are returned in two slices aligned with the inputs: for each input, either
+// the result or the error at the same index is set. For example:
+//
+// 		results, errs := gqlclient.QueryEach(client, repoQuery, repos,
+// 			func(r Repo) map[string]interface{} {
+// 				return map[string]interface{}{"owner": r.Owner, "name": r.Name}
+// 			},
+// 			decodeRepo, gqlclient.WithEachConcurrency(4))
+//
+func QueryEach[T, R any](client GqlClient, queryStr string, inputs []T, buildVars func(T) map[string]interface{},
+	decode func(*QueryResponse) (R, error), opts ...EachOption) ([]R, []error) {
+
+	config := eachConfig{concurrency: 1}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+
+	// Work through the inputs with as many workers as we are allowed, each recording its outcome
+	// in the slot belonging to its input
+	results := make([]R, len(inputs))
+	errs := make([]error, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < config.concurrency && w < len(inputs); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], errs[i] = queryOne(client, queryStr, buildVars(inputs[i]), decode)
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results, errs
+}
+
+// queryOne runs a single query for QueryEach(...) and decodes its response.
+func queryOne[R any](client GqlClient, queryStr string, queryParms map[string]interface{}, decode func(*QueryResponse) (R, error)) (R, error) {
+	var result R
+	response := QueryResponse{Data: new(json.RawMessage)}
+	if err := client.QueryContext(context.Background(), &queryStr, &queryParms, &response); err != nil {
+		return result, err
+	}
+	if err := response.Err(); err != nil {
+		return result, err
+	}
+	return decode(&response)
+}
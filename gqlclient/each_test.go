@@ -0,0 +1,71 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for running a query over many inputs.
+
+// repoInput is an input to QueryEach(...) in these tests
+type repoInput struct {
+	owner, name string
+}
+
+// TestQueryEach confirms that results and errors are aligned with the inputs, whether the queries
+// are run one at a time or concurrently.
+func TestQueryEach(t *testing.T) {
+
+	// Echo the repository name back, unless it does not exist, keeping track of the concurrency
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables["name"] == "i-dont-exist" {
+			w.Write([]byte(`{"data":{"repository":null},"errors":[{"message":"Could not resolve to a Repository"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"` + body.Variables["name"] + `","owner":{"login":"` + body.Variables["owner"] + `"}}}}`))
+	}))
+	defer server.Close()
+
+	inputs := []repoInput{{"mikebway", "gogql"}, {"mikebway", "i-dont-exist"}, {"golang", "go"}}
+	buildVars := func(in repoInput) map[string]interface{} {
+		return map[string]interface{}{"owner": in.owner, "name": in.name}
+	}
+	decode := func(response *QueryResponse) (string, error) {
+		var data SimpleRepoDataResponse
+		err := json.Unmarshal(*response.Data.(*json.RawMessage), &data)
+		return data.Repository.Owner.Login + "/" + data.Repository.Name, err
+	}
+
+	client := CreateClient(server.URL, nil)
+	for _, concurrency := range []int{1, 3} {
+		atomic.StoreInt32(&maxInFlight, 0)
+		results, errs := QueryEach(client, SimpleRepoDataQuery, inputs, buildVars, decode, WithEachConcurrency(concurrency))
+		assert.Equal(t, []string{"mikebway/gogql", "", "golang/go"}, results)
+		assert.Nil(t, errs[0])
+		assert.NotNil(t, errs[1], "The missing repository should have failed")
+		assert.Contains(t, errs[1].Error(), "Could not resolve to a Repository")
+		assert.Nil(t, errs[2])
+		assert.True(t, atomic.LoadInt32(&maxInFlight) <= int32(concurrency), "No more than %d queries should have been in flight", concurrency)
+	}
+}
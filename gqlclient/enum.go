@@ -0,0 +1,37 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// enumNamePattern matches the names that the GraphQL specification allows for enum values.
+var enumNamePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Enum is the value of a GraphQL enum, for use as a query variable. GraphQL enum variables are sent
+// as plain JSON strings, so an ordinary Go string works just as well, but an Enum makes plain that
+// the server expects one of the identifiers of an enum type, and is checked before the query is sent
+// rather than being rejected by the server. For example:
+//
+// 		query := "query($s: [PullRequestState!]) { repository(...) { pullRequests(states: $s) { totalCount } } }"
+// 		queryParms := map[string]interface{}{"s": []gqlclient.Enum{gqlclient.EnumVar("OPEN")}}
+//
+// The identifier is sent exactly as given; GraphQL enum values are case sensitive, so "open" is not
+// the same value as "OPEN".
+type Enum string
+
+// EnumVar returns the Enum value with the given identifier, e.g. EnumVar("OPEN").
+func EnumVar(name string) Enum {
+	return Enum(name)
+}
+
+// MarshalJSON encodes the enum value as a JSON string, failing if it is not a name that GraphQL
+// allows for an enum value. The query then fails with the error before anything is sent.
+func (e Enum) MarshalJSON() ([]byte, error) {
+	name := string(e)
+	if !enumNamePattern.MatchString(name) || name == "true" || name == "false" || name == "null" {
+		return nil, fmt.Errorf("%q is not a valid GraphQL enum value", name)
+	}
+	return json.Marshal(name)
+}
@@ -0,0 +1,61 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for GraphQL enum variables.
+
+// TestEnumVariable confirms that enum values are sent as the exact identifiers given.
+func TestEnumVariable(t *testing.T) {
+
+	// Record the body of each request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil)
+
+	query := "query($s: [PullRequestState!]) { repository(owner: \"mikebway\", name: \"gogql\") { pullRequests(states: $s) { totalCount } } }"
+	queryParms := map[string]interface{}{"s": []Enum{Enum("OPEN"), EnumVar("MERGED")}}
+	assert.Nil(t, client.Query(&query, &queryParms, &QueryResponse{}), "The query should have been sent")
+	var sent struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	assert.Nil(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, []interface{}{"OPEN", "MERGED"}, sent.Variables["s"], "The identifiers should have been sent as given")
+}
+
+// TestEnumInvalid confirms that values that cannot be enum identifiers are refused before sending.
+func TestEnumInvalid(t *testing.T) {
+
+	for _, name := range []string{"", "IN PROGRESS", "1ST", "null", "true", "open-ish"} {
+		_, err := json.Marshal(EnumVar(name))
+		assert.NotNil(t, err, "%q should have been refused", name)
+	}
+	for _, name := range []string{"OPEN", "open", "_internal", "V2", "NULL"} {
+		text, err := json.Marshal(EnumVar(name))
+		assert.Nil(t, err, "%q should have been accepted", name)
+		assert.Equal(t, `"`+name+`"`, string(text))
+	}
+
+	// A query with a bad enum should fail without reaching the server
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	query := "query($s: PullRequestState) { viewer { login } }"
+	queryParms := map[string]interface{}{"s": EnumVar("IN PROGRESS")}
+	assert.NotNil(t, CreateClient(server.URL, nil).Query(&query, &queryParms, &QueryResponse{}))
+	assert.Equal(t, 0, requests, "Nothing should have been sent")
+}
@@ -0,0 +1,288 @@
+package gqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEmptyResponse is the error returned when the GraphQL server, or something between it and the
+// client such as a misbehaving proxy, responds with 200 OK but an empty body.
+var ErrEmptyResponse = errors.New("GraphQL server returned an empty 200 response")
+
+// AuthError is the error returned when the GraphQL server rejects a request with a 401
+// UNAUTHORIZED response, typically because the authorization token is missing, invalid or expired.
+type AuthError struct {
+	StatusCode int // The HTTP status code of the response
+}
+
+// Error describes the authorization failure.
+func (e AuthError) Error() string {
+	return "Recieved 401 UNAUTHORIZED response! Did you need to provide an authorization key?"
+}
+
+// RateLimitError is the error returned when the GraphQL server rejects a request with a 429 TOO
+// MANY REQUESTS response. Clients configured with WithRetry(...) will retry such requests, waiting
+// at least as long as the server asks.
+type RateLimitError struct {
+	StatusCode int           // The HTTP status code of the response
+	RetryAfter time.Duration // The delay requested by the server's Retry-After header, zero if none was given
+}
+
+// Error describes the rate limit rejection.
+func (e RateLimitError) Error() string {
+	msg := fmt.Sprintf("rate limited by GraphQL server (%d %s)", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
+
+// StatusError is the error returned when the GraphQL server responds with an HTTP status code other
+// than 200 OK that is not covered by a more specific error type such as AuthError or RateLimitError.
+type StatusError struct {
+	StatusCode int    // The HTTP status code of the response
+	Status     string // The HTTP status line of the response, e.g. "502 Bad Gateway"
+}
+
+// Error describes the unexpected response status.
+func (e StatusError) Error() string {
+	return "Expected 200 response but received: " + e.Status
+}
+
+// retryableStatusCodes are the HTTP status codes that IsRetryableError(...) considers transient.
+var retryableStatusCodes = map[int]bool{
+	http.StatusBadGateway:         true,
+	http.StatusServiceUnavailable: true,
+	http.StatusGatewayTimeout:     true,
+}
+
+// retryableGraphQLCodes are the GraphQL error codes that IsRetryableError(...) considers transient.
+var retryableGraphQLCodes = map[string]bool{
+	"RATE_LIMITED":        true,
+	"SERVICE_UNAVAILABLE": true,
+	"TIMEOUT":             true,
+}
+
+// IsRetryableError reports whether an error returned by a GqlClient is of a transient kind that
+// might succeed if the query were repeated later, for the benefit of callers that manage their own
+// retries, e.g. through a job queue, rather than configuring WithRetry(...).
+//
+// Network failures, connections dropped mid-response, 502, 503 and 504 responses and RateLimitError
+// are considered retryable, as are GraphQL errors if every one of them carries a code such as
+// RATE_LIMITED, SERVICE_UNAVAILABLE or TIMEOUT. Everything else, including AuthError,
+// ErrBudgetExceeded, RedirectError, ErrRequestTooLarge and the expiry or cancellation of the
+// caller's context, is considered permanent.
+func IsRetryableError(err error) bool {
+
+	// Nothing to retry if there was no error, or if the caller gave up
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Transport level failures
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Server responses that ask us to try again later
+	var rateErr RateLimitError
+	if errors.As(err, &rateErr) {
+		return true
+	}
+	var statusErr StatusError
+	if errors.As(err, &statusErr) {
+		return retryableStatusCodes[statusErr.StatusCode]
+	}
+
+	// GraphQL errors are only worth retrying if none of them is permanent
+	var multiErr *MultiGraphQLError
+	if errors.As(err, &multiErr) {
+		for i := range multiErr.Errors {
+			if !retryableGraphQLCodes[multiErr.Errors[i].Code()] {
+				return false
+			}
+		}
+		return len(multiErr.Errors) > 0
+	}
+	var gqlErr *GraphQLError
+	if errors.As(err, &gqlErr) {
+		return retryableGraphQLCodes[gqlErr.Code()]
+	}
+	return false
+}
+
+// parseRetryAfter interprets a Retry-After header value, which may be either a number of seconds or
+// an HTTP date, the latter being measured from the given current time. Zero is returned if the value
+// is missing or cannot be understood.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil && when.After(now) {
+		return when.Sub(now).Round(time.Second)
+	}
+	return 0
+}
+
+// GraphQLError is a single error reported by a GraphQL service in the errors list of its response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`    // The description of the error
+	Type       string                 `json:"type"`       // The category of the error, e.g. FORBIDDEN, where reported by github
+	Locations  []ErrorLocation        `json:"locations"`  // The places in the query document to which the error relates, if any
+	Path       ErrorPath              `json:"path"`       // The path to the field of the response in which the error arose, if any
+	Extensions map[string]interface{} `json:"extensions"` // Additional information about the error, if any
+}
+
+// ErrorLocation is a place in a query document to which a GraphQL error relates, as found in the
+// locations list of the error. Lines and columns are counted from 1.
+type ErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Code returns the error code found in the extensions of the error, or the empty string if there is
+// none. Servers following the common convention report codes such as "FORBIDDEN" as extensions.code.
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Error returns the message reported by the GraphQL service.
+func (e *GraphQLError) Error() string {
+	return e.Message
+}
+
+// ErrorPath is the path to the field of a response in which a GraphQL error arose, as found in the
+// path list of the error: field names as strings, interspersed with list indexes as numbers, e.g.
+// ["repository", "owner", 0, "login"].
+type ErrorPath []interface{}
+
+// String formats the path with dots between field names and list indexes in brackets, e.g.
+// "repository.owner[0].login".
+func (p ErrorPath) String() string {
+	var sb strings.Builder
+	for _, element := range p {
+		switch e := element.(type) {
+		case string:
+			if sb.Len() > 0 {
+				sb.WriteString(".")
+			}
+			sb.WriteString(e)
+		case float64:
+			fmt.Fprintf(&sb, "[%d]", int(e))
+		default:
+			fmt.Fprintf(&sb, "[%v]", e)
+		}
+	}
+	return sb.String()
+}
+
+// PathString describes the path to the field in which the error arose, as by ErrorPath.String(), or
+// returns the empty string if the service reported no path.
+func (e GraphQLError) PathString() string {
+	return e.Path.String()
+}
+
+// LocationString describes the locations of the error in a form suited to log messages, e.g.
+// "line 3, column 7", or "line 3, column 7; line 8, column 2" where there are several. The empty
+// string is returned if the service reported no locations.
+func (e GraphQLError) LocationString() string {
+	locations := make([]string, len(e.Locations))
+	for i, loc := range e.Locations {
+		locations[i] = fmt.Sprintf("line %d, column %d", loc.Line, loc.Column)
+	}
+	return strings.Join(locations, "; ")
+}
+
+// GraphQLErrors is the list of errors reported by a GraphQL service in a response. The specification
+// calls for a list but some servers report a single error as an object on its own; both are accepted
+// when decoding, the latter becoming a list of one.
+type GraphQLErrors []GraphQLError
+
+// UnmarshalJSON decodes either a list of errors or a single error object.
+func (e *GraphQLErrors) UnmarshalJSON(b []byte) error {
+	trimmed := bytes.TrimSpace(b)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		var single GraphQLError
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return err
+		}
+		*e = GraphQLErrors{single}
+		return nil
+	}
+	var list []GraphQLError
+	if err := json.Unmarshal(trimmed, &list); err != nil {
+		return err
+	}
+	*e = list
+	return nil
+}
+
+// MultiGraphQLError is an error that aggregates all of the errors reported by a GraphQL service in
+// a single response, as returned by QueryResponse.Err(). The individual errors can be examined
+// directly through the Errors field or with errors.As(...), which will find the first *GraphQLError
+// in the list.
+type MultiGraphQLError struct {
+	Errors []GraphQLError // The errors reported by the GraphQL service
+}
+
+// Error assembles the messages of all of the GraphQL errors into a single string.
+func (e *MultiGraphQLError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("Errors found in GraphQL Response:\n\n")
+	for _, ge := range e.Errors {
+		sb.WriteString(ge.Message)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// Unwrap returns the individual GraphQL errors for the benefit of errors.Is(...) and errors.As(...).
+func (e *MultiGraphQLError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = &e.Errors[i]
+	}
+	return errs
+}
+
+// HasErrors returns true if the GraphQL service reported any errors in the response.
+func (r *QueryResponse) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// FirstError returns the first of the errors reported by the GraphQL service in the response, as a
+// *GraphQLError, or nil if there were none.
+func (r *QueryResponse) FirstError() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	return &r.Errors[0]
+}
+
+// Err returns a *MultiGraphQLError aggregating all of the errors reported by the GraphQL service in
+// the response, or nil if there were none. For example:
+//
+// 		if err := response.Err(); err != nil {
+// 			return err
+// 		}
+//
+func (r *QueryResponse) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	return &MultiGraphQLError{Errors: r.Errors}
+}
@@ -0,0 +1,211 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the GraphQL error types.
+
+// TestMultiGraphQLError confirms the aggregate error message and access to the individual errors.
+func TestMultiGraphQLError(t *testing.T) {
+
+	// Decode a response carrying two errors
+	response := QueryResponse{}
+	err := json.Unmarshal([]byte(`{"data":null,"errors":[{"message":"first problem"},{"message":"second problem"}]}`), &response)
+	assert.Nil(t, err, "Response should have been decoded")
+	assert.Equal(t, 2, len(response.Errors), "Both errors should have been decoded")
+
+	// Wrap them and check the message
+	err = &MultiGraphQLError{Errors: response.Errors}
+	assert.Equal(t, "Errors found in GraphQL Response:\n\nfirst problem\nsecond problem\n", err.Error())
+
+	// Unwrapping should find the first of the individual errors
+	var gqlErr *GraphQLError
+	assert.True(t, errors.As(err, &gqlErr), "Should have been able to unwrap a GraphQLError")
+	assert.Equal(t, "first problem", gqlErr.Message)
+}
+
+// TestQueryResponseErrors confirms the error helper methods of QueryResponse for responses with
+// zero, one and multiple errors.
+func TestQueryResponseErrors(t *testing.T) {
+
+	// No errors at all, or an empty list of them
+	for _, body := range []string{`{"data":{}}`, `{"data":{},"errors":[]}`} {
+		response := QueryResponse{}
+		assert.Nil(t, json.Unmarshal([]byte(body), &response))
+		assert.False(t, response.HasErrors(), "%s should have no errors", body)
+		assert.Nil(t, response.FirstError(), "%s should have no first error", body)
+		assert.Nil(t, response.Err(), "%s should have no aggregate error", body)
+	}
+
+	// A single error
+	response := QueryResponse{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"errors":[{"message":"only problem","extensions":{"code":"NOT_FOUND"}}]}`), &response))
+	assert.True(t, response.HasErrors())
+	var gqlErr *GraphQLError
+	assert.True(t, errors.As(response.FirstError(), &gqlErr), "The first error should be a *GraphQLError")
+	assert.Equal(t, "NOT_FOUND", gqlErr.Code())
+	assert.Equal(t, "Errors found in GraphQL Response:\n\nonly problem\n", response.Err().Error())
+
+	// Several errors
+	response = QueryResponse{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"errors":[{"message":"first problem"},{"message":"second problem"}]}`), &response))
+	assert.True(t, response.HasErrors())
+	assert.Equal(t, "first problem", response.FirstError().Error())
+	var multiErr *MultiGraphQLError
+	assert.True(t, errors.As(response.Err(), &multiErr), "The aggregate error should be a *MultiGraphQLError")
+	assert.Equal(t, 2, len(multiErr.Errors))
+	assert.Equal(t, "Errors found in GraphQL Response:\n\nfirst problem\nsecond problem\n", response.Err().Error())
+}
+
+// TestIsRetryableErrorTransient confirms that transient failures are recognized as retryable, even
+// when wrapped.
+func TestIsRetryableErrorTransient(t *testing.T) {
+
+	// Network failures, as the http.Client would report them
+	opErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	assert.True(t, IsRetryableError(opErr), "A network failure should be retryable")
+	assert.True(t, IsRetryableError(&url.Error{Op: "Post", URL: "https://example.com", Err: opErr}), "A wrapped network failure should be retryable")
+	assert.True(t, IsRetryableError(io.ErrUnexpectedEOF), "A truncated response should be retryable")
+
+	// Server responses that invite a retry
+	for _, code := range []int{502, 503, 504} {
+		assert.True(t, IsRetryableError(StatusError{StatusCode: code, Status: http.StatusText(code)}), "A %d response should be retryable", code)
+	}
+	assert.True(t, IsRetryableError(RateLimitError{StatusCode: 429}), "Rate limiting should be retryable")
+	assert.True(t, IsRetryableError(fmt.Errorf("page 2: %w", RateLimitError{StatusCode: 429})), "Wrapped rate limiting should be retryable")
+
+	// GraphQL errors that all carry transient codes
+	timeout := GraphQLError{Message: "timed out", Extensions: map[string]interface{}{"code": "TIMEOUT"}}
+	assert.True(t, IsRetryableError(&timeout), "A GraphQL timeout should be retryable")
+	assert.True(t, IsRetryableError(&MultiGraphQLError{Errors: []GraphQLError{timeout, timeout}}), "GraphQL timeouts should be retryable")
+}
+
+// TestIsRetryableErrorPermanent confirms that permanent failures are not considered retryable.
+func TestIsRetryableErrorPermanent(t *testing.T) {
+
+	assert.False(t, IsRetryableError(nil), "No error is nothing to retry")
+	assert.False(t, IsRetryableError(AuthError{StatusCode: 401}), "An authorization failure should not be retryable")
+	assert.False(t, IsRetryableError(ErrBudgetExceeded{Budget: 10, Cost: 20}), "An overspent budget should not be retryable")
+	assert.False(t, IsRetryableError(RedirectError{StatusCode: 301, Location: "https://example.com"}), "A refused redirect should not be retryable")
+	assert.False(t, IsRetryableError(ErrRequestTooLarge{Size: 20, Limit: 10}), "An oversized request should not be retryable")
+	assert.False(t, IsRetryableError(StatusError{StatusCode: 500, Status: "500 Internal Server Error"}), "A 500 response should not be retryable")
+	assert.False(t, IsRetryableError(StatusError{StatusCode: 404, Status: "404 Not Found"}), "A 404 response should not be retryable")
+	assert.False(t, IsRetryableError(context.DeadlineExceeded), "An expired context should not be retryable")
+	assert.False(t, IsRetryableError(errors.New("something else")), "An unknown error should not be retryable")
+
+	// GraphQL errors with permanent codes, or none at all, spoil the whole response
+	forbidden := GraphQLError{Message: "forbidden", Extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+	timeout := GraphQLError{Message: "timed out", Extensions: map[string]interface{}{"code": "TIMEOUT"}}
+	assert.False(t, IsRetryableError(&forbidden), "A forbidden GraphQL error should not be retryable")
+	assert.False(t, IsRetryableError(&GraphQLError{Message: "no code"}), "A GraphQL error without a code should not be retryable")
+	assert.False(t, IsRetryableError(&MultiGraphQLError{Errors: []GraphQLError{timeout, forbidden}}), "Mixed GraphQL errors should not be retryable")
+}
+
+// TestIsRetryableErrorFromClient confirms that the errors returned by the client for bad gateway
+// responses are recognized.
+func TestIsRetryableErrorFromClient(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	queryStr := SimpleRepoDataQuery
+	err := CreateClient(server.URL, nil).Query(&queryStr, nil, &QueryResponse{})
+	assert.NotNil(t, err, "The query should have failed")
+	assert.Equal(t, "Expected 200 response but received: 502 Bad Gateway", err.Error())
+	assert.True(t, IsRetryableError(err), "The bad gateway should be retryable")
+}
+
+// TestEmptyResponse confirms that an empty 200 response is reported as such rather than as a JSON
+// syntax error.
+func TestEmptyResponse(t *testing.T) {
+
+	for _, body := range []string{"", " \n\t "} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		queryStr := SimpleRepoDataQuery
+		err := CreateClient(server.URL, nil).Query(&queryStr, nil, &QueryResponse{Data: new(SimpleRepoDataResponse)})
+		assert.Equal(t, ErrEmptyResponse, err, "An empty body should have been reported: %q", body)
+		server.Close()
+	}
+
+	// A malformed body is still reported as malformed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":`))
+	}))
+	defer server.Close()
+	queryStr := SimpleRepoDataQuery
+	err := CreateClient(server.URL, nil).Query(&queryStr, nil, &QueryResponse{})
+	assert.NotNil(t, err, "A truncated body should have failed")
+	assert.NotEqual(t, ErrEmptyResponse, err, "A truncated body is not an empty one")
+}
+
+// TestGraphQLErrorsShapes confirms that errors are decoded whether they are reported as a list or,
+// by servers that do not follow the specification, as a single object.
+func TestGraphQLErrorsShapes(t *testing.T) {
+
+	tests := map[string]GraphQLErrors{
+		`{"data":null,"errors":[{"message":"first"},{"message":"second"}]}`: {{Message: "first"}, {Message: "second"}},
+		`{"data":null,"errors":{"message":"only","extensions":{"code":"FORBIDDEN"}}}`: {
+			{Message: "only", Extensions: map[string]interface{}{"code": "FORBIDDEN"}},
+		},
+		`{"data":null,"errors":[]}`: {},
+		`{"data":{},"errors":null}`: nil,
+		`{"data":{}}`:               nil,
+	}
+	for body, expected := range tests {
+		response := QueryResponse{}
+		err := json.Unmarshal([]byte(body), &response)
+		assert.Nil(t, err, "Response should have been decoded: %s", body)
+		assert.Equal(t, expected, response.Errors, "Errors should have been decoded: %s", body)
+	}
+
+	// A single error object should be reported just like a list of one
+	response := QueryResponse{}
+	json.Unmarshal([]byte(`{"errors":{"message":"only"}}`), &response)
+	assert.True(t, response.HasErrors())
+	assert.Equal(t, "only", response.FirstError().Error())
+
+	// Nonsense is still nonsense
+	err := json.Unmarshal([]byte(`{"errors":"broken"}`), &response)
+	assert.NotNil(t, err, "Errors that are neither a list nor an object should be reported")
+}
+
+// TestGraphQLErrorLocations confirms that the locations of an error are decoded and described.
+func TestGraphQLErrorLocations(t *testing.T) {
+
+	response := QueryResponse{}
+	body := `{"errors":[{"message":"Parse error","locations":[{"line":3,"column":7},{"line":8,"column":2}]},{"message":"no location"}]}`
+	assert.Nil(t, json.Unmarshal([]byte(body), &response))
+	assert.Equal(t, []ErrorLocation{{Line: 3, Column: 7}, {Line: 8, Column: 2}}, response.Errors[0].Locations)
+	assert.Equal(t, "line 3, column 7; line 8, column 2", response.Errors[0].LocationString())
+	assert.Equal(t, "", response.Errors[1].LocationString(), "An error without locations should have none to describe")
+}
+
+// TestGraphQLErrorPath confirms that the path of an error is decoded and formatted.
+func TestGraphQLErrorPath(t *testing.T) {
+
+	response := QueryResponse{}
+	body := `{"errors":[{"message":"not found","path":["repository","owner",0,"login"]},{"message":"no path"}]}`
+	assert.Nil(t, json.Unmarshal([]byte(body), &response))
+	assert.Equal(t, ErrorPath{"repository", "owner", float64(0), "login"}, response.Errors[0].Path)
+	assert.Equal(t, "repository.owner[0].login", response.Errors[0].PathString())
+	assert.Equal(t, "", response.Errors[1].PathString(), "An error without a path should have none to describe")
+
+	// Paths built by hand, whatever their numeric type, format alike
+	assert.Equal(t, "[2].nodes[10]", ErrorPath{2, "nodes", 10}.String())
+}
@@ -0,0 +1,126 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// FieldNameTransformer is a function that translates a field name found in the data of a GraphQL
+// response into the name that the json tags of the caller's data structures expect.
+type FieldNameTransformer func(name string) string
+
+// WithFieldNameTransformer is a ClientOption that passes the name of every field in the data of each
+// response through the given transformer before the data is decoded, so that one set of structures,
+// tagged in one naming convention, can be decoded from servers that use another. For example,
+// WithFieldNameTransformer(SnakeToCamel) allows the snake_case fields of one server to be decoded
+// into structures tagged with the camelCase names used by most others. Only the data of a response
+// is affected; its errors and extensions are decoded as received.
+//
+// Without this option, field names are matched to json tags as the encoding/json package always
+// does, which already tolerates differences of case alone. Should two fields of the same object
+// transform to the same name, which of them is decoded is undefined.
+func WithFieldNameTransformer(transform FieldNameTransformer) ClientOption {
+	return func(gc *gqlClient) {
+		gc.fieldNames = transform
+	}
+}
+
+// SnakeToCamel is a FieldNameTransformer that converts snake_case names to camelCase, e.g.
+// "created_at" to "createdAt". Leading underscores, as in "__typename", are left alone.
+func SnakeToCamel(name string) string {
+
+	// Keep any leading underscores, then capitalize whatever follows each underscore after that
+	trimmed := strings.TrimLeft(name, "_")
+	var b strings.Builder
+	b.WriteString(name[:len(name)-len(trimmed)])
+	upper := false
+	for _, r := range trimmed {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// CamelToSnake is a FieldNameTransformer that converts camelCase names to snake_case, e.g.
+// "createdAt" to "created_at". A run of capitals is treated as a single word, so "pullRequestURL"
+// becomes "pull_request_url".
+func CamelToSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+
+			// Start a new word at a capital that follows a lower case letter or digit, or that is the
+			// last capital of a run followed by lower case, as the "R" of "URLRoot"
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// transformFieldNames returns a copy of a response body in which the field names of the data have
+// been passed through the given transformer. Numbers are carried through untouched, so that no
+// precision is lost to the round trip.
+func transformFieldNames(body []byte, transform func(string) string) ([]byte, error) {
+
+	// Pick the data out of the response, leaving everything else as it is
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body, nil
+	}
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	raw, ok := envelope["data"]
+	if !ok {
+		return body, nil
+	}
+
+	// Rename the fields of the data and put it back
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	renamed, err := json.Marshal(renameFields(data, transform))
+	if err != nil {
+		return nil, err
+	}
+	envelope["data"] = renamed
+	return json.Marshal(envelope)
+}
+
+// renameFields returns the given decoded JSON value with the names of the fields of every object
+// within it passed through the transformer.
+func renameFields(value interface{}, transform func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for name, field := range v {
+			renamed[transform(name)] = renameFields(field, transform)
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = renameFields(item, transform)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,82 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for response field name transformation.
+
+// issueData is tagged with camelCase names, as most GraphQL servers would return them.
+type issueData struct {
+	Issue struct {
+		Typename  string `json:"__typename"`
+		CreatedAt string `json:"createdAt"`
+		Number    int64  `json:"number"`
+		Labels    []struct {
+			DisplayName string `json:"displayName"`
+		} `json:"labelNodes"`
+	} `json:"issue"`
+}
+
+// TestFieldNameTransformer confirms that snake_case response fields can be decoded into camelCase
+// tagged structures, and that they are not without the option.
+func TestFieldNameTransformer(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"issue":{"__typename":"Issue","created_at":"2024-07-01","number":9007199254740993,` +
+			`"label_nodes":[{"display_name":"bug"},{"display_name":"help wanted"}]}},` +
+			`"errors":[{"message":"partial","extensions":{"error_code":"X"}}]}`))
+	}))
+	defer server.Close()
+
+	// Transformed, everything lands where it should, without losing precision in the numbers
+	client := CreateClient(server.URL, nil, WithFieldNameTransformer(SnakeToCamel))
+	response := QueryResponse{Data: new(issueData)}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response), "Query should have succeeded")
+	issue := response.Data.(*issueData).Issue
+	assert.Equal(t, "Issue", issue.Typename)
+	assert.Equal(t, "2024-07-01", issue.CreatedAt)
+	assert.Equal(t, int64(9007199254740993), issue.Number)
+	assert.Equal(t, 2, len(issue.Labels))
+	assert.Equal(t, "help wanted", issue.Labels[1].DisplayName)
+	assert.Equal(t, "X", response.Errors[0].Extensions["error_code"], "Errors should not have been transformed")
+
+	// Without the transformer, the snake_case fields are not matched
+	client = CreateClient(server.URL, nil)
+	response = QueryResponse{Data: new(issueData)}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response), "Query should have succeeded")
+	assert.Empty(t, response.Data.(*issueData).Issue.CreatedAt)
+	assert.Empty(t, response.Data.(*issueData).Issue.Labels)
+}
+
+// TestFieldNameCases confirms the conversions made by the supplied transformers.
+func TestFieldNameCases(t *testing.T) {
+
+	toCamel := map[string]string{
+		"created_at":      "createdAt",
+		"createdAt":       "createdAt",
+		"__typename":      "__typename",
+		"pull_request_id": "pullRequestId",
+		"trailing_":       "trailing",
+		"":                "",
+	}
+	for name, expected := range toCamel {
+		assert.Equal(t, expected, SnakeToCamel(name), "SnakeToCamel(%q)", name)
+	}
+
+	toSnake := map[string]string{
+		"createdAt":      "created_at",
+		"created_at":     "created_at",
+		"pullRequestURL": "pull_request_url",
+		"URLRoot":        "url_root",
+		"sha256Sum":      "sha256_sum",
+		"__typename":     "__typename",
+	}
+	for name, expected := range toSnake {
+		assert.Equal(t, expected, CamelToSnake(name), "CamelToSnake(%q)", name)
+	}
+}
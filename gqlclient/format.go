@@ -0,0 +1,63 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FormatError returns a human readable, possibly multi-line, description of an error returned by a
+// GqlClient, including whatever context the type of error provides: the location, path and code of
+// each GraphQL error, the delay requested by a rate limit rejection, and so on. Errors of types that
+// FormatError does not recognize, wrapped or otherwise, are described by their own Error() method.
+func FormatError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	// Look for the richest description we know how to give
+	var multiErr *MultiGraphQLError
+	var gqlErr *GraphQLError
+	var rateErr RateLimitError
+	var authErr AuthError
+	switch {
+	case errors.As(err, &multiErr):
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "GraphQL service reported %d error(s):", len(multiErr.Errors))
+		for i := range multiErr.Errors {
+			fmt.Fprintf(&sb, "\n  %d. %s", i+1, formatGraphQLError(&multiErr.Errors[i], "     "))
+		}
+		return sb.String()
+
+	case errors.As(err, &gqlErr):
+		return "GraphQL service reported an error: " + formatGraphQLError(gqlErr, "  ")
+
+	case errors.As(err, &rateErr):
+		msg := fmt.Sprintf("Rate limited by GraphQL server\n  status: %d", rateErr.StatusCode)
+		if rateErr.RetryAfter > 0 {
+			msg += fmt.Sprintf("\n  retry after: %v", rateErr.RetryAfter)
+		}
+		return msg
+
+	case errors.As(err, &authErr):
+		return fmt.Sprintf("GraphQL server refused authorization\n  status: %d\n  check that the authorization token is present, valid and has the required scopes", authErr.StatusCode)
+	}
+	return err.Error()
+}
+
+// formatGraphQLError describes a single GraphQL error, placing any details on following lines with
+// the given indentation.
+func formatGraphQLError(e *GraphQLError, indent string) string {
+	var sb strings.Builder
+	sb.WriteString(e.Message)
+	if location := e.LocationString(); location != "" {
+		fmt.Fprintf(&sb, "\n%slocation: %s", indent, location)
+	}
+	if path := e.PathString(); path != "" {
+		fmt.Fprintf(&sb, "\n%spath: %s", indent, path)
+	}
+	if code := e.Code(); code != "" {
+		fmt.Fprintf(&sb, "\n%scode: %s", indent, code)
+	}
+	return sb.String()
+}
@@ -0,0 +1,66 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for error formatting.
+
+// TestFormatError confirms that each type of error is described with its distinctive details.
+func TestFormatError(t *testing.T) {
+
+	forbidden := GraphQLError{Message: "Resource not accessible by integration", Extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+	notFound := GraphQLError{Message: "Could not resolve to a Repository",
+		Locations: []ErrorLocation{{Line: 2, Column: 3}}, Path: ErrorPath{"repository"}}
+	badLogin := GraphQLError{Message: "Field 'login' is missing",
+		Locations: []ErrorLocation{{Line: 3, Column: 7}, {Line: 8, Column: 2}}, Path: ErrorPath{"repository", "owner", 0, "login"}}
+
+	// An aggregate of GraphQL errors should list them all
+	formatted := FormatError(&MultiGraphQLError{Errors: []GraphQLError{forbidden, notFound}})
+	assert.Contains(t, formatted, "2 error(s)")
+	assert.Contains(t, formatted, "1. Resource not accessible by integration\n     code: FORBIDDEN")
+	assert.Contains(t, formatted, "2. Could not resolve to a Repository\n     location: line 2, column 3\n     path: repository")
+
+	// A single GraphQL error, even when wrapped, should include its code
+	formatted = FormatError(fmt.Errorf("fetching repo: %w", &forbidden))
+	assert.Contains(t, formatted, "Resource not accessible by integration")
+	assert.Contains(t, formatted, "code: FORBIDDEN")
+	assert.NotContains(t, formatted, "location:", "An error without a location should not report one")
+	assert.NotContains(t, formatted, "path:", "An error without a path should not report one")
+
+	// A single GraphQL error should include its locations and path
+	formatted = FormatError(&badLogin)
+	assert.Equal(t, "GraphQL service reported an error: Field 'login' is missing\n"+
+		"  location: line 3, column 7; line 8, column 2\n  path: repository.owner[0].login", formatted)
+
+	// A rate limit error should say when to try again
+	formatted = FormatError(RateLimitError{StatusCode: 429, RetryAfter: 30 * time.Second})
+	assert.Contains(t, formatted, "status: 429")
+	assert.Contains(t, formatted, "retry after: 30s")
+
+	// An authorization error should give some advice
+	formatted = FormatError(AuthError{StatusCode: 401})
+	assert.Contains(t, formatted, "status: 401")
+	assert.Contains(t, formatted, "authorization token")
+
+	// Anything else should describe itself
+	assert.Equal(t, "something else", FormatError(errors.New("something else")))
+	assert.Equal(t, "", FormatError(nil))
+}
+
+// TestParseRetryAfter confirms both forms of the Retry-After header are understood.
+func TestParseRetryAfter(t *testing.T) {
+
+	now := time.Now()
+	assert.Equal(t, 120*time.Second, parseRetryAfter("120", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	later := now.Add(time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT")
+	delay := parseRetryAfter(later, now)
+	assert.True(t, delay > 59*time.Minute && delay <= time.Hour, "Delay %v should be about an hour", delay)
+}
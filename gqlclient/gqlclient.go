@@ -5,11 +5,12 @@ package gqlclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,6 +24,40 @@ type GqlClient interface {
 	// any parameters.
 	Query(queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error
 
+	// QueryContext behaves as Query but carries the given context through to the HTTP request and any
+	// retry delays, allowing the caller to cancel the operation or impose a deadline upon it.
+	QueryContext(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error
+
+	// UploadQuery sends a GraphQL query or mutation whose variables include one or more *Upload values
+	// as a multipart request, parsing the response into the provided object reference.
+	UploadQuery(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error
+
+	// QueryDeferred sends a GraphQL query that uses the @defer or @stream directives and delivers the
+	// initial response and each subsequent part to the handler as they arrive.
+	QueryDeferred(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, handler func(*QueryResponse) error) error
+
+	// QueryByID behaves as QueryContext but sends the query registered under the given ID with the
+	// client's PersistenceStore.
+	QueryByID(ctx context.Context, queryID string, queryParms *map[string]interface{}, response *QueryResponse) error
+
+	// PrepareQuery packs a query once and keeps it under the given name, to be sent by QueryPrepared.
+	PrepareQuery(name, queryStr string) error
+
+	// QueryPrepared behaves as QueryContext but sends the query prepared under the given name by
+	// PrepareQuery, without packing it again.
+	QueryPrepared(ctx context.Context, name string, queryParms *map[string]interface{}, response *QueryResponse) error
+
+	// NamedQuery behaves as QueryContext but sends the query kept under the given name in the store.
+	NamedQuery(ctx context.Context, name string, store QueryStore, queryParms *map[string]interface{}, response *QueryResponse) error
+
+	// Transaction runs the given function, which sends a sequence of mutations through the TxClient
+	// it is given, sending the rollback mutations registered for them should the function fail.
+	Transaction(ctx context.Context, fn func(tx TxClient) error) error
+
+	// Close releases the resources held by the client: its idle connections and any background
+	// work started by its options. Close may be called more than once.
+	Close() error
+
 	// GetTargetURL returns the target API URL of the GqlClient.
 	GetTargetURL() string
 }
@@ -35,21 +70,119 @@ type GqlClient interface {
 type gqlClient struct {
 	targetURL     string  // The GraphQL server URL, e.g. https://api.github.com/graphql
 	authorization *string // If not nil, the authoorization header value to be supplied with GraphQL calls
+	maxRetries    int     // The number of times a failed request may be retried, zero by default
+	backoff       Backoff // The strategy used to pace retry attempts
+	clock         Clock   // The source of the current time and of retry delays
+
+	httpClient *http.Client // If not nil, used in place of the package scoped httpClient
+
+	middleware     []Middleware        // Functions wrapped around every query, outermost first
+	transforms     []QueryTransform    // Functions applied to each packed query before it is sent
+	requestHooks   []RequestHook       // Functions given the chance to modify each request before it is sent
+	responseHooks  []ResponseHook      // Functions given the chance to supply the response to each request in place of the server
+	afterResponse  []AfterResponseHook // Functions to be shown every raw response before it is decoded
+	validators     []ResponseValidator // Checks applied to every successful response once it has been decoded
+	bodyLogger     func(body []byte)   // If not nil, given the exact bytes of every JSON request body
+	uploadProgress UploadProgressFunc  // If not nil, told of the progress of UploadQuery(...) requests
+	queryBudget    int                 // If greater than zero, the maximum acceptable rate limit cost of a query
+	autoRateLimit  bool                // If true, the rate limit is requested with every query and parsed from the response
+	autoOpName     bool                // If true, the operationName is sent along with each query
+	maxRequestSize int64               // If greater than zero, the largest JSON request body that may be sent
+	maxQueryLength int                 // If greater than zero, the longest packed query that may be sent
+	bodyTimeout    time.Duration       // If greater than zero, the time allowed to read each response body
+	contentType    string              // If not empty, the Content-Type of query requests in place of application/json
+	logger         Logger              // If not nil, told of problems that cannot be reported to a caller
+	warmup         []WarmupQuery       // Queries to be sent in the background when the client is created
+	errorSampler   *errorSampler       // If not nil, selects the responses whose GraphQL errors are logged
+	connReuse      func(reused bool)   // If not nil, told whether each request was sent over a reused connection
+	connTracing    bool                // If true, the progress of each connection is logged to the logger
+	dnsCache       *dnsCache           // If not nil, the host name addresses remembered for WithDNSCacheTimeout(...)
+	flights        *flightGroup        // If not nil, the identical queries currently in flight
+	resolver       func() string       // If not nil, chooses the GraphQL server URL for each request in place of targetURL
+	fieldNames     func(string) string // If not nil, applied to the field names of every response's data before it is decoded
+	persisted      PersistenceStore    // If not nil, the source of the queries sent by QueryByID(...)
+	stopWarmup     context.CancelFunc  // If not nil, abandons any warmup queries still running in the background
+	varSchema      *variablesSchema    // If not nil, the JSON Schema that the variables of every query must conform to
+	idempotency    bool                // If true, each mutation is sent with an Idempotency-Key header that is kept across retries
+	tokens         *tokenRing          // If not nil, the authorization values used in turn in place of authorization
+	prepared       *preparedQueries    // The queries prepared by PrepareQuery(...), ready to be sent by QueryPrepared(...)
+	roundTrip      []RoundTripObserver // Functions to be told the duration of every HTTP round trip
+	authRefresh    AuthRefreshFunc     // If not nil, called to obtain a new authorization value after a 401
+	authMutex      *sync.RWMutex       // If not nil, guards the authorization value against concurrent refreshes
 }
 
+// ClientOption is a function that configures optional behavior of a gqlClient. Options are
+// supplied to CreateClient(...) and applied in the order given.
+type ClientOption func(*gqlClient)
+
 // CreateClient returns a reference to an initialized GqlClient instance. The target URL for the
 // GraphQL must be provided. The authorization string my be nil if no token or basic auth header
 // is required by the server. A typical authirization value for a target URL, say, https://api.github.com/graphql
 // the authorization value would be of the form "token f69acf817105a9e024f3e94a80bbf09e2879abef". Note that
 // the authorization value is write only - once set in the GqlClient it cannot be accessed outside of the
 // `gqlclient` package. While the targetURL can be retrieved vai the GetTargetURL() function, it cannot be
-// modified.
-func CreateClient(targetURL string, authorization *string) GqlClient {
-	return gqlClient{targetURL, authorization}
+// modified, although the WithEndpointResolver(...) option can be used to choose the URL afresh for
+// each request.
+//
+// Any number of ClientOption values, e.g. WithRetry(...), may be supplied to adjust the behavior
+// of the client.
+func CreateClient(targetURL string, authorization *string, opts ...ClientOption) GqlClient {
+
+	// Start with the default configuration
+	gc := &gqlClient{
+		targetURL:     targetURL,
+		authorization: authorization,
+		backoff:       defaultBackoff(),
+		clock:         realClock{},
+		prepared:      newPreparedQueries(),
+	}
+
+	// Apply whatever options the caller has asked for
+	for _, opt := range opts {
+		opt(gc)
+	}
+
+	// Prime the server, if asked to, without holding up the caller
+	if len(gc.warmup) > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		gc.stopWarmup = cancel
+		go gc.warmUp(ctx)
+	}
+	return gc
+}
+
+// WithAuthorization is a ClientOption that replaces the authorization header value given to
+// CreateClient(...). It is chiefly of use with Clone(...) to derive a client with different
+// credentials. A nil authorization removes the header altogether.
+func WithAuthorization(authorization *string) ClientOption {
+	return func(gc *gqlClient) {
+		gc.authorization = authorization
+	}
+}
+
+// WithEndpointResolver is a ClientOption that has the GraphQL server URL chosen afresh for every
+// request by calling the given function, allowing a long lived client to be switched between, say,
+// staging and production servers by configuration or feature flags. If the resolver returns the
+// empty string, the URL given to CreateClient(...) is used.
+func WithEndpointResolver(resolve func() string) ClientOption {
+	return func(gc *gqlClient) {
+		gc.resolver = resolve
+	}
 }
 
-// GetTargetURL returns the target API URL of the GqlClient.
-func (gc gqlClient) GetTargetURL() string {
+// GetTargetURL returns the target API URL of the GqlClient, i.e. the URL to which a request made now
+// would be sent.
+func (gc *gqlClient) GetTargetURL() string {
+	return gc.endpoint()
+}
+
+// endpoint returns the URL to which the next request should be sent.
+func (gc *gqlClient) endpoint() string {
+	if gc.resolver != nil {
+		if url := gc.resolver(); url != "" {
+			return url
+		}
+	}
 	return gc.targetURL
 }
 
@@ -62,9 +195,51 @@ func (gc gqlClient) GetTargetURL() string {
 type QueryResponse struct {
 	Data interface {
 	} `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors"`
+	Errors GraphQLErrors `json:"errors"`
+
+	// Extensions holds any extensions map returned by the GraphQL service alongside the data. Middleware
+	// may also record information here, e.g. see ResponseTimingMiddleware().
+	Extensions map[string]interface{} `json:"extensions"`
+
+	// RateLimitInfo is populated from the rateLimit field of the response data when the client has
+	// been configured to track query costs, with WithQueryBudget(...) or WithAutoRateLimitField(). It
+	// is nil otherwise.
+	RateLimitInfo *RateLimitInfo `json:"-"`
+
+	// Path locates the Data of a part of the response delivered by QueryDeferred(...) within the
+	// full response, e.g. ["repository", "issues", 3]. It is nil otherwise.
+	Path []interface{} `json:"path"`
+
+	// bodySize is the size in bytes of the response body, for the benefit of WithMetrics(...)
+	bodySize int64
+
+	// sharedBody, if not nil, receives the body of a 200 response, for the benefit of WithSingleFlight()
+	sharedBody *[]byte
+}
+
+// UnmarshalData decodes the Data of the response into v, which must be a pointer, allowing a response
+// that was received into a generic Data object, e.g. a *json.RawMessage or a map[string]interface{},
+// to be bound to a concrete type later on, for example once it is known which shape the data takes.
+// If Data is already JSON it is decoded directly; otherwise it is first re-encoded.
+func (r *QueryResponse) UnmarshalData(v interface{}) error {
+	var raw []byte
+	switch data := r.Data.(type) {
+	case *json.RawMessage:
+		if data != nil {
+			raw = *data
+		}
+	case json.RawMessage:
+		raw = data
+	default:
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+	if len(raw) == 0 {
+		raw = []byte("null")
+	}
+	return json.Unmarshal(raw, v)
 }
 
 // PageInfo is a GraphQL connections paging information structure, returned as an optional component
@@ -98,42 +273,301 @@ type PageInfo struct {
 // The query string may be formatted with whitespace and carriage returns for readbility, any such whitespace shall
 // be removed prior to submission to the GraphQL server. The queryParms may be nil if the query does not require
 // any parameters.
-func (gc gqlClient) Query(queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+func (gc *gqlClient) Query(queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return gc.QueryContext(context.Background(), queryStr, queryParms, response)
+}
+
+// QueryContext behaves as Query but carries the given context through to the HTTP request and any
+// retry delays, allowing the caller to cancel the operation or impose a deadline upon it.
+func (gc *gqlClient) QueryContext(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+
+	// Wrap the real work in whatever middleware has been configured, the first registered outermost
+	query := QueryFunc(gc.query)
+	for i := len(gc.middleware) - 1; i >= 0; i-- {
+		query = gc.middleware[i](query)
+	}
+	err := query(ctx, queryStr, queryParms, response)
+	if err == nil {
+		gc.logSampledErrors(queryStr, response)
+	}
+	return err
+}
+
+// query does the real work of QueryContext(...), once any middleware has had its say.
+func (gc *gqlClient) query(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
 
 	// Build the GraphQL query into JSON that we can POST
-	q := query{packQuery(queryStr), *queryParms}
-	queryBytes, err := json.Marshal(q)
+	packed, err := gc.prepareQuery(ctx, queryStr)
 	if err != nil {
 		return err
 	}
+	if err := gc.checkVariables(queryParms); err != nil {
+		return err
+	}
+	ctx = gc.idempotencyContext(ctx, packed)
+	ctx = gc.roundTripContext(ctx, packed)
+	queryBytes, err := gc.encodeQuery(ctx, packed, queryParms)
+	if err != nil {
+		return err
+	}
+	if err := gc.checkRequestSize(queryBytes); err != nil {
+		return err
+	}
+
+	// Join any identical query that is already on its way, if we have been asked to; mutations and
+	// subscriptions always go on their own, lest their side effects be lost
+	if gc.flights != nil && queriesOnly(packed) {
+		return gc.singleFlight(ctx, queryBytes, response)
+	}
+	return gc.deliver(ctx, queryBytes, response)
+}
+
+// deliver posts the JSON encoded query to the GraphQL server, parsing the response into the provided
+// object reference, and retrying and refreshing the authorization as the client has been configured.
+func (gc *gqlClient) deliver(ctx context.Context, queryBytes []byte, response *QueryResponse) error {
 
-	// Form up an HTTP POST request, supplying the github access token
-	req, _ := http.NewRequest("POST", gc.targetURL, bytes.NewReader(queryBytes))
-	req.Header.Set("Content-Type", "application/json")
-	if gc.authorization != nil {
-		req.Header.Add("Authorization", *gc.authorization)
+	// Keep trying until we succeed, hit an error that is not worth retrying, or run out of retries
+	refreshed := false
+	for attempt := 1; ; attempt++ {
+		authorization := gc.authHeader()
+		retry, err := gc.post(ctx, queryBytes, response)
+
+		// If our token has been rejected and we know how to get a new one, try again straight
+		// away, but only the once
+		var authErr AuthError
+		if gc.authRefresh != nil && !refreshed && errors.As(err, &authErr) {
+			if err := gc.refreshAuth(ctx, authorization); err != nil {
+				return err
+			}
+			refreshed = true
+			attempt--
+			continue
+		}
+		if err == nil || !retry || attempt > gc.maxRetries {
+			return err
+		}
+
+		// Wait as long as our backoff strategy tells us to, or the server asked us to if that is
+		// longer, unless the context gives up first
+		delay := gc.backoff.NextDelay(attempt)
+		var rateErr RateLimitError
+		if errors.As(err, &rateErr) && rateErr.RetryAfter > delay {
+			delay = rateErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-gc.clock.After(delay):
+		}
 	}
+}
+
+// prepareQuery packs the query string, checks its length and applies any configured transforms to it.
+func (gc *gqlClient) prepareQuery(ctx context.Context, queryStr *string) (string, error) {
 
-	// Submit the POST and wait for the response
-	resp, err := httpClient.Do(req)
+	// Queries prepared by PrepareQuery(...) are packed already, unless middleware has swapped them
+	var packed string
+	if prepared, ok := ctx.Value(preparedContextKey{}).(*preparedQuery); ok && prepared.packed == *queryStr {
+		packed = prepared.packed
+	} else {
+		packed = packQuery(queryStr)
+	}
+	if err := gc.checkQueryLength(packed); err != nil {
+		return "", err
+	}
+	for _, transform := range gc.transforms {
+		var err error
+		if packed, err = transform(packed); err != nil {
+			return "", err
+		}
+	}
+	return packed, nil
+}
+
+// post makes a single attempt to deliver the JSON encoded query to the GraphQL server and parse
+// the response into the provided object reference. If an error is returned, the boolean result
+// is true if the failure was of a transient kind that might succeed on a later attempt.
+func (gc *gqlClient) post(ctx context.Context, queryBytes []byte, response *QueryResponse) (bool, error) {
+
+	// Make an audit record of exactly what we are about to send
+	if gc.bodyLogger != nil {
+		if err := gc.logRequestBody(queryBytes); err != nil {
+			return false, err
+		}
+	}
+
+	// Form up an HTTP POST request
+	req, err := http.NewRequest("POST", gc.endpoint(), bytes.NewReader(queryBytes))
 	if err != nil {
-		return err
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", gc.requestContentType())
+	return gc.send(req, response)
+}
+
+// send completes an HTTP request by supplying the authorization header, submits it to the GraphQL
+// server, and parses the response into the provided object reference. If an error is returned, the
+// boolean result is true if the failure was of a transient kind that might succeed on a later attempt.
+func (gc *gqlClient) send(req *http.Request, response *QueryResponse) (bool, error) {
+
+	// Send the request
+	resp, retry, err := gc.do(req)
+	if err != nil {
+		return retry, err
 	}
 	defer resp.Body.Close()
 
+	// Load the raw response body, whatever the status, and let any interested hooks see it
+	body, err := gc.readBody(resp.Body)
+	if err != nil {
+		return req.Context().Err() == nil, err
+	}
+	response.bodySize = int64(len(body))
+	for _, hook := range gc.afterResponse {
+		hook(req, resp, body)
+	}
+
 	// If the response status code is not 200, report an error
-	if resp.StatusCode != 200 {
-		if resp.StatusCode == 401 {
-			return errors.New("Recieved 401 UNAUTHORIZED response! Did you need to provide an authorization key?")
+	if retry, err := gc.checkStatus(req, resp); err != nil {
+		return retry, err
+	}
+
+	// Share the body with anyone else waiting for it, then decode it for ourselves
+	if response.sharedBody != nil {
+		*response.sharedBody = body
+	}
+	return false, gc.decode(body, response)
+}
+
+// decode unmarshals the body of a 200 response into the provided object reference and checks that
+// it makes sense.
+func (gc *gqlClient) decode(body []byte, response *QueryResponse) error {
+
+	// Unmarshal the response into the provided object, translating its field names if we have been
+	// asked to, and check that it makes sense
+	decodable := body
+	if gc.fieldNames != nil {
+		var err error
+		if decodable, err = transformFieldNames(body, gc.fieldNames); err != nil {
+			return err
 		}
-		return errors.New("Expected 200 response but received: " + resp.Status)
 	}
+	err := unmarshalResponse(decodable, response)
+	if err != nil {
+		return err
+	}
+	if err := gc.validate(response); err != nil || (gc.queryBudget <= 0 && !gc.autoRateLimit) {
+		return err
+	}
+
+	// Pick out the rate limit information that we asked for and hold the query to its budget
+	response.RateLimitInfo = parseRateLimit(body)
+	return gc.checkBudget(response.RateLimitInfo)
+}
 
-	// Load the raw response body
-	body, _ := ioutil.ReadAll(resp.Body)
+// do supplies the authorization header of an HTTP request, gives the request and response hooks
+// their say and, unless a response hook supplied one, submits the request to the GraphQL server,
+// returning the response for the caller to read and close. If an error is returned, the boolean
+// result is true if the failure was of a transient kind that might succeed on a later attempt.
+func (gc *gqlClient) do(req *http.Request) (*http.Response, bool, error) {
 
-	// Unmarshal the response into the provided object
-	return json.Unmarshal(body, &response)
+	// Supply the github access token, or whatever other authorization we have been given
+	ctx := req.Context()
+	if authorization := gc.authHeader(); authorization != nil {
+		req.Header.Add("Authorization", *authorization)
+	}
+	tokenIndex := -1
+	if gc.tokens != nil {
+		var token string
+		tokenIndex, token = gc.tokens.pick(gc.clock.Now())
+		req.Header.Set("Authorization", token)
+	}
+
+	// Identify the operation, if it is to be recognised when it is retried
+	if key, ok := ctx.Value(idempotencyContextKey{}).(string); ok && key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	// Give any request hooks their chance to adjust the request
+	for _, hook := range gc.requestHooks {
+		if err := hook(req); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// A response hook may answer the request itself, sparing us the trip to the server
+	for _, hook := range gc.responseHooks {
+		if resp, ok := hook(req); ok {
+			return substituteResponse(req, resp)
+		}
+	}
+
+	// Follow the request onto its connection, if anyone wants to know how that goes
+	if gc.connReuse != nil {
+		req = req.WithContext(traceConnReuse(req.Context(), gc.connReuse))
+	}
+	if gc.connTracing && gc.logger != nil {
+		req = req.WithContext(traceConnection(req.Context(), gc.logger))
+	}
+
+	// Submit the POST and wait for the response. Network failures are worth retrying but
+	// not if it was our own context that brought things to a halt.
+	resp, err := gc.sendRequest(req)
+	if err == nil && tokenIndex >= 0 {
+		resp, err = gc.switchTokens(req, resp, tokenIndex)
+	}
+	if err != nil {
+		return nil, ctx.Err() == nil && !isRedirectError(err), err
+	}
+	return resp, false, nil
+}
+
+// sendRequest sends the request over the client's http.Client, telling any round trip observers how
+// long it took.
+func (gc *gqlClient) sendRequest(req *http.Request) (*http.Response, error) {
+	start := gc.clock.Now()
+	resp, err := gc.client().Do(req)
+	if len(gc.roundTrip) > 0 {
+		gc.observeRoundTrip(req, gc.clock.Now().Sub(start))
+	}
+	return resp, err
+}
+
+// checkStatus returns an error describing the response if its status code is anything other than
+// 200 OK. If an error is returned, the boolean result is true if the failure was of a transient kind
+// that might succeed on a later attempt.
+func (gc *gqlClient) checkStatus(req *http.Request, resp *http.Response) (bool, error) {
+	if resp.StatusCode == 200 {
+		return false, nil
+	}
+	if resp.StatusCode == 401 {
+		return false, AuthError{StatusCode: resp.StatusCode}
+	}
+	if err := unfollowedRedirect(req, resp); err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true, RateLimitError{StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), gc.clock.Now())}
+	}
+	return resp.StatusCode >= 500, StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+}
+
+// unmarshalResponse decodes the body of a 200 OK response into the provided object reference,
+// returning ErrEmptyResponse rather than a JSON syntax error if the body is empty.
+func unmarshalResponse(body []byte, response *QueryResponse) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ErrEmptyResponse
+	}
+	return json.Unmarshal(body, response)
+}
+
+// client returns the http.Client through which the gqlClient should send its requests.
+func (gc *gqlClient) client() *http.Client {
+	if gc.httpClient != nil {
+		return gc.httpClient
+	}
+	return httpClient
 }
 
 // packQuery strips whitespace and newlines from a formatted GraphQL query.
@@ -145,8 +579,9 @@ func packQuery(str *string) string {
 
 // For GraphQL over HTTP 1.1, the query and its parameters must be wrapped in a JSON object.
 type query struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
 }
 
 // httpClient is a package scoped http client declaration that can be overriden by unit tests
@@ -5,6 +5,9 @@ This file contains unit test code for gqlclient.
 package gqlclient
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
@@ -148,3 +151,67 @@ func TestInvalidAuth(t *testing.T) {
 	assert.NotEmpty(t, err, "Call with invalid authorization should have failed")
 	assert.Contains(t, err.Error(), "Recieved 401 UNAUTHORIZED response!", err.Error(), "http client should have reported a 401 error")
 }
+
+// TestUnmarshalData confirms that generic response data can be decoded into a concrete type later on.
+func TestUnmarshalData(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil)
+
+	// Receive the data in each of the generic forms, then decode it properly
+	for _, data := range []interface{}{nil, new(interface{}), new(json.RawMessage), map[string]interface{}{}} {
+		response := QueryResponse{Data: data}
+		err := client.Query(&SimpleRepoDataQuery, nil, &response)
+		assert.Nil(t, err, "Query should have succeeded for %T", data)
+
+		var repo SimpleRepoDataResponse
+		err = response.UnmarshalData(&repo)
+		assert.Nil(t, err, "Data should have been decoded from %T", data)
+		assert.Equal(t, "gogql", repo.Repository.Name, "Name should have been decoded from %T", data)
+		assert.Equal(t, "mikebway", repo.Repository.Owner.Login, "Owner should have been decoded from %T", data)
+	}
+
+	// No data leaves the target untouched
+	repo := SimpleRepoDataResponse{}
+	repo.Repository.Name = "unchanged"
+	assert.Nil(t, (&QueryResponse{}).UnmarshalData(&repo))
+	assert.Equal(t, "unchanged", repo.Repository.Name)
+}
+
+// TestEndpointResolver confirms that the resolver chooses the server for each request, falling back
+// to the URL given to CreateClient(...) if it has nothing to say.
+func TestEndpointResolver(t *testing.T) {
+
+	// Two servers that identify themselves in their responses
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"repository":{"name":"` + name + `"}}}`))
+		}))
+	}
+	staging := newServer("staging")
+	defer staging.Close()
+	production := newServer("production")
+	defer production.Close()
+
+	// Switch between them, then fall back to the default
+	endpoints := []string{staging.URL, production.URL, ""}
+	calls := 0
+	client := CreateClient(staging.URL, nil, WithEndpointResolver(func() string {
+		calls++
+		return endpoints[(calls-1)%len(endpoints)]
+	}))
+	for _, expected := range []string{"staging", "production", "staging"} {
+		response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+		err := client.Query(&SimpleRepoDataQuery, nil, &response)
+		assert.Nil(t, err, "Query should have succeeded")
+		assert.Equal(t, expected, response.Data.(*SimpleRepoDataResponse).Repository.Name, "Query should have gone to %s", expected)
+	}
+	assert.Equal(t, 3, calls, "The resolver should have been consulted for every request")
+
+	// The target URL reports the endpoint that would be used now
+	endpoints = []string{production.URL}
+	assert.Equal(t, production.URL, client.GetTargetURL())
+}
@@ -0,0 +1,31 @@
+package gqlclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// queryHashes caches the hashes computed by QueryHash(...), keyed by the original query string.
+var queryHashes sync.Map
+
+// QueryHash returns the hex encoded SHA-256 hash of the packed form of a GraphQL query, as used to
+// identify queries for automatic persisted queries (APQ) and query allow-listing. Because the hash
+// is taken after whitespace has been packed, formatting changes do not alter it.
+//
+// Hashes are cached by the original query string so that repeated calls for the same query do not
+// repeat the work. The cache is never emptied and so is intended for the fixed set of queries that
+// an application uses, not for queries built dynamically from user input.
+func QueryHash(queryStr *string) string {
+
+	// Have we seen this one before?
+	if hash, ok := queryHashes.Load(*queryStr); ok {
+		return hash.(string)
+	}
+
+	// No, work it out and remember it
+	sum := sha256.Sum256([]byte(packQuery(queryStr)))
+	hash := hex.EncodeToString(sum[:])
+	queryHashes.Store(*queryStr, hash)
+	return hash
+}
@@ -0,0 +1,32 @@
+package gqlclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for query hashing.
+
+// TestQueryHash confirms that equivalent queries hash identically regardless of formatting.
+func TestQueryHash(t *testing.T) {
+
+	packed := "query FetchRepoInfo($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { name owner { login } } }"
+	hash := QueryHash(&SimpleRepoDataQuery)
+	assert.Equal(t, 64, len(hash), "Hash should be 64 hex digits")
+	assert.Equal(t, hash, QueryHash(&packed), "Differently formatted queries should hash identically")
+
+	// The hash should be the well known SHA-256 of the packed query
+	empty := "  \n\t "
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", QueryHash(&empty))
+
+	// Asking again should give the same, cached, answer
+	assert.Equal(t, hash, QueryHash(&SimpleRepoDataQuery), "Repeated hashing should give the same result")
+	cached, ok := queryHashes.Load(SimpleRepoDataQuery)
+	assert.True(t, ok, "The hash should have been cached")
+	assert.Equal(t, hash, cached)
+
+	// But a different query should hash differently
+	other := "query { viewer { login } }"
+	assert.NotEqual(t, hash, QueryHash(&other), "Different queries should hash differently")
+}
@@ -0,0 +1,71 @@
+package gqlclient
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultSizeBuckets are the upper bounds of the response size buckets used by a
+// HistogramMetricsRecorder unless WithSizeBuckets(...) says otherwise: 1KB, 10KB, 100KB, 1MB and 10MB.
+var defaultSizeBuckets = []int64{1 << 10, 10 << 10, 100 << 10, 1 << 20, 10 << 20}
+
+// HistogramMetricsRecorder is a MetricsRecorder that keeps a histogram of response body sizes in
+// memory, for capacity planning or to spot unexpectedly large payloads. Sizes are counted in
+// buckets defined by their upper bounds, a response falling in the first bucket whose bound is
+// greater than or equal to its size, plus a final overflow bucket for anything larger than the
+// largest bound. Query durations and errors are not recorded. A HistogramMetricsRecorder is safe
+// for concurrent use and must be obtained from NewHistogramMetricsRecorder(...).
+type HistogramMetricsRecorder struct {
+	mutex   sync.Mutex // Guards the counts
+	buckets []int64    // The upper bounds of the buckets, in ascending order
+	counts  []int64    // The number of responses in each bucket, plus the overflow bucket
+}
+
+// HistogramOption is a function that configures a HistogramMetricsRecorder.
+type HistogramOption func(*HistogramMetricsRecorder)
+
+// WithSizeBuckets is a HistogramOption that sets the upper bounds, in bytes, of the response size
+// buckets. The bounds need not be given in order.
+func WithSizeBuckets(buckets []int64) HistogramOption {
+	return func(h *HistogramMetricsRecorder) {
+		h.buckets = append([]int64(nil), buckets...)
+		sort.Slice(h.buckets, func(i, j int) bool { return h.buckets[i] < h.buckets[j] })
+	}
+}
+
+// NewHistogramMetricsRecorder returns an empty HistogramMetricsRecorder, configured by the given
+// options, ready to be passed to WithMetrics(...).
+func NewHistogramMetricsRecorder(opts ...HistogramOption) *HistogramMetricsRecorder {
+	h := &HistogramMetricsRecorder{buckets: defaultSizeBuckets}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.counts = make([]int64, len(h.buckets)+1)
+	return h
+}
+
+// RecordQuery does nothing; only response sizes are recorded.
+func (h *HistogramMetricsRecorder) RecordQuery(operation string, duration time.Duration, err error) {
+}
+
+// RecordResponseSize counts the response in the appropriate bucket.
+func (h *HistogramMetricsRecorder) RecordResponseSize(operation string, sizeBytes int64) {
+	i := sort.Search(len(h.buckets), func(i int) bool { return h.buckets[i] >= sizeBytes })
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.counts[i]++
+}
+
+// Buckets returns the upper bounds of the buckets in ascending order.
+func (h *HistogramMetricsRecorder) Buckets() []int64 {
+	return append([]int64(nil), h.buckets...)
+}
+
+// Counts returns the number of responses counted in each bucket, in the same order as Buckets(),
+// followed by the number that were larger than every bucket.
+func (h *HistogramMetricsRecorder) Counts() []int64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]int64(nil), h.counts...)
+}
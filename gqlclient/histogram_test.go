@@ -0,0 +1,45 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the response size histogram.
+
+// TestHistogramMetricsRecorder confirms that response sizes are counted in the right buckets.
+func TestHistogramMetricsRecorder(t *testing.T) {
+
+	// Serve responses padded out to the size asked for in a header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size, _ := strconv.Atoi(r.Header.Get("X-Size"))
+		body := `{"data":{"pad":""}}`
+		body = body[:len(body)-3] + strings.Repeat("x", size-len(body)) + body[len(body)-3:]
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	// Buckets given out of order should be sorted
+	histogram := NewHistogramMetricsRecorder(WithSizeBuckets([]int64{1000, 100}))
+	assert.Equal(t, []int64{100, 1000}, histogram.Buckets())
+
+	// Five queries with known response sizes
+	for _, size := range []int{50, 100, 101, 1000, 5000} {
+		client := CreateClient(server.URL, nil, WithMetrics(histogram), WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Size", strconv.Itoa(size))
+			return nil
+		}))
+		assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	}
+	assert.Equal(t, []int64{2, 2, 1}, histogram.Counts(), "Sizes should be counted up to and including each bound")
+
+	// The defaults should be there if no buckets are given
+	histogram = NewHistogramMetricsRecorder()
+	assert.Equal(t, defaultSizeBuckets, histogram.Buckets())
+	assert.Equal(t, make([]int64, len(defaultSizeBuckets)+1), histogram.Counts())
+}
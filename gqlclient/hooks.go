@@ -0,0 +1,234 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// QueryTransform is a function that is given each packed query string before it is sent and
+// returns the query that should be sent in its place. Returning an error abandons the query and
+// the error is returned to the caller.
+type QueryTransform func(query string) (string, error)
+
+// WithQueryTransform is a ClientOption that registers a transformation to be applied to every
+// query after it has been packed but before it is marshalled for sending. This allows, for
+// example, a platform team to inject required fields or strip disallowed ones across all callers.
+// If the option is given more than once, the transforms are applied in the order they were
+// registered, each being given the output of the one before.
+func WithQueryTransform(transform QueryTransform) ClientOption {
+	return func(gc *gqlClient) {
+		gc.transforms = append(gc.transforms, transform)
+	}
+}
+
+// RequestHook is a function that is given each HTTP request immediately before it is sent, after
+// the standard headers have been set. Hooks may add headers or otherwise adjust the request;
+// returning an error abandons the request and the error is returned from the query.
+type RequestHook func(req *http.Request) error
+
+// WithRequestHook is a ClientOption that registers a hook to be invoked on every outgoing request.
+// If the option is given more than once, the hooks are called in the order they were registered.
+func WithRequestHook(hook RequestHook) ClientOption {
+	return func(gc *gqlClient) {
+		gc.requestHooks = append(gc.requestHooks, hook)
+	}
+}
+
+// ResponseHook is a function that is given each HTTP request immediately before it would be sent,
+// after the request hooks have been called, and may answer it with a response of its own, e.g. one
+// found in a cache or a stub standing in for the server in an offline test. Returning true supplies
+// the response and the request is never sent; returning false lets the request go ahead as normal.
+type ResponseHook func(req *http.Request) (*http.Response, bool)
+
+// WithResponseHook is a ClientOption that registers a hook that may supply the response to each
+// request in place of the server. A supplied response is treated exactly as if the server had sent
+// it: its status is checked, its body is shown to any AfterResponseHook and then decoded, and it may
+// be retried. Supplying a nil response fails the request with an error. If the option is given more
+// than once, the hooks are called in the order they were registered until one supplies a response.
+func WithResponseHook(hook ResponseHook) ClientOption {
+	return func(gc *gqlClient) {
+		gc.responseHooks = append(gc.responseHooks, hook)
+	}
+}
+
+// substituteResponse readies a response supplied by a ResponseHook to be handled as though it had
+// come from the server.
+func substituteResponse(req *http.Request, resp *http.Response) (*http.Response, bool, error) {
+	if resp == nil {
+		return nil, false, errors.New("response hook supplied a nil response")
+	}
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+	if resp.Request == nil {
+		resp.Request = req
+	}
+	return resp, false, nil
+}
+
+// WithHeaders is a ClientOption that sets the given headers on every outgoing request, e.g. headers
+// required by a proxy or service mesh. The headers are set before any other request hooks are
+// called, so hooks registered after this option can override them.
+func WithHeaders(headers map[string]string) ClientOption {
+
+	// Take our own copy of the headers so that later changes to the caller's map cannot affect us
+	fixed := make(map[string]string, len(headers))
+	for name, value := range headers {
+		fixed[name] = value
+	}
+	return WithRequestHook(func(req *http.Request) error {
+		for name, value := range fixed {
+			req.Header.Set(name, value)
+		}
+		return nil
+	})
+}
+
+// WithContextHeaderMapping is a ClientOption that copies values carried by the context of each query
+// into the headers of its HTTP requests, e.g. to pass on the trace or tenant IDs of the operation
+// that made the query. The mapping is from context key to header name: for each key whose value is
+// present in the request context, the header is set to the value, formatted with fmt.Sprint if it
+// is not already a string. Keys with no value in the context are ignored.
+func WithContextHeaderMapping(mapping map[interface{}]string) ClientOption {
+
+	// Take our own copy of the mapping so that later changes to the caller's map cannot affect us
+	headers := make(map[interface{}]string, len(mapping))
+	for key, header := range mapping {
+		headers[key] = header
+	}
+	return WithRequestHook(func(req *http.Request) error {
+		ctx := req.Context()
+		for key, header := range headers {
+			switch value := ctx.Value(key).(type) {
+			case nil:
+			case string:
+				req.Header.Set(header, value)
+			default:
+				req.Header.Set(header, fmt.Sprint(value))
+			}
+		}
+		return nil
+	})
+}
+
+// AfterResponseHook is a function that is shown every HTTP response received by a client, along
+// with the request that produced it and the fully buffered response body. The body has already
+// been read from the response, so hooks must use the body parameter rather than resp.Body.
+type AfterResponseHook func(req *http.Request, resp *http.Response, body []byte)
+
+// WithAfterResponse is a ClientOption that registers a hook to be invoked after each response body
+// has been read but before it is decoded. Hooks are called for every response, including those
+// with a non-200 status and those that are subsequently retried, making them suitable for
+// cross-cutting concerns such as caching response bodies or asserting invariants. If the option
+// is given more than once, the hooks are called in the order they were registered.
+func WithAfterResponse(hook AfterResponseHook) ClientOption {
+	return func(gc *gqlClient) {
+		gc.afterResponse = append(gc.afterResponse, hook)
+	}
+}
+
+// ResponseValidator is the interface implemented by semantic checks applied to every successful
+// response, for invariants that the caller relies upon beyond the shape of the JSON, e.g. that a
+// repository was found or that its owner is the one that was asked for.
+type ResponseValidator interface {
+	// Validate returns an error if the decoded response breaks the invariant.
+	Validate(resp *QueryResponse) error
+}
+
+// ResponseValidatorFunc is an adapter allowing an ordinary function to be used as a ResponseValidator.
+type ResponseValidatorFunc func(resp *QueryResponse) error
+
+// Validate calls f(resp).
+func (f ResponseValidatorFunc) Validate(resp *QueryResponse) error {
+	return f(resp)
+}
+
+// ValidationError is the error returned from a query when a ResponseValidator rejects the response.
+// The response has been populated as normal by the time the error is returned.
+type ValidationError struct {
+	Err error // The error returned by the validator
+}
+
+// Error describes the validation failure.
+func (e ValidationError) Error() string {
+	return "GraphQL response failed validation: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the validator for the benefit of errors.Is(...) and errors.As(...).
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrNullData is the error returned by the NonNullDataValidator() when a response has no data.
+var ErrNullData = errors.New("GraphQL response data is null")
+
+// NonNullDataValidator returns a ResponseValidator that rejects responses whose data is null, or that
+// were given no data object to be decoded into.
+func NonNullDataValidator() ResponseValidator {
+	return ResponseValidatorFunc(func(resp *QueryResponse) error {
+		if raw, ok := resp.Data.(*json.RawMessage); ok && raw != nil {
+			if len(*raw) == 0 || string(*raw) == "null" {
+				return ErrNullData
+			}
+			return nil
+		}
+		data := reflect.ValueOf(resp.Data)
+		if !data.IsValid() || (data.Kind() == reflect.Ptr && data.IsNil()) {
+			return ErrNullData
+		}
+		return nil
+	})
+}
+
+// WithResponseValidator is a ClientOption that registers a validator to be run over every response
+// that the GraphQL service did not report errors in, once it has been decoded. An error returned by
+// the validator is returned from the query wrapped in a ValidationError, allowing sanity checks to be
+// enforced in one place rather than by every caller. If the option is given more than once, the
+// validators are run in the order they were registered and the first error is returned.
+func WithResponseValidator(v ResponseValidator) ClientOption {
+	return func(gc *gqlClient) {
+		gc.validators = append(gc.validators, v)
+	}
+}
+
+// validate runs the response validators over a decoded response, returning the first error found.
+func (gc *gqlClient) validate(response *QueryResponse) error {
+	if response.HasErrors() {
+		return nil
+	}
+	for _, validator := range gc.validators {
+		if err := validator.Validate(response); err != nil {
+			return ValidationError{Err: err}
+		}
+	}
+	return nil
+}
+
+// WithRequestBodyLogger is a ClientOption that registers a function to be given the raw JSON bytes
+// of every request body, e.g. to meet compliance requirements for audit logs of outgoing API calls.
+// The function is responsible for storing the bytes wherever they need to go and must not modify
+// them. It is called synchronously before each request is sent, including each retry, so the body
+// is always recorded before the network call is made. If the function panics, the request is
+// abandoned and the panic is returned from the query as an error.
+//
+// The multipart bodies of UploadQuery(...) requests are streamed rather than built in memory and
+// so are not given to the logger.
+func WithRequestBodyLogger(logger func(body []byte)) ClientOption {
+	return func(gc *gqlClient) {
+		gc.bodyLogger = logger
+	}
+}
+
+// logRequestBody passes the body to the request body logger, converting any panic into an error.
+func (gc *gqlClient) logRequestBody(body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("request body logger failed, request abandoned: %v", r)
+		}
+	}()
+	gc.bodyLogger(body)
+	return nil
+}
@@ -0,0 +1,337 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the client hook options.
+
+// TestAfterResponseHook confirms that the after response hook is shown the raw body of both
+// successful and failed responses, and that decoding still works after the hook has run.
+func TestAfterResponseHook(t *testing.T) {
+
+	// Serve a good response for the first call and a 500 for the rest
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("something broke"))
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// Capture everything the hook is given
+	var bodies []string
+	var statuses []int
+	client := CreateClient(server.URL, nil, WithAfterResponse(func(req *http.Request, resp *http.Response, body []byte) {
+		assert.Equal(t, server.URL, req.URL.String(), "Hook should be given the originating request")
+		statuses = append(statuses, resp.StatusCode)
+		bodies = append(bodies, string(body))
+	}))
+
+	// The successful query should still be decoded
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "First query should have succeeded")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name, "Response should have been decoded")
+
+	// The failed query should also have been shown to the hook
+	err = client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.NotNil(t, err, "Second query should have failed")
+
+	assert.Equal(t, []int{200, 500}, statuses, "Hook should have seen both responses")
+	assert.Contains(t, bodies[0], `"name":"gogql"`, "Hook should have captured the good body")
+	assert.Equal(t, "something broke", bodies[1], "Hook should have captured the error body")
+}
+
+// TestResponseHook confirms that a response supplied by a hook is decoded like any other, without
+// the request ever reaching the server, and that requests the hook passes over are sent as normal.
+func TestResponseHook(t *testing.T) {
+
+	// Count the requests that actually reach the server
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"data":{"repository":{"name":"live","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// Answer every request that carries a particular header with a canned response
+	var seen []int
+	client := CreateClient(server.URL, nil,
+		WithResponseHook(func(req *http.Request) (*http.Response, bool) {
+			if req.Header.Get("X-Offline") == "" {
+				return nil, false
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(`{"data":{"repository":{"name":"canned","owner":{"login":"mikebway"}}}}`)),
+			}, true
+		}),
+		WithAfterResponse(func(req *http.Request, resp *http.Response, body []byte) {
+			seen = append(seen, resp.StatusCode)
+		}))
+
+	// The canned response should be decoded without troubling the server
+	offline, _ := Clone(client, WithHeaders(map[string]string{"X-Offline": "yes"}))
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, offline.Query(&SimpleRepoDataQuery, nil, &response), "The canned response should have been accepted")
+	assert.Equal(t, "canned", response.Data.(*SimpleRepoDataResponse).Repository.Name, "The canned response should have been decoded")
+	assert.Equal(t, 0, calls, "No request should have reached the server")
+	assert.Equal(t, []int{200}, seen, "The canned response should have been shown to the after response hook")
+
+	// Without the header, the request goes to the server as usual
+	response = QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response))
+	assert.Equal(t, "live", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	assert.Equal(t, 1, calls, "The request should have reached the server")
+
+	// A canned failure is a failure, and a nil response is an error
+	failing := CreateClient(server.URL, nil, WithResponseHook(func(req *http.Request) (*http.Response, bool) {
+		return &http.Response{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, true
+	}))
+	var statusErr StatusError
+	assert.True(t, errors.As(failing.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), &statusErr))
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	broken := CreateClient(server.URL, nil, WithResponseHook(func(req *http.Request) (*http.Response, bool) {
+		return nil, true
+	}))
+	assert.NotNil(t, broken.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), "A nil response should have been refused")
+	assert.Equal(t, 1, calls, "None of those requests should have reached the server")
+}
+
+// TestWithHeaders confirms that fixed headers can be overridden by later request hooks.
+func TestWithHeaders(t *testing.T) {
+
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithHeaders(map[string]string{"X-Team": "platform", "X-Env": "prod"}),
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Env", "staging")
+			return nil
+		}))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "platform", headers.Get("X-Team"))
+	assert.Equal(t, "staging", headers.Get("X-Env"), "The later hook should have had the last word")
+}
+
+// contextKey is the type of the context keys used in these tests
+type contextKey string
+
+// TestContextHeaderMapping confirms that values found in the query context are copied into the
+// mapped request headers, and that keys missing from the context are left out.
+func TestContextHeaderMapping(t *testing.T) {
+
+	// Capture the headers of each request
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithContextHeaderMapping(map[interface{}]string{
+		contextKey("trace"):  "X-Trace-ID",
+		contextKey("tenant"): "X-Tenant-ID",
+	}))
+
+	// Place a trace ID in the context, but no tenant ID
+	ctx := context.WithValue(context.Background(), contextKey("trace"), "abc123")
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should have succeeded")
+	assert.Equal(t, "abc123", headers.Get("X-Trace-ID"), "Trace ID should have been copied into its header")
+	assert.Empty(t, headers.Values("X-Tenant-ID"), "Missing tenant ID should not have produced a header")
+
+	// Values that are not strings are formatted
+	ctx = context.WithValue(ctx, contextKey("tenant"), 42)
+	err = client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should have succeeded")
+	assert.Equal(t, "42", headers.Get("X-Tenant-ID"), "Tenant ID should have been formatted into its header")
+}
+
+// TestQueryTransform confirms that transforms are applied, in order, to the packed query and that
+// a transform error abandons the query.
+func TestQueryTransform(t *testing.T) {
+
+	// Record the queries received
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		json.NewDecoder(r.Body).Decode(&q)
+		received = append(received, q.Query)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// Append a field to the repository selection, then show that the second transform sees the result
+	addField := func(query string) (string, error) {
+		return strings.Replace(query, "repository(owner: $owner, name: $name) {", "repository(owner: $owner, name: $name) { url", 1), nil
+	}
+	var seenBySecond string
+	second := func(query string) (string, error) {
+		seenBySecond = query
+		return query, nil
+	}
+	client := CreateClient(server.URL, nil, WithQueryTransform(addField), WithQueryTransform(second))
+
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Transformed query should not have failed")
+	expected := "query FetchRepoInfo($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { url name owner { login } } }"
+	assert.Equal(t, expected, received[0], "The transformed query should have been sent")
+	assert.Equal(t, expected, seenBySecond, "The second transform should have been given the output of the first")
+
+	// A failing transform should stop the query before it is sent
+	client = CreateClient(server.URL, nil, WithQueryTransform(func(query string) (string, error) {
+		return "", errors.New("query not permitted")
+	}))
+	err = client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.EqualError(t, err, "query not permitted")
+	assert.Equal(t, 1, len(received), "The rejected query should not have been sent")
+}
+
+// TestRequestBodyLogger confirms that the exact body of each request is logged before it is sent
+// and that a panicking logger abandons the request.
+func TestRequestBodyLogger(t *testing.T) {
+
+	// Count the requests that reach the server
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// Log three queries
+	var bodies [][]byte
+	client := CreateClient(server.URL, nil, WithRequestBodyLogger(func(body []byte) {
+		assert.Equal(t, len(bodies), requests, "Body should be logged before the request is sent")
+		bodies = append(bodies, body)
+	}))
+	queries := []string{
+		"query A {\n\tviewer {\n\t\tlogin\n\t}\n}",
+		"query B { rateLimit { cost } }",
+		"{\n  repository(owner: \"mikebway\", name: \"gogql\") { name }\n}",
+	}
+	for _, q := range queries {
+		assert.Nil(t, client.Query(&q, nil, &QueryResponse{}), "Logged query should not have failed")
+	}
+	assert.Equal(t, 3, len(bodies), "Every body should have been logged")
+	assert.Contains(t, string(bodies[0]), `"query":"query A { viewer { login } }"`)
+	assert.Contains(t, string(bodies[1]), `"query":"query B { rateLimit { cost } }"`)
+	assert.Contains(t, string(bodies[2]), `"query":"{ repository(owner: \"mikebway\", name: \"gogql\") { name } }"`)
+
+	// A logger that panics should stop the request being sent
+	client = CreateClient(server.URL, nil, WithRequestBodyLogger(func(body []byte) {
+		panic("audit log unavailable")
+	}))
+	err := client.Query(&queries[0], nil, &QueryResponse{})
+	assert.NotNil(t, err, "A panicking logger should have failed the query")
+	assert.Contains(t, err.Error(), "audit log unavailable")
+	assert.Equal(t, 3, requests, "The request should not have been sent")
+}
+
+// TestResponseValidator confirms that a validator can reject a decoded response, failing the query.
+func TestResponseValidator(t *testing.T) {
+
+	// Serve a repository that does not exist
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("X-Repo"), "missing") {
+			w.Write([]byte(`{"data":{"repository":null}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// A validator that insists on a repository
+	errNoRepo := errors.New("repository must not be nil")
+	var validated []interface{}
+	validator := ResponseValidatorFunc(func(resp *QueryResponse) error {
+		validated = append(validated, resp.Data)
+		if (*resp.Data.(*map[string]interface{}))["repository"] == nil {
+			return errNoRepo
+		}
+		return nil
+	})
+
+	// A good response passes
+	client := CreateClient(server.URL, nil, WithResponseValidator(validator))
+	response := QueryResponse{Data: new(map[string]interface{})}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response), "A valid response should not have failed")
+	assert.Equal(t, []interface{}{response.Data}, validated, "The validator should have been given the decoded data")
+
+	// A bad one does not
+	client = CreateClient(server.URL, nil, WithResponseValidator(validator), WithRequestHook(func(req *http.Request) error {
+		req.Header.Set("X-Repo", "missing")
+		return nil
+	}))
+	response = QueryResponse{Data: new(map[string]interface{})}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Equal(t, ValidationError{Err: errNoRepo}, err, "The validator's error should have been returned")
+	assert.True(t, errors.Is(err, errNoRepo), "The validator's error should be unwrappable")
+	assert.Equal(t, "GraphQL response failed validation: repository must not be nil", err.Error())
+	assert.Contains(t, *response.Data.(*map[string]interface{}), "repository", "The response should still have been populated")
+}
+
+// TestResponseValidatorSkipsErrors confirms that validators are not run over responses that the
+// GraphQL service has reported errors in, leaving the errors for the caller to deal with.
+func TestResponseValidatorSkipsErrors(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"Could not resolve to a Repository"}]}`))
+	}))
+	defer server.Close()
+
+	called := false
+	client := CreateClient(server.URL, nil, WithResponseValidator(ResponseValidatorFunc(func(resp *QueryResponse) error {
+		called = true
+		return errors.New("should not happen")
+	})))
+	response := QueryResponse{}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response))
+	assert.False(t, called, "The validator should not have been called")
+	assert.True(t, response.HasErrors())
+}
+
+// TestNonNullDataValidator confirms that null data is rejected, whatever the data object.
+func TestNonNullDataValidator(t *testing.T) {
+
+	body := `{"data":null}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil, WithResponseValidator(NonNullDataValidator()))
+
+	// Null data is rejected whether decoded into a struct, raw JSON or nothing at all
+	for _, data := range []interface{}{new(SimpleRepoDataResponse), new(json.RawMessage), nil} {
+		err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: data})
+		assert.Equal(t, ValidationError{Err: ErrNullData}, err, "Null data should have been rejected for %T", data)
+	}
+
+	// Real data is not
+	body = `{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`
+	for _, data := range []interface{}{new(SimpleRepoDataResponse), new(json.RawMessage), nil} {
+		err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: data})
+		assert.Nil(t, err, "Real data should have been accepted for %T", data)
+	}
+}
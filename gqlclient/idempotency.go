@@ -0,0 +1,43 @@
+package gqlclient
+
+import "context"
+
+// IdempotencyKeyHeader is the HTTP header in which idempotency keys are sent.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyContextKey is the type of the context key under which idempotency keys are stored.
+type idempotencyContextKey struct{}
+
+// WithIdempotencyKey is a ClientOption that sends every mutation with an IdempotencyKeyHeader holding
+// a random key, generated afresh for each call but kept the same for every retry of that call, so
+// that a server which has already applied a mutation can recognise a retry of it, e.g. one sent
+// because the response to the first attempt was lost, and not apply it twice. Queries are not given
+// keys, having no side effects to repeat.
+//
+// This only helps if the GraphQL server supports idempotency keys, remembering the keys it has seen
+// and the responses it gave; servers that do not simply ignore the header. A key of the caller's
+// choosing can be given for any operation, with or without this option, using
+// ContextWithIdempotencyKey(...).
+func WithIdempotencyKey() ClientOption {
+	return func(gc *gqlClient) {
+		gc.idempotency = true
+	}
+}
+
+// ContextWithIdempotencyKey returns a copy of the given context carrying an idempotency key, which is
+// sent in the IdempotencyKeyHeader of every request made with the context, in place of any key that
+// WithIdempotencyKey() would generate. Callers who retry operations themselves, across process
+// restarts say, can use this to keep the key the same across their own retries too.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyContextKey{}, key)
+}
+
+// idempotencyContext returns the context with which the packed query should be delivered: one with a
+// freshly generated idempotency key if the client generates them and the query is a mutation that
+// does not already have one, or the given context otherwise.
+func (gc *gqlClient) idempotencyContext(ctx context.Context, packed string) context.Context {
+	if !gc.idempotency || ctx.Value(idempotencyContextKey{}) != nil || operationType(&packed) != "mutation" {
+		return ctx
+	}
+	return ContextWithIdempotencyKey(ctx, NewRequestID())
+}
@@ -0,0 +1,54 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for idempotency keys.
+
+// TestIdempotencyKey confirms that a mutation keeps the same key across its retries, that each call
+// gets a new key, and that queries get none.
+func TestIdempotencyKey(t *testing.T) {
+
+	// Fail every other request so that each call is tried twice
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys)%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil, WithIdempotencyKey(), WithRetry(1), WithBackoff(FixedBackoff{Delay: time.Millisecond}))
+
+	// Two calls of the same mutation, each retried once
+	mutation := "mutation AddStar($id: ID!) { addStar(input: {starrableId: $id}) { clientMutationId } }"
+	queryParms := map[string]interface{}{"id": "R_1"}
+	assert.Nil(t, client.Query(&mutation, &queryParms, &QueryResponse{}))
+	assert.Nil(t, client.Query(&mutation, &queryParms, &QueryResponse{}))
+	assert.Equal(t, 4, len(keys), "Each call should have been retried once")
+	assert.NotEmpty(t, keys[0], "The mutation should have been given a key")
+	assert.Equal(t, keys[0], keys[1], "The retry should have reused the key")
+	assert.Equal(t, keys[2], keys[3], "The retry should have reused the key")
+	assert.NotEqual(t, keys[0], keys[2], "Each call should have had a key of its own")
+
+	// Queries are left alone
+	keys = nil
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, []string{"", ""}, keys, "Queries should not have been given keys")
+
+	// A key given by the caller wins, even for a client that would not otherwise send one
+	keys = nil
+	client = CreateClient(server.URL, nil, WithRetry(1), WithBackoff(FixedBackoff{Delay: time.Millisecond}))
+	ctx := ContextWithIdempotencyKey(context.Background(), "order-1234")
+	assert.Nil(t, client.QueryContext(ctx, &mutation, &queryParms, &QueryResponse{}))
+	assert.Equal(t, []string{"order-1234", "order-1234"}, keys, "The caller's key should have been used")
+}
@@ -0,0 +1,74 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// LoadQueriesFromFS loads every GraphQL document in the file system whose path matches the glob
+// pattern, e.g. "queries/*.graphql", returning a map from each file's name, without its directory or
+// extension, to its packed query text. It is intended for queries kept in files of their own and
+// embedded in the program with //go:embed:
+//
+// 		//go:embed queries/*.graphql
+// 		var queryFiles embed.FS
+//
+// 		queries, err := gqlclient.LoadQueriesFromFS(queryFiles, "queries/*.graphql")
+//
+// Comments are removed as the documents are packed. Files that cannot be read, are not well formed
+// GraphQL, or share a name with another file are left out of the map and reported together in an
+// error joined with errors.Join(...), each prefixed with the path of the file. The queries that did
+// load are still returned. Only a malformed pattern prevents anything being returned.
+func LoadQueriesFromFS(fsys fs.FS, pattern string) (map[string]string, error) {
+
+	// Find the files
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load each in turn, carrying on past failures to report them all at once
+	queries := make(map[string]string, len(paths))
+	loadedFrom := make(map[string]string, len(paths))
+	var errs []error
+	for _, p := range paths {
+		name := strings.TrimSuffix(path.Base(p), path.Ext(p))
+		if other, ok := loadedFrom[name]; ok {
+			errs = append(errs, fmt.Errorf("%s: query name %q is already taken by %s", p, name, other))
+			continue
+		}
+		queryStr, err := loadQueryFile(fsys, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			continue
+		}
+		queries[name] = queryStr
+		loadedFrom[name] = p
+	}
+	return queries, errors.Join(errs...)
+}
+
+// loadQueryFile reads a GraphQL document from the file system, checks that it is well formed, and
+// returns it packed, without its comments.
+func loadQueryFile(fsys fs.FS, p string) (string, error) {
+
+	content, err := fs.ReadFile(fsys, p)
+	if err != nil {
+		return "", err
+	}
+	packed, err := packDocument(string(content))
+	if err != nil {
+		return "", err
+	}
+	tokens, err := tokenize(packed)
+	if err != nil {
+		return "", err
+	}
+	if _, err := definitions(tokens); err != nil {
+		return "", err
+	}
+	return packed, nil
+}
@@ -0,0 +1,50 @@
+package gqlclient
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for loading queries from a file system.
+
+// TestLoadQueriesFromFS confirms that well formed documents are loaded and packed, without their
+// comments, and that broken ones are reported without preventing the others from loading.
+func TestLoadQueriesFromFS(t *testing.T) {
+
+	fsys := fstest.MapFS{
+		"queries/repo.graphql": {Data: []byte(`# Fetch a repository by owner and name
+query FetchRepoInfo($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) {
+		name # The short name
+		description(format: "# not a comment")
+	}
+}
+`)},
+		"queries/viewer.graphql": {Data: []byte("{\n  viewer {\n    login\n  }\n}\n")},
+		"queries/broken.graphql": {Data: []byte("query Broken { viewer { login }\n")},
+		"queries/README.md":      {Data: []byte("Not a query")},
+	}
+
+	queries, err := LoadQueriesFromFS(fsys, "queries/*.graphql")
+	assert.Equal(t, map[string]string{
+		"repo":   `query FetchRepoInfo($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { name description(format: "# not a comment") } }`,
+		"viewer": "{ viewer { login } }",
+	}, queries, "The good queries should have been loaded")
+	assert.NotNil(t, err, "The broken query should have been reported")
+	assert.True(t, strings.HasPrefix(err.Error(), "queries/broken.graphql: "), "The error should name the file: %v", err)
+	assert.Equal(t, 1, len(err.(interface{ Unwrap() []error }).Unwrap()), "There should have been just the one error")
+
+	// Names must be unique
+	fsys["more/viewer.graphql"] = &fstest.MapFile{Data: []byte("{ viewer { name } }")}
+	queries, err = LoadQueriesFromFS(fsys, "*/viewer.graphql")
+	assert.Equal(t, map[string]string{"viewer": "{ viewer { name } }"}, queries)
+	assert.EqualError(t, err, `queries/viewer.graphql: query name "viewer" is already taken by more/viewer.graphql`)
+
+	// A bad pattern gets nothing
+	queries, err = LoadQueriesFromFS(fsys, "queries/[")
+	assert.Nil(t, queries)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,50 @@
+package gqlclient
+
+import "strings"
+
+// maskedValue is the value that MaskSensitiveVariables(...) puts in place of sensitive ones.
+const maskedValue = "***"
+
+// MaskSensitiveVariables returns a copy of a map of query variables that is safe to log, with the
+// value of every entry whose key matches one of the sensitive keys, ignoring case, replaced by "***".
+// Nested maps, including those within lists, are copied and masked in the same way, so that the
+// original variables are left untouched. For example:
+//
+// 		logger.Log(gqlclient.LevelDebug, "sending query", "variables",
+// 			gqlclient.MaskSensitiveVariables(queryParms, []string{"password", "token"}))
+//
+// Only maps of type map[string]interface{} and lists of type []interface{} are walked; other values,
+// including structs, are copied as they are.
+func MaskSensitiveVariables(vars map[string]interface{}, sensitiveKeys []string) map[string]interface{} {
+	if vars == nil {
+		return nil
+	}
+	sensitive := make(map[string]bool, len(sensitiveKeys))
+	for _, key := range sensitiveKeys {
+		sensitive[strings.ToLower(key)] = true
+	}
+	return maskValue(vars, sensitive).(map[string]interface{})
+}
+
+// maskValue returns a copy of the given variable value with the values of any sensitive keys masked.
+func maskValue(value interface{}, sensitive map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if sensitive[strings.ToLower(k)] {
+				result[k] = maskedValue
+			} else {
+				result[k] = maskValue(item, sensitive)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = maskValue(item, sensitive)
+		}
+		return result
+	}
+	return value
+}
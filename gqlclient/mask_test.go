@@ -0,0 +1,50 @@
+package gqlclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for masking sensitive query variables.
+
+// TestMaskSensitiveVariables confirms that sensitive values are masked at any depth, whatever the
+// case of their keys, without the original variables being changed.
+func TestMaskSensitiveVariables(t *testing.T) {
+
+	vars := map[string]interface{}{
+		"owner":    "mikebway",
+		"Password": "hunter2",
+		"input": map[string]interface{}{
+			"name":  "gogql",
+			"token": "ghp_secret",
+			"credentials": []interface{}{
+				map[string]interface{}{"APIKEY": "abc123", "label": "primary"},
+			},
+		},
+		"count": 5,
+	}
+
+	masked := MaskSensitiveVariables(vars, []string{"password", "token", "apiKey"})
+	assert.Equal(t, map[string]interface{}{
+		"owner":    "mikebway",
+		"Password": "***",
+		"input": map[string]interface{}{
+			"name":  "gogql",
+			"token": "***",
+			"credentials": []interface{}{
+				map[string]interface{}{"APIKEY": "***", "label": "primary"},
+			},
+		},
+		"count": 5,
+	}, masked)
+
+	// The original should not have been touched
+	assert.Equal(t, "hunter2", vars["Password"])
+	input := vars["input"].(map[string]interface{})
+	assert.Equal(t, "ghp_secret", input["token"])
+	assert.Equal(t, "abc123", input["credentials"].([]interface{})[0].(map[string]interface{})["APIKEY"])
+
+	// Nothing in, nothing out
+	assert.Nil(t, MaskSensitiveVariables(nil, []string{"password"}))
+}
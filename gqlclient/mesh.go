@@ -0,0 +1,47 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// b3ContextKey is the type of the context key under which ContextWithB3Headers(...) stores headers.
+type b3ContextKey struct{}
+
+// ContextWithB3Headers returns a copy of the given context carrying the Zipkin B3 trace propagation
+// headers found among the given headers, i.e. those named X-B3-*, along with the single b3 header.
+// Typically the headers are those of an incoming request, so that queries made while handling it
+// are stitched into the same trace by clients created with NewClientWithServiceMesh(...). Other
+// headers are ignored.
+func ContextWithB3Headers(ctx context.Context, headers http.Header) context.Context {
+	b3 := http.Header{}
+	for name, values := range headers {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-b3-") || lower == "b3" {
+			b3[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	return context.WithValue(ctx, b3ContextKey{}, b3)
+}
+
+// NewClientWithServiceMesh returns a GqlClient for use within a service mesh such as Istio or
+// Linkerd. It is equivalent to CreateClient(...) with WithHeaders(meshHeaders) applied, so that the
+// headers the mesh requires are sent with every request, and with a request hook that propagates the
+// B3 trace headers placed in the query context by ContextWithB3Headers(...). Any ClientOption values
+// are applied after these, as for CreateClient(...).
+func NewClientWithServiceMesh(targetURL string, authorization *string, meshHeaders map[string]string, opts ...ClientOption) GqlClient {
+	opts = append([]ClientOption{WithHeaders(meshHeaders), WithRequestHook(propagateB3)}, opts...)
+	return CreateClient(targetURL, authorization, opts...)
+}
+
+// propagateB3 is a RequestHook that copies any B3 headers carried by the request context into the
+// request.
+func propagateB3(req *http.Request) error {
+	if b3, ok := req.Context().Value(b3ContextKey{}).(http.Header); ok {
+		for name, values := range b3 {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+	return nil
+}
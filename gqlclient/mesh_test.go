@@ -0,0 +1,51 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for service mesh clients.
+
+// TestNewClientWithServiceMesh confirms that the mesh headers are sent with every request and that
+// B3 headers in the query context are propagated, while other headers of the context are not.
+func TestNewClientWithServiceMesh(t *testing.T) {
+
+	// Capture the headers of each request
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	meshHeaders := map[string]string{"x-forwarded-client-cert": "By=spiffe://cluster.local/ns/gogql", "baggage": "tenant=acme"}
+	client := NewClientWithServiceMesh(server.URL, nil, meshHeaders)
+	meshHeaders["baggage"] = "tenant=changed"
+
+	// Without B3 headers in the context, only the mesh headers are sent
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "By=spiffe://cluster.local/ns/gogql", headers.Get("X-Forwarded-Client-Cert"))
+	assert.Equal(t, "tenant=acme", headers.Get("Baggage"), "Later changes to the caller's map should not matter")
+	assert.Empty(t, headers.Get("X-B3-Traceid"))
+
+	// With them, they are propagated
+	incoming := http.Header{}
+	incoming.Set("X-B3-TraceId", "463ac35c9f6413ad48485a3953bb6124")
+	incoming.Set("X-B3-SpanId", "a2fb4a1d1a96d312")
+	incoming.Set("X-B3-Sampled", "1")
+	incoming.Set("b3", "463ac35c9f6413ad48485a3953bb6124-a2fb4a1d1a96d312-1")
+	incoming.Set("Cookie", "session=secret")
+	ctx := ContextWithB3Headers(context.Background(), incoming)
+	assert.Nil(t, client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "463ac35c9f6413ad48485a3953bb6124", headers.Get("X-B3-TraceId"))
+	assert.Equal(t, "a2fb4a1d1a96d312", headers.Get("X-B3-SpanId"))
+	assert.Equal(t, "1", headers.Get("X-B3-Sampled"))
+	assert.Equal(t, "463ac35c9f6413ad48485a3953bb6124-a2fb4a1d1a96d312-1", headers.Get("B3"))
+	assert.Empty(t, headers.Get("Cookie"), "Headers other than B3 should not have been propagated")
+	assert.Equal(t, "tenant=acme", headers.Get("Baggage"), "The mesh headers should still have been sent")
+}
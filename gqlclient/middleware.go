@@ -0,0 +1,48 @@
+package gqlclient
+
+import (
+	"context"
+	"time"
+)
+
+// QueryFunc is the signature of GqlClient.QueryContext(...), the function that middleware wraps.
+type QueryFunc func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error
+
+// Middleware wraps a QueryFunc with additional behavior, returning a QueryFunc that will typically
+// do some work of its own before and/or after calling the next function in the chain.
+type Middleware func(next QueryFunc) QueryFunc
+
+// WithMiddleware is a ClientOption that wraps every query made by the client in the given middleware.
+// The first middleware given is the outermost, i.e. it is called first and returns last. If the
+// option is given more than once, middleware from earlier options is outside that of later ones.
+func WithMiddleware(middleware ...Middleware) ClientOption {
+	return func(gc *gqlClient) {
+		gc.middleware = append(gc.middleware, middleware...)
+	}
+}
+
+// TimingExtension is the QueryResponse.Extensions key under which ResponseTimingMiddleware()
+// records the duration of each query.
+const TimingExtension = "x-client-timing-ms"
+
+// ResponseTimingMiddleware returns Middleware that measures the time taken by the rest of the
+// middleware chain and the query itself, recording the duration in milliseconds as a float64 in
+// the response extensions under the TimingExtension key. The time is recorded whether or not the
+// query succeeds.
+//
+// What is measured depends on where the middleware sits in the chain: placed before (outside)
+// a caching middleware, it records the total latency including cache lookups; placed after
+// (inside) it, only cache misses reach it and so only the cost of real queries is recorded.
+func ResponseTimingMiddleware() Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			start := time.Now()
+			err := next(ctx, queryStr, queryParms, response)
+			if response.Extensions == nil {
+				response.Extensions = make(map[string]interface{})
+			}
+			response.Extensions[TimingExtension] = float64(time.Since(start)) / float64(time.Millisecond)
+			return err
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the middleware support.
+
+// TestMiddlewareOrder confirms that middleware is called outermost first.
+func TestMiddlewareOrder(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// Each middleware records when it is entered and left
+	var calls []string
+	tracer := func(name string) Middleware {
+		return func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+				calls = append(calls, "enter "+name)
+				err := next(ctx, queryStr, queryParms, response)
+				calls = append(calls, "leave "+name)
+				return err
+			}
+		}
+	}
+
+	client := CreateClient(server.URL, nil, WithMiddleware(tracer("a"), tracer("b")), WithMiddleware(tracer("c")))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query should not have failed")
+	assert.Equal(t, []string{"enter a", "enter b", "enter c", "leave c", "leave b", "leave a"}, calls)
+}
+
+// TestResponseTimingMiddleware confirms that the query duration is recorded in the extensions.
+func TestResponseTimingMiddleware(t *testing.T) {
+
+	// Take a little while to respond, and include an extension of our own
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"data":{},"extensions":{"server":"fake"}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithMiddleware(ResponseTimingMiddleware()))
+	response := QueryResponse{}
+	err := client.Query(&SimpleRepoDataQuery, nil, &response)
+	assert.Nil(t, err, "Query should not have failed")
+
+	timing, ok := response.Extensions[TimingExtension].(float64)
+	assert.True(t, ok, "Timing extension should be a number")
+	assert.True(t, timing >= 50, "Timing %v should be at least 50 milliseconds", timing)
+	assert.Equal(t, "fake", response.Extensions["server"], "Server extensions should be preserved")
+}
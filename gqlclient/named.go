@@ -0,0 +1,127 @@
+package gqlclient
+
+import (
+	"context"
+	"io/fs"
+)
+
+// ErrQueryNotFound is the error returned when a query is asked for by a name, or ID, that is unknown:
+// by NamedQuery(...) if the QueryStore has no query under the name, by QueryByID(...) if the
+// PersistenceStore has none, and by QueryPrepared(...) if none has been prepared. For the latter
+// two, Err is ErrQueryNotRegistered or ErrQueryNotPrepared respectively, so that errors.Is(...) can
+// tell where the query was looked for.
+type ErrQueryNotFound struct {
+	Name string // The name or ID that was looked up
+	Err  error  // The more particular error, if any, that the error unwraps to
+}
+
+// Error names the query that could not be found.
+func (e ErrQueryNotFound) Error() string {
+	if e.Err != nil {
+		return e.Err.Error() + ": " + e.Name
+	}
+	return "no query found with name " + e.Name
+}
+
+// Unwrap returns the more particular error, if any, for the benefit of errors.Is(...).
+func (e ErrQueryNotFound) Unwrap() error {
+	return e.Err
+}
+
+// QueryStore is a read-only source of query strings, keyed by a logical name, from which
+// NamedQuery(...) obtains the queries it sends. It allows the queries of a large code base to be
+// kept in one place and referred to by name wherever they are used. Unlike a PersistenceStore, which
+// is given to a client once with WithQueryPersistenceStore(...) and to which queries are registered
+// as well as looked up, a QueryStore is given with each call and nothing is ever added to it.
+type QueryStore interface {
+	// LookupQuery returns the query kept under the given name, and whether there was one.
+	LookupQuery(name string) (string, bool)
+}
+
+// QueryStoreFunc is an adapter allowing an ordinary function, such as the GetQuery method of a
+// PersistenceStore, to be used as a QueryStore.
+type QueryStoreFunc func(name string) (string, bool)
+
+// LookupQuery calls f(name).
+func (f QueryStoreFunc) LookupQuery(name string) (string, bool) {
+	return f(name)
+}
+
+// NamedQuery looks up the query kept under the given name in the store and sends it as
+// QueryContext(...) would. ErrQueryNotFound is returned if the name is unknown to the store.
+func (gc *gqlClient) NamedQuery(ctx context.Context, name string, store QueryStore, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return gc.sendStoredQuery(ctx, store, name, nil, queryParms, response)
+}
+
+// sendStoredQuery looks up the query kept under the given name in the store and sends it just as
+// QueryContext(...) would, middleware, transforms and all. If the store has no such query, an
+// ErrQueryNotFound wrapping the given error is returned instead. If the store is the client's cache
+// of prepared queries, the query is already packed and its operations known, and prepareQuery(...)
+// and encodeQuery(...) are told so, that they need not do the work again.
+func (gc *gqlClient) sendStoredQuery(ctx context.Context, store QueryStore, name string, notFound error, queryParms *map[string]interface{}, response *QueryResponse) error {
+
+	// Find the query, if we can
+	var queryStr string
+	var ok bool
+	if prepared, isPrepared := store.(*preparedQueries); isPrepared {
+		var query *preparedQuery
+		if query, ok = prepared.lookupPrepared(name); ok {
+			queryStr = query.packed
+			ctx = context.WithValue(ctx, preparedContextKey{}, query)
+		}
+	} else {
+		queryStr, ok = store.LookupQuery(name)
+	}
+	if !ok {
+		return ErrQueryNotFound{Name: name, Err: notFound}
+	}
+
+	// And send it like any other
+	return gc.QueryContext(ctx, &queryStr, queryParms, response)
+}
+
+// mapQueryStore is the QueryStore returned by MapQueryStore(...).
+type mapQueryStore map[string]string
+
+// MapQueryStore returns a QueryStore holding the queries of the given map, keyed by name. The map is
+// copied, so later changes to it do not affect the store, which is safe for concurrent use.
+func MapQueryStore(m map[string]string) QueryStore {
+	store := make(mapQueryStore, len(m))
+	for name, queryStr := range m {
+		store[name] = queryStr
+	}
+	return store
+}
+
+// LookupQuery returns the query kept under the given name, and whether there was one.
+func (s mapQueryStore) LookupQuery(name string) (string, bool) {
+	queryStr, ok := s[name]
+	return queryStr, ok
+}
+
+// fsQueryStore is the QueryStore returned by FSQueryStore(...).
+type fsQueryStore struct {
+	fsys fs.FS // The file system holding the query files
+}
+
+// FSQueryStore returns a QueryStore that reads each query, when it is looked up, from the file in the
+// root of the file system named after it with a .graphql or .gql extension, e.g. the query named
+// "FetchRepo" from FetchRepo.graphql. Use fs.Sub(...) to serve queries from a subdirectory. Files are
+// checked and packed as by LoadQueriesFromFS(...); a file that cannot be read or is not well formed
+// GraphQL is treated as though it were not there.
+func FSQueryStore(fsys fs.FS) QueryStore {
+	return fsQueryStore{fsys: fsys}
+}
+
+// LookupQuery returns the query kept under the given name, and whether there was one.
+func (s fsQueryStore) LookupQuery(name string) (string, bool) {
+	if !fs.ValidPath(name) {
+		return "", false
+	}
+	for _, ext := range []string{".graphql", ".gql"} {
+		if queryStr, err := loadQueryFile(s.fsys, name+ext); err == nil {
+			return queryStr, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,102 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for queries sent by name from a QueryStore.
+
+// TestNamedQuery confirms that a query is looked up by name and sent, and that an unknown name is
+// reported without anything being sent.
+func TestNamedQuery(t *testing.T) {
+
+	// Record the body of each request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	queries := map[string]string{
+		"RepoInfo": SimpleRepoDataQuery,
+		"Viewer":   "{ viewer { login } }",
+	}
+	store := MapQueryStore(queries)
+	delete(queries, "Viewer")
+	client := CreateClient(server.URL, nil)
+
+	queryParms := map[string]interface{}{"owner": "mikebway", "name": "gogql"}
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.NamedQuery(context.Background(), "RepoInfo", store, &queryParms, &response)
+	assert.Nil(t, err, "Named query should have succeeded")
+	assert.Contains(t, body, `"query":"`+packQuery(&SimpleRepoDataQuery)+`"`, "The named query should have been sent")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+
+	_, ok := store.LookupQuery("Viewer")
+	assert.True(t, ok, "The store should not have been affected by changes to the map")
+
+	// An unknown name goes nowhere
+	body = ""
+	err = client.NamedQuery(context.Background(), "NoSuchQuery", store, nil, &QueryResponse{})
+	var notFound ErrQueryNotFound
+	assert.True(t, errors.As(err, &notFound), "An unknown name should have been reported")
+	assert.Equal(t, "NoSuchQuery", notFound.Name)
+	assert.EqualError(t, err, "no query found with name NoSuchQuery")
+	assert.Empty(t, body, "Nothing should have been sent")
+}
+
+// TestFSQueryStore confirms that queries are read from files named after them, and that missing,
+// malformed and out of reach files are not found.
+func TestFSQueryStore(t *testing.T) {
+
+	store := FSQueryStore(fstest.MapFS{
+		"RepoInfo.graphql": &fstest.MapFile{Data: []byte("# The repository\nquery RepoInfo {\n\trepository(owner: \"mikebway\", name: \"gogql\") { name }\n}\n")},
+		"Viewer.gql":       &fstest.MapFile{Data: []byte("{ viewer { login } }")},
+		"Broken.graphql":   &fstest.MapFile{Data: []byte("query Broken { viewer {")},
+	})
+
+	queryStr, ok := store.LookupQuery("RepoInfo")
+	assert.True(t, ok, "The .graphql file should have been found")
+	assert.Equal(t, `query RepoInfo { repository(owner: "mikebway", name: "gogql") { name } }`, queryStr, "The query should have been packed")
+	queryStr, ok = store.LookupQuery("Viewer")
+	assert.True(t, ok, "The .gql file should have been found")
+	assert.Equal(t, "{ viewer { login } }", queryStr)
+
+	for _, name := range []string{"Missing", "Broken", "../RepoInfo", ""} {
+		_, ok := store.LookupQuery(name)
+		assert.False(t, ok, "Query %q should not have been found", name)
+	}
+}
+
+// TestQueryNotFound confirms that every way of sending a query by name reports an unknown name in the
+// same way, while still saying where the query was looked for.
+func TestQueryNotFound(t *testing.T) {
+
+	persisted := InMemoryPersistenceStore()
+	client := CreateClient("http://localhost:1", nil, WithQueryPersistenceStore(persisted))
+	for _, test := range []struct {
+		err      error
+		expected error
+		message  string
+	}{
+		{client.NamedQuery(context.Background(), "Missing", QueryStoreFunc(persisted.GetQuery), nil, &QueryResponse{}), nil, "no query found with name Missing"},
+		{client.QueryByID(context.Background(), "Missing", nil, &QueryResponse{}), ErrQueryNotRegistered, "no query registered with ID: Missing"},
+		{client.QueryPrepared(context.Background(), "Missing", nil, &QueryResponse{}), ErrQueryNotPrepared, "no query prepared with name: Missing"},
+	} {
+		var notFound ErrQueryNotFound
+		assert.True(t, errors.As(test.err, &notFound), "An ErrQueryNotFound should have been returned: %v", test.err)
+		assert.Equal(t, "Missing", notFound.Name)
+		assert.Equal(t, test.expected, notFound.Err)
+		assert.EqualError(t, test.err, test.message)
+	}
+}
@@ -0,0 +1,51 @@
+package gqlclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// serviceAccountTokenSource creates the token source used by NewClientFromServiceAccount(...). It
+// is a variable so that unit tests can avoid the need for a real service account key.
+var serviceAccountTokenSource = google.JWTAccessTokenSourceFromJSON
+
+// OAuth2Auth returns a RequestHook, for use with WithRequestHook(...), that sets the Authorization
+// header of each request from the given OAuth2 token source, e.g. "Bearer eyJhbGciOi...". The
+// token source is consulted for every request; wrap it in oauth2.ReuseTokenSource(...) if it does
+// not already cache its tokens until they expire. If a token cannot be obtained, the request is
+// abandoned and the error returned from the query.
+func OAuth2Auth(ts oauth2.TokenSource) RequestHook {
+	return func(req *http.Request) error {
+		token, err := ts.Token()
+		if err != nil {
+			return fmt.Errorf("could not obtain OAuth2 token: %w", err)
+		}
+		token.SetAuthHeader(req)
+		return nil
+	}
+}
+
+// NewClientFromServiceAccount returns a GqlClient for a GraphQL API hosted on Google Cloud that
+// authenticates callers with service account credentials. The saJSON is the content of a service
+// account key file, as downloaded from the Google Cloud console, and the audience is the URL of
+// the GraphQL API, which serves both as the target URL of the client and the audience of the
+// self-signed JWT tokens that the client presents as Bearer tokens. Any ClientOption values are
+// applied as for CreateClient(...).
+//
+// An error is returned if the JSON cannot be parsed, is not a service account key, or holds a
+// private key of an unsupported type.
+func NewClientFromServiceAccount(saJSON []byte, audience string, opts ...ClientOption) (GqlClient, error) {
+
+	// Have the oauth2 package make sense of the key for us
+	ts, err := serviceAccountTokenSource(saJSON, audience)
+	if err != nil {
+		return nil, fmt.Errorf("could not load service account credentials: %w", err)
+	}
+
+	// Present the tokens ahead of any hooks the caller may have supplied
+	opts = append([]ClientOption{WithRequestHook(OAuth2Auth(ts))}, opts...)
+	return CreateClient(audience, nil, opts...), nil
+}
@@ -0,0 +1,142 @@
+package gqlclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+// This file defines unit tests for OAuth2 and Google Cloud service account authorization.
+
+// fakeTokenSource is an oauth2.TokenSource that hands out a fixed token, or a fixed error.
+type fakeTokenSource struct {
+	token *oauth2.Token
+	err   error
+	calls int
+}
+
+// Token returns the fake token or error.
+func (ts *fakeTokenSource) Token() (*oauth2.Token, error) {
+	ts.calls++
+	return ts.token, ts.err
+}
+
+// newAuthRecordingServer returns a fake GraphQL server that records the Authorization header of
+// the last request that it received.
+func newAuthRecordingServer(auth *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"data":{}}`))
+	}))
+}
+
+// serviceAccountJSON returns a service account key file holding the given PEM encoded private key.
+func serviceAccountJSON(t *testing.T, keyType string, keyPEM []byte) []byte {
+	saJSON, err := json.Marshal(map[string]string{
+		"type":           keyType,
+		"client_email":   "gogql@example.iam.gserviceaccount.com",
+		"private_key_id": "key-1",
+		"private_key":    string(keyPEM),
+	})
+	assert.Nil(t, err)
+	return saJSON
+}
+
+// TestOAuth2Auth confirms that the token from a token source is sent as a Bearer token.
+func TestOAuth2Auth(t *testing.T) {
+
+	var auth string
+	server := newAuthRecordingServer(&auth)
+	defer server.Close()
+
+	ts := &fakeTokenSource{token: &oauth2.Token{AccessToken: "fake-access-token", TokenType: "Bearer"}}
+	client := CreateClient(server.URL, nil, WithRequestHook(OAuth2Auth(ts)))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "Bearer fake-access-token", auth)
+	assert.Equal(t, 1, ts.calls, "The token source should have been asked for a token")
+
+	// A token source failure should abandon the request
+	ts = &fakeTokenSource{err: errors.New("metadata server unavailable")}
+	client = CreateClient(server.URL, nil, WithRequestHook(OAuth2Auth(ts)))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.NotNil(t, err, "The token source failure should have been reported")
+	assert.Contains(t, err.Error(), "metadata server unavailable")
+}
+
+// TestNewClientFromServiceAccount confirms that a service account client targets the audience and
+// presents a Bearer token, without needing real credentials.
+func TestNewClientFromServiceAccount(t *testing.T) {
+
+	var auth string
+	server := newAuthRecordingServer(&auth)
+	defer server.Close()
+
+	// Substitute a fake token source for the real thing
+	var gotJSON []byte
+	var gotAudience string
+	defer func(original func([]byte, string) (oauth2.TokenSource, error)) {
+		serviceAccountTokenSource = original
+	}(serviceAccountTokenSource)
+	serviceAccountTokenSource = func(saJSON []byte, audience string) (oauth2.TokenSource, error) {
+		gotJSON, gotAudience = saJSON, audience
+		return &fakeTokenSource{token: &oauth2.Token{AccessToken: "service-token", TokenType: "Bearer"}}, nil
+	}
+
+	client, err := NewClientFromServiceAccount([]byte(`{"type":"service_account"}`), server.URL)
+	assert.Nil(t, err, "Client creation should have succeeded")
+	assert.Equal(t, server.URL, client.GetTargetURL(), "The client should target the audience")
+	assert.Equal(t, server.URL, gotAudience, "The audience should have been passed to the token source")
+	assert.Equal(t, `{"type":"service_account"}`, string(gotJSON))
+
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "Bearer service-token", auth)
+}
+
+// TestNewClientFromServiceAccountKeys confirms that real service account key files are understood,
+// signing a JWT locally, and that unusable ones are reported.
+func TestNewClientFromServiceAccountKeys(t *testing.T) {
+
+	var auth string
+	server := newAuthRecordingServer(&auth)
+	defer server.Close()
+
+	// An RSA key is what Google issues
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	client, err := NewClientFromServiceAccount(serviceAccountJSON(t, "service_account", rsaPEM), server.URL)
+	assert.Nil(t, err, "An RSA service account key should have been accepted")
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.True(t, strings.HasPrefix(auth, "Bearer "), "A Bearer token should have been sent")
+	assert.Equal(t, 3, len(strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")), "The token should be a JWT")
+
+	// Garbage is not a key file
+	_, err = NewClientFromServiceAccount([]byte("not json"), server.URL)
+	assert.NotNil(t, err, "Unparseable JSON should have been rejected")
+	assert.Contains(t, err.Error(), "service account credentials")
+
+	// Nor is a key file for something other than a service account
+	_, err = NewClientFromServiceAccount(serviceAccountJSON(t, "authorized_user", rsaPEM), server.URL)
+	assert.NotNil(t, err, "A non service account key file should have been rejected")
+
+	// And an elliptic curve key is not supported
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	ecBytes, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	assert.Nil(t, err)
+	ecPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecBytes})
+	_, err = NewClientFromServiceAccount(serviceAccountJSON(t, "service_account", ecPEM), server.URL)
+	assert.NotNil(t, err, "An elliptic curve key should have been rejected")
+}
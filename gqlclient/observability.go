@@ -0,0 +1,225 @@
+package gqlclient
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger is the interface through which a GqlClient reports on its activity when configured with
+// WithLogger(...) or WithBackgroundLogger(...). It is deliberately small so that any logging package can be adapted to it.
+type Logger interface {
+	// Log records a message at the given level, e.g. LevelInfo, with optional alternating key and
+	// value pairs providing structured context.
+	Log(level string, msg string, keyvals ...interface{})
+}
+
+// The levels at which the client logs
+const (
+	LevelDebug = "DEBUG"
+	LevelInfo  = "INFO"
+	LevelWarn  = "WARN"
+	LevelError = "ERROR"
+	LevelAudit = "AUDIT" // Used by WithAuditLogger(...) for the record of every mutation
+)
+
+// MetricsRecorder is the interface through which a GqlClient reports query metrics when configured
+// with WithMetrics(...).
+type MetricsRecorder interface {
+	// RecordQuery records the outcome of a single query: the name of the operation (empty for
+	// anonymous operations), how long it took, and the error it failed with, if any.
+	RecordQuery(operation string, duration time.Duration, err error)
+
+	// RecordResponseSize records the size, in bytes, of the body of the response to a successful
+	// query, identified by its operation name (empty for anonymous operations).
+	RecordResponseSize(operation string, sizeBytes int64)
+}
+
+// RequestIDHeader is the HTTP header in which WithRequestID(...) sends request IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// WithLogger is a ClientOption that logs the outcome of every query: successes at LevelInfo and
+// failures at LevelError, along with the operation name and duration. The logger is also told of
+// problems with background activity, such as WithWarmup(...) queries, just as if it had been given
+// with WithBackgroundLogger(...).
+func WithLogger(logger Logger) ClientOption {
+	return func(gc *gqlClient) {
+		WithBackgroundLogger(logger)(gc)
+		WithMiddleware(loggingMiddleware(logger))(gc)
+	}
+}
+
+// WithBackgroundLogger is a ClientOption that gives the client a logger for everything other than the
+// outcome of each query: failed WithWarmup(...) queries, the errors picked by WithErrorSampler(...)
+// and the events of WithConnectionTracing(...). Unlike WithLogger(...), it does not log every query,
+// for services that would rather not have a log entry per query but still want to hear of the rest.
+func WithBackgroundLogger(logger Logger) ClientOption {
+	return func(gc *gqlClient) {
+		gc.logger = logger
+	}
+}
+
+// loggingMiddleware returns the Middleware that does the work of WithLogger(...).
+func loggingMiddleware(logger Logger) Middleware {
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			start := time.Now()
+			err := next(ctx, queryStr, queryParms, response)
+			operation := firstOperationName(queryStr)
+			if err != nil {
+				logger.Log(LevelError, "GraphQL query failed", "operation", operation, "duration", time.Since(start), "error", err)
+			} else {
+				logger.Log(LevelInfo, "GraphQL query completed", "operation", operation, "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// PerOperationLogger returns Middleware that logs the outcome of every query to the given logger at a
+// level that depends on the type of the operation, e.g. LevelDebug for queries but LevelInfo for
+// mutations, which change state and are generally of more interest. Operations whose level is the
+// empty string are not logged at all. The type is taken from the first operation in the query
+// document; documents that cannot be understood are treated as queries.
+func PerOperationLogger(logger Logger, queryLevel, mutationLevel, subscriptionLevel string) Middleware {
+	levels := map[string]string{"query": queryLevel, "mutation": mutationLevel, "subscription": subscriptionLevel}
+	return func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			start := time.Now()
+			err := next(ctx, queryStr, queryParms, response)
+			keyword := operationType(queryStr)
+			level := levels[keyword]
+			if level == "" {
+				return err
+			}
+			operation := firstOperationName(queryStr)
+			if err != nil {
+				logger.Log(level, "GraphQL "+keyword+" failed", "operation", operation, "duration", time.Since(start), "error", err)
+			} else {
+				logger.Log(level, "GraphQL "+keyword+" completed", "operation", operation, "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// WithAuditLogger is a ClientOption that keeps an audit trail of every mutation, successful or not,
+// logging each at LevelAudit to the given logger regardless of any other logging configured for the
+// client. Queries and subscriptions are not logged.
+func WithAuditLogger(logger Logger) ClientOption {
+	return WithMiddleware(PerOperationLogger(logger, "", LevelAudit, ""))
+}
+
+// WithMetrics is a ClientOption that records the outcome and duration of every query, and the
+// response size of every successful one, with the given MetricsRecorder.
+func WithMetrics(recorder MetricsRecorder) ClientOption {
+	return WithMiddleware(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			start := time.Now()
+			err := next(ctx, queryStr, queryParms, response)
+			operation := firstOperationName(queryStr)
+			recorder.RecordQuery(operation, time.Since(start), err)
+			if err == nil {
+				recorder.RecordResponseSize(operation, response.bodySize)
+			}
+			return err
+		}
+	})
+}
+
+// WithOTelTracing is a ClientOption that wraps every query in an OpenTelemetry span created by the
+// given tracer. The span is a child of any span found in the query context, is named for the
+// GraphQL operation, and records the error if the query fails.
+func WithOTelTracing(tracer trace.Tracer) ClientOption {
+	return WithMiddleware(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			operation := firstOperationName(queryStr)
+			ctx, span := tracer.Start(ctx, "GraphQL "+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attribute.String("graphql.operation.name", operation)))
+			defer span.End()
+
+			err := next(ctx, queryStr, queryParms, response)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	})
+}
+
+// WithRequestID is a ClientOption that sends a request ID, obtained from the given function, in the
+// RequestIDHeader of every HTTP request. Retried requests are given fresh IDs. If generate is nil,
+// random version 4 UUIDs are used.
+func WithRequestID(generate func() string) ClientOption {
+	if generate == nil {
+		generate = NewRequestID
+	}
+	return WithRequestHook(func(req *http.Request) error {
+		req.Header.Set(RequestIDHeader, generate())
+		return nil
+	})
+}
+
+// NewRequestID returns a random version 4 UUID, as used by default by WithRequestID(...).
+func NewRequestID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40 // Version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// ObservabilityOptions selects the observability features enabled by WithObservabilityBundle(...).
+// Each feature is enabled if its field is not nil.
+type ObservabilityOptions struct {
+	Logger  Logger          // If not nil, passed to WithLogger(...)
+	Metrics MetricsRecorder // If not nil, passed to WithMetrics(...)
+	Tracer  trace.Tracer    // If not nil, passed to WithOTelTracing(...)
+}
+
+// WithObservabilityBundle is a ClientOption that enables logging, metrics and tracing together,
+// along with request IDs so that the three can be correlated with server side records. It is a
+// shorthand for giving WithLogger(...), WithMetrics(...), WithOTelTracing(...) and WithRequestID(nil)
+// individually; features whose ObservabilityOptions field is nil are left disabled.
+func WithObservabilityBundle(opts ObservabilityOptions) ClientOption {
+	return func(gc *gqlClient) {
+		if opts.Tracer != nil {
+			WithOTelTracing(opts.Tracer)(gc)
+		}
+		if opts.Metrics != nil {
+			WithMetrics(opts.Metrics)(gc)
+		}
+		if opts.Logger != nil {
+			WithLogger(opts.Logger)(gc)
+		}
+		WithRequestID(nil)(gc)
+	}
+}
+
+// operationType returns the type of the first operation in a query document, i.e. "query",
+// "mutation" or "subscription", or "query" if the document cannot be understood.
+func operationType(queryStr *string) string {
+	info := documentInfoOf(*queryStr)
+	if info.err != nil || len(info.types) == 0 {
+		return "query"
+	}
+	return info.types[0]
+}
+
+// firstOperationName returns the name of the first operation in a query document, or the empty
+// string if it is anonymous or the document cannot be understood.
+func firstOperationName(queryStr *string) string {
+	info := documentInfoOf(*queryStr)
+	if info.err != nil || len(info.names) == 0 {
+		return ""
+	}
+	return info.names[0]
+}
@@ -0,0 +1,211 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// This file defines unit tests for the observability options.
+
+// logEntry is a single call to a capturingLogger
+type logEntry struct {
+	level   string
+	msg     string
+	keyvals []interface{}
+}
+
+// capturingLogger is a Logger that remembers what it is given
+type capturingLogger struct {
+	mutex   sync.Mutex
+	entries []logEntry
+}
+
+// Log records the entry
+func (l *capturingLogger) Log(level string, msg string, keyvals ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.entries = append(l.entries, logEntry{level, msg, keyvals})
+}
+
+// snapshot returns a copy of the entries recorded so far
+func (l *capturingLogger) snapshot() []logEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]logEntry(nil), l.entries...)
+}
+
+// metricsRecord is a single call to a capturingMetrics
+type metricsRecord struct {
+	operation string
+	duration  time.Duration
+	err       error
+}
+
+// capturingMetrics is a MetricsRecorder that remembers what it is given
+type capturingMetrics struct {
+	records []metricsRecord
+	sizes   []int64
+}
+
+// RecordQuery records the query
+func (m *capturingMetrics) RecordQuery(operation string, duration time.Duration, err error) {
+	m.records = append(m.records, metricsRecord{operation, duration, err})
+}
+
+// RecordResponseSize records the response size
+func (m *capturingMetrics) RecordResponseSize(operation string, sizeBytes int64) {
+	m.sizes = append(m.sizes, sizeBytes)
+}
+
+// capturingTracer is an OpenTelemetry tracer that remembers the spans it starts
+type capturingTracer struct {
+	noop.Tracer
+	spans []*capturingSpan
+}
+
+// capturingSpan is an OpenTelemetry span that remembers what is done to it
+type capturingSpan struct {
+	noop.Span
+	name   string
+	attrs  []attribute.KeyValue
+	status codes.Code
+	ended  bool
+}
+
+// Start starts and remembers a new span
+func (tr *capturingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(opts...)
+	span := &capturingSpan{name: name, attrs: config.Attributes()}
+	tr.spans = append(tr.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+// SetStatus remembers the status
+func (s *capturingSpan) SetStatus(code codes.Code, description string) {
+	s.status = code
+}
+
+// End remembers that the span was ended
+func (s *capturingSpan) End(options ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+// TestObservabilityBundle confirms that a query is logged, measured, traced and given a request ID.
+func TestObservabilityBundle(t *testing.T) {
+
+	// Fail every second request, and remember the request IDs
+	var requestIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIDs = append(requestIDs, r.Header.Get(RequestIDHeader))
+		if len(requestIDs)%2 == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	logger := &capturingLogger{}
+	metrics := &capturingMetrics{}
+	tracer := &capturingTracer{}
+	client := CreateClient(server.URL, nil, WithObservabilityBundle(ObservabilityOptions{
+		Logger:  logger,
+		Metrics: metrics,
+		Tracer:  tracer,
+	}))
+
+	// One success and one failure
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), "First query should have succeeded")
+	assert.NotNil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), "Second query should have failed")
+
+	// Both should have been logged
+	assert.Equal(t, 2, len(logger.entries), "Both queries should have been logged")
+	assert.Equal(t, LevelInfo, logger.entries[0].level)
+	assert.Equal(t, []interface{}{"operation", "FetchRepoInfo"}, logger.entries[0].keyvals[:2])
+	assert.Equal(t, LevelError, logger.entries[1].level)
+
+	// Both should have been measured
+	assert.Equal(t, 2, len(metrics.records), "Both queries should have been measured")
+	assert.Equal(t, "FetchRepoInfo", metrics.records[0].operation)
+	assert.Nil(t, metrics.records[0].err)
+	assert.NotNil(t, metrics.records[1].err)
+	assert.Equal(t, []int64{int64(len(`{"data":{}}`))}, metrics.sizes, "Only the successful response size should have been recorded")
+
+	// Both should have been traced
+	assert.Equal(t, 2, len(tracer.spans), "Both queries should have been traced")
+	assert.Equal(t, "GraphQL FetchRepoInfo", tracer.spans[0].name)
+	assert.Contains(t, tracer.spans[0].attrs, attribute.String("graphql.operation.name", "FetchRepoInfo"))
+	assert.True(t, tracer.spans[0].ended, "The span should have been ended")
+	assert.Equal(t, codes.Unset, tracer.spans[0].status)
+	assert.Equal(t, codes.Error, tracer.spans[1].status, "The failure should have been recorded on the span")
+
+	// And each request should have had its own ID
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Equal(t, 2, len(requestIDs))
+	assert.Regexp(t, uuidPattern, requestIDs[0])
+	assert.Regexp(t, uuidPattern, requestIDs[1])
+	assert.NotEqual(t, requestIDs[0], requestIDs[1], "Request IDs should be unique")
+}
+
+// TestObservabilityBundlePartial confirms that features with nil options are left disabled.
+func TestObservabilityBundlePartial(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	metrics := &capturingMetrics{}
+	client := CreateClient(server.URL, nil, WithObservabilityBundle(ObservabilityOptions{Metrics: metrics}))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, 1, len(metrics.records), "The query should have been measured")
+	assert.Equal(t, 1, len(client.(*gqlClient).middleware), "Only the metrics middleware should have been added")
+}
+
+// TestPerOperationLogger confirms that queries and mutations are logged at their own levels, and
+// that the audit logger only records mutations.
+func TestPerOperationLogger(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	logger := &capturingLogger{}
+	audit := &capturingLogger{}
+	client := CreateClient(server.URL, nil,
+		WithMiddleware(PerOperationLogger(logger, LevelDebug, LevelInfo, LevelWarn)),
+		WithAuditLogger(audit))
+
+	// A query, then a mutation with a fragment ahead of it
+	queryStr := `query Viewer { viewer { login } }`
+	mutationStr := `fragment F on Issue { id } mutation AddIssue { createIssue(input: {title: "x"}) { issue { ...F } } }`
+	assert.Nil(t, client.Query(&queryStr, nil, &QueryResponse{}), "Query should have succeeded")
+	assert.Nil(t, client.Query(&mutationStr, nil, &QueryResponse{}), "Mutation should have succeeded")
+
+	entries := logger.snapshot()
+	assert.Equal(t, 2, len(entries), "Both operations should have been logged")
+	assert.Equal(t, LevelDebug, entries[0].level, "Query should have been logged at the query level")
+	assert.Equal(t, "GraphQL query completed", entries[0].msg)
+	assert.Equal(t, []interface{}{"operation", "Viewer"}, entries[0].keyvals[:2])
+	assert.Equal(t, LevelInfo, entries[1].level, "Mutation should have been logged at the mutation level")
+	assert.Equal(t, "GraphQL mutation completed", entries[1].msg)
+	assert.Equal(t, []interface{}{"operation", "AddIssue"}, entries[1].keyvals[:2])
+
+	// Only the mutation should have made it into the audit trail
+	entries = audit.snapshot()
+	assert.Equal(t, 1, len(entries), "Only the mutation should have been audited")
+	assert.Equal(t, LevelAudit, entries[0].level)
+	assert.Equal(t, "GraphQL mutation completed", entries[0].msg)
+}
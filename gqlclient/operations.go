@@ -0,0 +1,252 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// WithAutoOperationName is a ClientOption that has the client send the operationName field alongside
+// each query, taking the name from the query document itself, e.g. FetchRepoInfo from
+// "query FetchRepoInfo(...) { ... }". This helps servers that log or authorize requests by operation
+// name. The field is omitted for anonymous operations. Documents containing more than one operation
+// must have the intended one chosen with ContextWithOperationName(...), whose name is then sent.
+func WithAutoOperationName() ClientOption {
+	return func(gc *gqlClient) {
+		gc.autoOpName = true
+	}
+}
+
+// ErrOperationNotSelected is returned for a query document that defines more than one operation if
+// none of them has been chosen with ContextWithOperationName(...).
+var ErrOperationNotSelected = errors.New("query document defines more than one operation but none was selected")
+
+// operationNameContextKey is the type of the context key under which chosen operation names are stored.
+type operationNameContextKey struct{}
+
+// ContextWithOperationName returns a copy of the given context that selects the named operation of
+// a query document defining several, e.g. a document of shared queries loaded from a file. The name
+// is sent as the operationName field of every query made with the context, as the GraphQL
+// specification requires for such documents, and is checked against the document first: a name that
+// the document does not define fails the query without anything being sent. Without a chosen name, a
+// document defining more than one operation fails with ErrOperationNotSelected.
+//
+// The name cannot be carried by the application/graphql request bodies of WithContentType(...), and
+// is ignored by clients configured to send them.
+func ContextWithOperationName(ctx context.Context, operationName string) context.Context {
+	return context.WithValue(ctx, operationNameContextKey{}, operationName)
+}
+
+// operationName returns the operation name to be sent with the given packed query: the one chosen by
+// the context, if any, or otherwise the only one the document defines if the client has been asked
+// to send it, or the empty string. An error is returned if the chosen operation is not defined by the
+// document, or if none was chosen and the document defines several. Documents that cannot be
+// understood are left for the server to judge.
+func (gc *gqlClient) operationName(ctx context.Context, packed string) (string, error) {
+	info := gc.documentInfo(ctx, packed)
+	names := info.names
+	if info.err != nil {
+		names = nil
+	}
+
+	// A name chosen by the caller must be one that the document defines
+	if chosen, _ := ctx.Value(operationNameContextKey{}).(string); chosen != "" {
+		for _, name := range names {
+			if name == chosen {
+				return chosen, nil
+			}
+		}
+		if names == nil {
+			return chosen, nil
+		}
+		return "", fmt.Errorf("operation %q is not defined by the query document", chosen)
+	}
+
+	// Otherwise there had better be no doubt about which operation is meant
+	if len(names) > 1 {
+		return "", ErrOperationNotSelected
+	}
+	if !gc.autoOpName || len(names) != 1 {
+		return "", nil
+	}
+	return names[0], nil
+}
+
+// ExtractOperationNames returns the names of all of the operations defined in a GraphQL query
+// document, in the order in which they are declared. Anonymous operations, including shorthand
+// queries, are represented by an empty string. Fragment definitions are not operations and are
+// not included. An error is returned if the document is not well formed, e.g. if its braces are
+// unbalanced.
+func ExtractOperationNames(queryStr string) ([]string, error) {
+
+	// Break the document down into its top level definitions. The document is tokenized as given
+	// rather than packed, since packing would have a comment swallow everything that followed it.
+	tokens, err := tokenize(queryStr)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := definitions(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the operation names
+	names := []string{}
+	for _, def := range defs {
+		if def.keyword != "fragment" {
+			names = append(names, def.name)
+		}
+	}
+	return names, nil
+}
+
+// OperationTypeOf returns the type of the operation in a GraphQL query document, "query", "mutation"
+// or "subscription", without sending it anywhere, for the benefit of middleware, routers and logging
+// code that treat the types differently. Shorthand queries, which begin with a brace, are of type
+// "query". If the document defines more than one operation, the type of the first is returned.
+//
+// An error is returned if the document is not well formed, defines no operation at all, e.g. if it
+// holds only fragments or comments, or begins with anything other than one of the keywords. The
+// keywords are case sensitive, as the GraphQL specification requires, so "QUERY { ... }" is refused.
+func OperationTypeOf(queryStr string) (string, error) {
+
+	// Break the document down into its top level definitions. The document is tokenized as given
+	// rather than packed, since packing would have a comment swallow everything that followed it.
+	tokens, err := tokenize(queryStr)
+	if err != nil {
+		return "", err
+	}
+	defs, err := definitions(tokens)
+	if err != nil {
+		return "", err
+	}
+
+	// Report the first operation we find
+	for _, def := range defs {
+		if def.keyword != "fragment" {
+			return def.keyword, nil
+		}
+	}
+	return "", errors.New("GraphQL document contains no operations")
+}
+
+// ComposeQueries merges several GraphQL documents, e.g. operations and fragments kept in separate
+// .graphql files, into a single packed document that can be sent as one query. Fragments defined
+// identically in more than one of the documents are included only once. An error is returned if any
+// of the documents is not well formed, if two operations share a name, if a fragment name is given
+// two different definitions, or if an anonymous operation would not be the only operation. Comments
+// are removed as the documents are packed. Where the result holds more than one operation, the one to
+// run is chosen with ContextWithOperationName(...).
+func ComposeQueries(queries []string) (string, error) {
+
+	// Gather the definitions of every document, skipping repeated fragments
+	var parts []string
+	fragments := make(map[string]string)
+	for _, q := range queries {
+		packed, err := packDocument(q)
+		if err != nil {
+			return "", err
+		}
+		tokens, err := tokenize(packed)
+		if err != nil {
+			return "", err
+		}
+		defs, err := definitions(tokens)
+		if err != nil {
+			return "", err
+		}
+		for _, def := range defs {
+			text := packed[tokens[def.start].pos : tokens[def.endBrace].pos+1]
+			if def.keyword == "fragment" {
+				if existing, ok := fragments[def.name]; ok {
+					if existing != text {
+						return "", fmt.Errorf("fragment %s is defined more than once, differently", def.name)
+					}
+					continue
+				}
+				fragments[def.name] = text
+			}
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		return "", errors.New("no GraphQL documents to compose")
+	}
+	composed := strings.Join(parts, " ")
+
+	// Make sure that every operation in the combined document can still be told apart
+	names, err := ExtractOperationNames(composed)
+	if err != nil {
+		return "", err
+	}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" && len(names) > 1 {
+			return "", errors.New("an anonymous operation cannot be composed with other operations")
+		}
+		if seen[name] {
+			return "", fmt.Errorf("operation %s is defined more than once", name)
+		}
+		seen[name] = true
+	}
+	return composed, nil
+}
+
+// maxDocumentInfos is the number of query documents whose analysis documentInfoOf(...) remembers.
+// Applications use a fixed set of queries, so the limit is only reached by queries built dynamically,
+// which are then analysed afresh each time rather than filling memory.
+const maxDocumentInfos = 1000
+
+var (
+	documentInfos     sync.Map     // The analyses made by documentInfoOf(...), keyed by document text
+	documentInfoCount atomic.Int32 // The number of analyses held in documentInfos
+)
+
+// documentInfo is what is known of the operations defined by a query document.
+type documentInfo struct {
+	names []string // The names of the operations, in order, empty for anonymous operations
+	types []string // The types of the operations, query, mutation or subscription, in the same order
+	err   error    // Set if the document could not be understood
+}
+
+// documentInfoOf returns the analysis of the operations defined by a query document, working it out
+// only the first time that the document is seen. Every query passes through here on its way to the
+// server, so this spares them the cost of tokenizing the document over and over.
+func documentInfoOf(doc string) *documentInfo {
+
+	// Have we seen this one before?
+	if info, ok := documentInfos.Load(doc); ok {
+		return info.(*documentInfo)
+	}
+
+	// No, work it out and remember it, if there is room
+	info := analyzeDocument(doc)
+	if documentInfoCount.Load() < maxDocumentInfos {
+		if _, loaded := documentInfos.LoadOrStore(doc, info); !loaded {
+			documentInfoCount.Add(1)
+		}
+	}
+	return info
+}
+
+// analyzeDocument works out the names and types of the operations defined by a query document.
+func analyzeDocument(doc string) *documentInfo {
+
+	info := &documentInfo{}
+	tokens, err := tokenize(doc)
+	var defs []definition
+	if err == nil {
+		defs, err = definitions(tokens)
+	}
+	info.err = err
+	for _, def := range defs {
+		if def.keyword != "fragment" {
+			info.names = append(info.names, def.name)
+			info.types = append(info.types, def.keyword)
+		}
+	}
+	return info
+}
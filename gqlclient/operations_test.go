@@ -0,0 +1,227 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the operation document helpers.
+
+// TestExtractOperationNames covers named, anonymous and mixed documents.
+func TestExtractOperationNames(t *testing.T) {
+
+	// Three named operations, with a fragment that should be ignored
+	names, err := ExtractOperationNames(`
+		query A($id: ID!) { node(id: $id) { ...F } }
+		mutation B { addStar(input: {starrableId: "}"}) { clientMutationId } }
+		fragment F on Node { id }
+		subscription C { updates { id } }`)
+	assert.Nil(t, err, "Named operations should have been parsed")
+	assert.Equal(t, []string{"A", "B", "C"}, names)
+
+	// An anonymous operation, in both the long and shorthand forms
+	names, err = ExtractOperationNames(SimpleRepoDataQuery)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"FetchRepoInfo"}, names)
+	names, err = ExtractOperationNames(`{ viewer { login } }`)
+	assert.Nil(t, err, "Shorthand query should have been parsed")
+	assert.Equal(t, []string{""}, names)
+
+	// A mix of named and anonymous
+	names, err = ExtractOperationNames(`query { viewer { login } } query Named { rateLimit { cost } }`)
+	assert.Nil(t, err, "Mixed operations should have been parsed")
+	assert.Equal(t, []string{"", "Named"}, names)
+
+	// Malformed documents
+	_, err = ExtractOperationNames(`query A { viewer { login }`)
+	assert.NotNil(t, err, "Unbalanced braces should have been reported")
+	_, err = ExtractOperationNames(`query A { viewer { login } } }`)
+	assert.NotNil(t, err, "A stray closing brace should have been reported")
+	_, err = ExtractOperationNames(`query A { viewer(login: "unterminated) { login } }`)
+	assert.NotNil(t, err, "An unterminated string should have been reported")
+	_, err = ExtractOperationNames(``)
+	assert.NotNil(t, err, "An empty document should have been reported")
+}
+
+// TestAutoOperationName confirms that the operation name is sent for named operations only.
+func TestAutoOperationName(t *testing.T) {
+
+	// Record the raw envelopes received
+	var envelopes []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&envelope)
+		envelopes = append(envelopes, envelope)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithAutoOperationName())
+	anonymous := "{ viewer { login } }"
+	for _, q := range []*string{&SimpleRepoDataQuery, &anonymous} {
+		assert.Nil(t, client.Query(q, nil, &QueryResponse{}), "Query should not have failed")
+	}
+	assert.Equal(t, "FetchRepoInfo", envelopes[0]["operationName"], "The operation name should have been sent")
+	assert.NotContains(t, envelopes[1], "operationName", "No name should be sent for an anonymous operation")
+
+	// And without the option, no name should be sent at all
+	client = CreateClient(server.URL, nil)
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.NotContains(t, envelopes[2], "operationName", "No name should be sent without the option")
+}
+
+// TestContextWithOperationName confirms that the chosen operation of a multi-operation document is
+// named in the request, and that documents whose operation is in doubt are refused before sending.
+func TestContextWithOperationName(t *testing.T) {
+
+	// Record the raw envelopes received
+	var envelopes []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&envelope)
+		envelopes = append(envelopes, envelope)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil)
+	multiple := "query ViewerLogin { viewer { login } } query ViewerName { viewer { name } }"
+
+	// The chosen operation should be named, with or without the automatic operation names
+	ctx := ContextWithOperationName(context.Background(), "ViewerName")
+	assert.Nil(t, client.QueryContext(ctx, &multiple, nil, &QueryResponse{}), "The chosen operation should have been sent")
+	auto := CreateClient(server.URL, nil, WithAutoOperationName())
+	assert.Nil(t, auto.QueryContext(ctx, &multiple, nil, &QueryResponse{}))
+	assert.Equal(t, 2, len(envelopes))
+	assert.Equal(t, "ViewerName", envelopes[0]["operationName"], "The chosen operation should have been named")
+	assert.Equal(t, "ViewerName", envelopes[1]["operationName"], "The chosen operation should have been named")
+
+	// Without a choice, or with a choice the document does not offer, nothing should be sent
+	assert.Equal(t, ErrOperationNotSelected, client.Query(&multiple, nil, &QueryResponse{}))
+	ctx = ContextWithOperationName(context.Background(), "ViewerEmail")
+	err := client.QueryContext(ctx, &multiple, nil, &QueryResponse{})
+	assert.NotNil(t, err, "An operation that the document does not define should have been refused")
+	assert.Contains(t, err.Error(), `"ViewerEmail"`)
+	assert.Equal(t, 2, len(envelopes), "Nothing more should have been sent")
+}
+
+// TestComposeQueries confirms that operations are merged with a single copy of their shared fragment.
+func TestComposeQueries(t *testing.T) {
+
+	repoQuery := `query Repo($owner: String!, $name: String!) {
+		repository(owner: $owner, name: $name) { ...RepoFields }
+	}
+	fragment RepoFields on Repository { name createdAt }`
+	viewerQuery := `query Viewer {
+		viewer { repositories(first: 1) { nodes { ...RepoFields } } }
+	}`
+	fragment := `fragment RepoFields on Repository {
+		name
+		createdAt
+	}`
+
+	// Two operations and a fragment that is defined twice, identically
+	composed, err := ComposeQueries([]string{repoQuery, viewerQuery, fragment})
+	assert.Nil(t, err, "Queries should have been composed")
+	assert.Equal(t, 1, strings.Count(composed, "query Repo("), "Repo operation should appear once")
+	assert.Equal(t, 1, strings.Count(composed, "query Viewer {"), "Viewer operation should appear once")
+	assert.Equal(t, 1, strings.Count(composed, "fragment RepoFields on Repository { name createdAt }"), "Shared fragment should appear once")
+	names, err := ExtractOperationNames(composed)
+	assert.Nil(t, err, "Composed document should be well formed")
+	assert.Equal(t, []string{"Repo", "Viewer"}, names)
+}
+
+// TestComposeQueriesComments confirms that comments in the documents, as typically found in .graphql
+// files, are removed rather than hiding the definitions that follow them.
+func TestComposeQueriesComments(t *testing.T) {
+
+	fragment := "# Shared fields\nfragment R on Repository { name } # the name only\n"
+	operation := "# Fetch one repository\nquery Repo($owner: String!) {\n\t# Look it up by owner\n\trepository(owner: $owner, name: \"gogql\") { ...R }\n}\n"
+
+	composed, err := ComposeQueries([]string{fragment, operation})
+	assert.Nil(t, err, "Commented documents should have been composed")
+	assert.Equal(t, `fragment R on Repository { name } query Repo($owner: String!) { repository(owner: $owner, name: "gogql") { ...R } }`, composed)
+
+	names, err := ExtractOperationNames(operation)
+	assert.Nil(t, err, "A commented document should be well formed")
+	assert.Equal(t, []string{"Repo"}, names, "The comment should not have hidden the operation")
+}
+
+// TestComposeQueriesConflicts confirms that documents that cannot be combined are reported.
+func TestComposeQueriesConflicts(t *testing.T) {
+
+	// Two operations sharing a name
+	_, err := ComposeQueries([]string{`query Repo { viewer { login } }`, `query Repo { rateLimit { cost } }`})
+	assert.NotNil(t, err, "Duplicate operation names should have been reported")
+	assert.Contains(t, err.Error(), "Repo", "The conflicting name should have been reported")
+
+	// A fragment with two different definitions
+	_, err = ComposeQueries([]string{`fragment F on User { login }`, `fragment F on User { name }`, `query A { viewer { ...F } }`})
+	assert.NotNil(t, err, "Conflicting fragments should have been reported")
+	assert.Contains(t, err.Error(), "F")
+
+	// An anonymous operation alongside another
+	_, err = ComposeQueries([]string{`{ viewer { login } }`, `query A { rateLimit { cost } }`})
+	assert.NotNil(t, err, "An anonymous operation should not have been composed with others")
+
+	// Nothing at all, or nonsense
+	_, err = ComposeQueries(nil)
+	assert.NotNil(t, err, "An empty list should have been reported")
+	_, err = ComposeQueries([]string{`query A { viewer { login }`})
+	assert.NotNil(t, err, "A malformed document should have been reported")
+}
+
+// TestOperationTypeOf covers each operation type, the shorthand query and the documents that are
+// refused.
+func TestOperationTypeOf(t *testing.T) {
+
+	types := map[string]string{
+		"query FetchRepoInfo($owner: String!) { repository(owner: $owner) { name } }": "query",
+		"mutation { addStar(input: {starrableId: \"x\"}) { clientMutationId } }":      "mutation",
+		"subscription OnIssue { issueOpened { number } }":                             "subscription",
+		"{ viewer { login } }":                              "query",
+		"fragment F on User { login }\nmutation M { ping }": "mutation",
+		"# Find out who we are\n{ viewer { login } }":       "query",
+	}
+	for doc, expected := range types {
+		opType, err := OperationTypeOf(doc)
+		assert.Nil(t, err, "%q should have been understood", doc)
+		assert.Equal(t, expected, opType, "%q", doc)
+	}
+
+	// Keywords are case sensitive, and there must be an operation to speak of
+	for _, doc := range []string{
+		"QUERY { viewer { login } }",
+		"Mutation M { ping }",
+		"# Nothing but a comment",
+		"",
+		"fragment F on User { login }",
+		"query { viewer { login }",
+	} {
+		opType, err := OperationTypeOf(doc)
+		assert.NotNil(t, err, "%q should have been refused", doc)
+		assert.Empty(t, opType)
+	}
+}
+
+// TestDocumentInfoOf confirms that the analysis of a document is worked out only once and then
+// shared by every query that sends it.
+func TestDocumentInfoOf(t *testing.T) {
+
+	doc := "fragment F on User { login }\nquery Who { viewer { ...F } }\nmutation Star { ping }"
+	info := documentInfoOf(doc)
+	assert.Nil(t, info.err, "the document should have been understood")
+	assert.Equal(t, []string{"Who", "Star"}, info.names)
+	assert.Equal(t, []string{"query", "mutation"}, info.types)
+	assert.Same(t, info, documentInfoOf(doc), "the analysis should have been remembered")
+
+	// Documents that cannot be understood are remembered as such
+	bad := documentInfoOf("query { viewer { login }")
+	assert.NotNil(t, bad.err, "the broken document should have been refused")
+	assert.Empty(t, bad.names)
+}
@@ -0,0 +1,404 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ParallelPageRequest describes a paged GraphQL connection query to be fetched by ParallelPaginate(...).
+type ParallelPageRequest struct {
+	QueryStr    string                 // The query, which must accept variables for the page size and position
+	BaseParams  map[string]interface{} // Variables common to every page, may be nil
+	PageSize    int                    // The number of items to request per page
+	PageSizeVar string                 // The name of the page size variable, "first" if empty
+	CursorVar   string                 // The name of the cursor variable used for sequential paging, "after" if empty
+	Concurrency int                    // The maximum number of pages to fetch at once, 4 if less than one
+
+	// OffsetParams, if not nil, returns the variables that position a page at the given item offset,
+	// e.g. an offset argument or a cursor synthesized from the offset for servers whose cursors allow
+	// that. If OffsetParams is nil, or the connection does not report a total count, pages can only
+	// be found by following cursors and are fetched one at a time.
+	OffsetParams func(offset int) map[string]interface{}
+
+	// NewData returns a new, empty, structure into which a page of the response can be decoded.
+	NewData func() interface{}
+
+	// ExtractPage returns the items found in a decoded page, its paging information, and the total
+	// number of items in the connection, or -1 if the connection does not expose a totalCount.
+	ExtractPage func(data interface{}) (items []interface{}, pageInfo *PageInfo, totalCount int, err error)
+}
+
+// ParallelPaginate fetches every item of a paged GraphQL connection, returning them in connection
+// order. The first page is fetched on its own to discover the total number of items; if the request
+// provides OffsetParams, the remaining pages are then fetched concurrently, at most Concurrency at a
+// time. Otherwise the pages are fetched sequentially by following cursors.
+//
+// The first error encountered, including expiry of the context deadline, abandons any pages still
+// to be fetched and is returned.
+func ParallelPaginate(ctx context.Context, client GqlClient, req ParallelPageRequest) ([]interface{}, error) {
+
+	// Fill in the defaults
+	if req.PageSize < 1 || req.NewData == nil || req.ExtractPage == nil {
+		return nil, errors.New("parallel pagination requires a PageSize, NewData and ExtractPage")
+	}
+	if req.PageSizeVar == "" {
+		req.PageSizeVar = "first"
+	}
+	if req.CursorVar == "" {
+		req.CursorVar = "after"
+	}
+	if req.Concurrency < 1 {
+		req.Concurrency = 4
+	}
+
+	// Fetch the first page to find out what we are dealing with
+	items, pageInfo, total, err := fetchPage(ctx, client, &req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we can't jump around the connection, follow the cursors one page at a time
+	if req.OffsetParams == nil || total < 0 {
+		for pageInfo != nil && pageInfo.HasNextPage {
+			var page []interface{}
+			page, pageInfo, _, err = fetchPage(ctx, client, &req, map[string]interface{}{req.CursorVar: pageInfo.EndCursor})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, page...)
+		}
+		return items, nil
+	}
+
+	// Otherwise, fetch all the remaining pages at once, within the bounds of our concurrency limit
+	pageCount := (total + req.PageSize - 1) / req.PageSize
+	if pageCount < 1 {
+		return items, nil
+	}
+	pages := make([][]interface{}, pageCount)
+	pages[0] = items
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	slots := make(chan struct{}, req.Concurrency)
+	for p := 1; p < pageCount; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+
+			// Wait our turn, unless things have already gone wrong
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				once.Do(func() { firstErr = ctx.Err() })
+				return
+			}
+
+			page, _, _, err := fetchPage(ctx, client, &req, req.OffsetParams(p*req.PageSize))
+			if err != nil {
+				once.Do(func() { firstErr = err })
+				cancel()
+				return
+			}
+			pages[p] = page
+		}(p)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Stitch the pages together in order
+	for _, page := range pages[1:] {
+		items = append(items, page...)
+	}
+	return items, nil
+}
+
+// fetchPage runs the paged query with the given position variables added to the base parameters.
+func fetchPage(ctx context.Context, client GqlClient, req *ParallelPageRequest, position map[string]interface{}) ([]interface{}, *PageInfo, int, error) {
+
+	// Assemble this page's variables
+	queryParms := make(map[string]interface{}, len(req.BaseParams)+len(position)+1)
+	for k, v := range req.BaseParams {
+		queryParms[k] = v
+	}
+	queryParms[req.PageSizeVar] = req.PageSize
+	for k, v := range position {
+		queryParms[k] = v
+	}
+
+	// Run the query and check for GraphQL errors
+	response := QueryResponse{Data: req.NewData()}
+	if err := client.QueryContext(ctx, &req.QueryStr, &queryParms, &response); err != nil {
+		return nil, nil, 0, err
+	}
+	if err := response.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+	return req.ExtractPage(response.Data)
+}
+
+// PaginateRequest describes a paged GraphQL connection query to be fetched by Paginate(...).
+type PaginateRequest[T any] struct {
+	QueryStr   string                 // The query, which must accept the cursor variable and, if PageSize is set, $first
+	BaseParams map[string]interface{} // Variables common to every page, may be nil
+	CursorVar  string                 // The name of the cursor variable, "after" if empty
+	PageSize   int                    // If greater than zero, passed to the query as the $first variable
+
+	// CostWarning, if not nil, is called once, with the total cost so far, when the cumulative github
+	// rate limit cost of the pages first exceeds CostThreshold, giving early warning of a crawl that
+	// is eating into the quota. The selection "rateLimit { cost remaining }" is injected into the
+	// query to have the cost of each page reported, so this is only suited to the github API.
+	CostWarning   func(total int)
+	CostThreshold int
+
+	// ExtractPage is given each page of the response, with its Data field set to a *json.RawMessage
+	// holding the raw JSON of the data, and returns the items on the page along with its paging
+	// information.
+	ExtractPage func(response *QueryResponse) ([]T, *PageInfo, error)
+}
+
+// Paginate fetches every item of a paged GraphQL connection, following cursors from one page to
+// the next until the connection reports that there are no more pages. The items of all pages are
+// returned in connection order. If any page fails, the pagination is abandoned and the error is
+// returned along with no items.
+//
+// For example, to collect the names of all of a user's repositories:
+//
+// 		names, err := gqlclient.Paginate(ctx, client, gqlclient.PaginateRequest[string]{
+// 			QueryStr:   reposQuery,
+// 			BaseParams: map[string]interface{}{"login": "mikebway"},
+// 			PageSize:   100,
+// 			ExtractPage: func(response *gqlclient.QueryResponse) ([]string, *gqlclient.PageInfo, error) {
+// 				var page ReposPage
+// 				err := json.Unmarshal(*response.Data.(*json.RawMessage), &page)
+// 				return page.Names(), &page.User.Repositories.PageInfo, err
+// 			},
+// 		})
+//
+func Paginate[T any](ctx context.Context, client GqlClient, req PaginateRequest[T]) ([]T, error) {
+
+	items := []T{}
+	err := walkPages(ctx, client, req, func(pageItems []T) error {
+		items = append(items, pageItems...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Result is a single item delivered by Stream(...), or the error that ended the stream.
+type Result[T any] struct {
+	Node T     // The item, if Err is nil
+	Err  error // The error that ended the stream, if any
+}
+
+// Stream fetches the items of a paged GraphQL connection lazily, delivering them one at a time on
+// the returned channel so that huge connections can be processed without holding every item in
+// memory. The next page is only requested once the items of the previous page have been consumed.
+// The query must accept an $after cursor variable, and any page size must be given in queryParms.
+// The extract function is used as described for PaginateRequest.ExtractPage.
+//
+// The channel is closed when the last page has been delivered. If a page fails, a final Result
+// carrying the error is delivered before the channel is closed. If ctx is cancelled the channel is
+// closed promptly, without an error being delivered, so callers that might cancel should check
+// ctx.Err() once the channel closes. For example:
+//
+// 		for result := range gqlclient.Stream(ctx, client, reposQuery, parms, extractRepos) {
+// 			if result.Err != nil {
+// 				return result.Err
+// 			}
+// 			process(result.Node)
+// 		}
+//
+func Stream[T any](ctx context.Context, client GqlClient, queryStr string, queryParms map[string]interface{},
+	extract func(response *QueryResponse) ([]T, *PageInfo, error)) <-chan Result[T] {
+
+	results := make(chan Result[T])
+	go func() {
+		defer close(results)
+
+		// Pass on each item as it is wanted, giving up if the caller loses interest
+		req := PaginateRequest[T]{QueryStr: queryStr, BaseParams: queryParms, ExtractPage: extract}
+		err := walkPages(ctx, client, req, func(pageItems []T) error {
+			for _, item := range pageItems {
+				select {
+				case results <- Result[T]{Node: item}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+
+		// Report any failure, unless it was the caller who brought things to a halt
+		if err != nil && ctx.Err() == nil {
+			results <- Result[T]{Err: err}
+		}
+	}()
+	return results
+}
+
+// walkPages does the work of Paginate(...) and Stream(...), fetching each page of a connection in
+// turn and passing its items to the visit function. If fetching a page or the visit function fails,
+// the walk is abandoned and the error returned.
+func walkPages[T any](ctx context.Context, client GqlClient, req PaginateRequest[T], visit func(items []T) error) error {
+
+	if req.ExtractPage == nil {
+		return errors.New("pagination requires an ExtractPage function")
+	}
+	if req.CursorVar == "" {
+		req.CursorVar = "after"
+	}
+
+	// Our own copy of the variables, to which we can add the cursor without upsetting the caller
+	queryParms := make(map[string]interface{}, len(req.BaseParams)+2)
+	for k, v := range req.BaseParams {
+		queryParms[k] = v
+	}
+	if req.PageSize > 0 {
+		queryParms["first"] = req.PageSize
+	}
+
+	// Have each page report its cost if we are to keep a running total
+	queryStr := req.QueryStr
+	if req.CostWarning != nil {
+		queryStr, _ = injectRateLimit(packQuery(&req.QueryStr))
+	}
+	totalCost, warned := 0, false
+
+	// Keep going until we run out of pages
+	for page := 1; ; page++ {
+
+		// Fetch the page, leaving the decoding to the caller's extraction function
+		response := QueryResponse{Data: new(json.RawMessage)}
+		if err := client.QueryContext(ctx, &queryStr, &queryParms, &response); err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		if err := response.Err(); err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		pageItems, pageInfo, err := req.ExtractPage(&response)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", page, err)
+		}
+		if err := visit(pageItems); err != nil {
+			return err
+		}
+
+		// Sound the alarm if the crawl has become more expensive than the caller expected
+		if req.CostWarning != nil {
+			totalCost += pageCost(&response)
+			if !warned && totalCost > req.CostThreshold {
+				warned = true
+				req.CostWarning(totalCost)
+			}
+		}
+
+		// Move on to the next page, if there is one
+		if pageInfo == nil || !pageInfo.HasNextPage {
+			return nil
+		}
+		queryParms[req.CursorVar] = pageInfo.EndCursor
+	}
+}
+
+// pageCost returns the rate limit cost reported for a page, whether the client picked it out of the
+// response itself or it was left in the raw data, or zero if no cost was reported.
+func pageCost(response *QueryResponse) int {
+	if response.RateLimitInfo != nil {
+		return response.RateLimitInfo.Cost
+	}
+	var data struct {
+		RateLimit *RateLimitInfo `json:"rateLimit"`
+	}
+	if raw, ok := response.Data.(*json.RawMessage); !ok || json.Unmarshal(*raw, &data) != nil || data.RateLimit == nil {
+		return 0
+	}
+	return data.RateLimit.Cost
+}
+
+// FindPageInfo returns the PageInfo found at the given dotted path within response data that has
+// been decoded without a typed structure, allowing the paging of any query to be driven without
+// declaring structures for it. For example, given the data of a commit history query:
+//
+// 		pageInfo, err := gqlclient.FindPageInfo(response.Data, "repository.ref.target.history.pageInfo")
+//
+// The data may be a map[string]interface{}, as decoded into a Data field left as nil, a pointer to
+// one, or a json.RawMessage or pointer to one, as used by Paginate(...). A path element that is a
+// number indexes into a list, e.g. "nodes.0.comments.pageInfo". An error naming the offending part
+// of the path is returned if the path cannot be followed or does not lead to a JSON object.
+func FindPageInfo(data interface{}, path string) (*PageInfo, error) {
+
+	// Get the data into a form that we can navigate
+	var node interface{}
+	switch d := data.(type) {
+	case *json.RawMessage:
+		if d == nil {
+			return nil, errors.New("no data to find page info in")
+		}
+		data = *d
+	case *map[string]interface{}:
+		if d == nil {
+			return nil, errors.New("no data to find page info in")
+		}
+		data = *d
+	}
+	if raw, ok := data.(json.RawMessage); ok {
+		if err := json.Unmarshal(raw, &node); err != nil {
+			return nil, err
+		}
+	} else {
+		node = data
+	}
+
+	// Follow the path one element at a time
+	walked := "data"
+	for _, element := range strings.Split(path, ".") {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[element]
+			if !ok {
+				return nil, fmt.Errorf("%s has no field %q", walked, element)
+			}
+			node = child
+		case []interface{}:
+			index, err := strconv.Atoi(element)
+			if err != nil || index < 0 || index >= len(n) {
+				return nil, fmt.Errorf("%s is a list of %d items, which %q does not index", walked, len(n), element)
+			}
+			node = n[index]
+		case nil:
+			return nil, fmt.Errorf("%s is null", walked)
+		default:
+			return nil, fmt.Errorf("%s is not an object or list", walked)
+		}
+		walked += "." + element
+	}
+
+	// Decode whatever we found into a PageInfo, which it had better resemble
+	found, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a page info object", walked)
+	}
+	encoded, err := json.Marshal(found)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo := &PageInfo{}
+	if err := json.Unmarshal(encoded, pageInfo); err != nil {
+		return nil, fmt.Errorf("%s is not a page info object: %w", walked, err)
+	}
+	return pageInfo, nil
+}
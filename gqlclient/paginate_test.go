@@ -0,0 +1,398 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for pagination.
+
+// The connection query used to exercise pagination
+var pagedQuery = `query Items($first: Int!, $after: String, $offset: Int) {
+	items(first: $first, after: $after, offset: $offset) {
+		totalCount
+		pageInfo { endCursor hasNextPage }
+		nodes
+	}
+}`
+
+// pagedData is the structure into which a page of items is decoded
+type pagedData struct {
+	Items struct {
+		TotalCount int      `json:"totalCount"`
+		PageInfo   PageInfo `json:"pageInfo"`
+		Nodes      []string `json:"nodes"`
+	} `json:"items"`
+}
+
+// extractPagedData is an ExtractPage function for pagedData
+func extractPagedData(data interface{}) ([]interface{}, *PageInfo, int, error) {
+	page := data.(*pagedData)
+	items := make([]interface{}, len(page.Items.Nodes))
+	for i, node := range page.Items.Nodes {
+		items[i] = node
+	}
+	return items, &page.Items.PageInfo, page.Items.TotalCount, nil
+}
+
+// newPagedServer returns a fake server presenting a connection of count items named item-0,
+// item-1, etc. Pages may be positioned by offset or by cursor, the cursor being the index of the
+// last item on the previous page. The maximum number of requests handled at once is recorded.
+func newPagedServer(count int, maxInFlight *int32) *httptest.Server {
+	var mutex sync.Mutex
+	var inFlight int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > *maxInFlight {
+			*maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		defer func() {
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+
+		// Dawdle a little so that concurrent requests overlap
+		time.Sleep(10 * time.Millisecond)
+
+		// Work out where the page starts
+		var q struct {
+			Variables struct {
+				First  int     `json:"first"`
+				After  *string `json:"after"`
+				Offset *int    `json:"offset"`
+			} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&q)
+		start := 0
+		if q.Variables.Offset != nil {
+			start = *q.Variables.Offset
+		} else if q.Variables.After != nil {
+			last, _ := strconv.Atoi(*q.Variables.After)
+			start = last + 1
+		}
+		end := start + q.Variables.First
+		if end > count {
+			end = count
+		}
+
+		// Build the page
+		page := pagedData{}
+		page.Items.TotalCount = count
+		page.Items.Nodes = []string{}
+		for i := start; i < end; i++ {
+			page.Items.Nodes = append(page.Items.Nodes, fmt.Sprintf("item-%d", i))
+		}
+		page.Items.PageInfo.EndCursor = strconv.Itoa(end - 1)
+		page.Items.PageInfo.HasNextPage = end < count
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": page})
+	}))
+}
+
+// expectedItems returns the items that a paged server of the given size should yield
+func expectedItems(count int) []interface{} {
+	items := make([]interface{}, count)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+	return items
+}
+
+// TestParallelPaginate confirms that pages fetched concurrently are returned in order.
+func TestParallelPaginate(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(23, &maxInFlight)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	items, err := ParallelPaginate(context.Background(), client, ParallelPageRequest{
+		QueryStr:    pagedQuery,
+		PageSize:    2,
+		Concurrency: 3,
+		OffsetParams: func(offset int) map[string]interface{} {
+			return map[string]interface{}{"offset": offset}
+		},
+		NewData:     func() interface{} { return new(pagedData) },
+		ExtractPage: extractPagedData,
+	})
+	assert.Nil(t, err, "Parallel pagination should not have failed")
+	assert.Equal(t, expectedItems(23), items, "All items should have been returned in order")
+	assert.True(t, maxInFlight > 1, "Pages should have been fetched concurrently")
+	assert.True(t, maxInFlight <= 3, "No more than three pages should have been fetched at once, not %d", maxInFlight)
+}
+
+// TestParallelPaginateSequentialFallback confirms that cursors are followed when pages cannot be
+// positioned by offset.
+func TestParallelPaginateSequentialFallback(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(7, &maxInFlight)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	items, err := ParallelPaginate(context.Background(), client, ParallelPageRequest{
+		QueryStr:    pagedQuery,
+		PageSize:    3,
+		NewData:     func() interface{} { return new(pagedData) },
+		ExtractPage: extractPagedData,
+	})
+	assert.Nil(t, err, "Sequential pagination should not have failed")
+	assert.Equal(t, expectedItems(7), items, "All items should have been returned in order")
+	assert.Equal(t, int32(1), maxInFlight, "Pages should have been fetched one at a time")
+}
+
+// TestParallelPaginateDeadline confirms that an expired deadline abandons the pagination.
+func TestParallelPaginateDeadline(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(100, &maxInFlight)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	client := CreateClient(server.URL, nil)
+	_, err := ParallelPaginate(ctx, client, ParallelPageRequest{
+		QueryStr:    pagedQuery,
+		PageSize:    1,
+		Concurrency: 1,
+		OffsetParams: func(offset int) map[string]interface{} {
+			return map[string]interface{}{"offset": offset}
+		},
+		NewData:     func() interface{} { return new(pagedData) },
+		ExtractPage: extractPagedData,
+	})
+	assert.NotNil(t, err, "Pagination should have been cut short by the deadline")
+}
+
+// extractNames is an ExtractPage function that returns the item names on a page
+func extractNames(response *QueryResponse) ([]string, *PageInfo, error) {
+	var page pagedData
+	if err := json.Unmarshal(*response.Data.(*json.RawMessage), &page); err != nil {
+		return nil, nil, err
+	}
+	return page.Items.Nodes, &page.Items.PageInfo, nil
+}
+
+// TestPaginate confirms that a generic pagination follows the cursors through three pages.
+func TestPaginate(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(7, &maxInFlight)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	names, err := Paginate(context.Background(), client, PaginateRequest[string]{
+		QueryStr:    pagedQuery,
+		PageSize:    3,
+		ExtractPage: extractNames,
+	})
+	assert.Nil(t, err, "Pagination should not have failed")
+	assert.Equal(t, []string{"item-0", "item-1", "item-2", "item-3", "item-4", "item-5", "item-6"}, names)
+}
+
+// TestPaginateAbort confirms that a failure part way through abandons the pagination.
+func TestPaginateAbort(t *testing.T) {
+
+	// Fail on the second page
+	var maxInFlight int32
+	pages := newPagedServer(7, &maxInFlight)
+	defer pages.Close()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 2 {
+			w.Write([]byte(`{"data":null,"errors":[{"message":"cursor expired"}]}`))
+			return
+		}
+		pages.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	names, err := Paginate(context.Background(), client, PaginateRequest[string]{
+		QueryStr:    pagedQuery,
+		PageSize:    3,
+		ExtractPage: extractNames,
+	})
+	assert.Nil(t, names, "No names should be returned from a failed pagination")
+	assert.NotNil(t, err, "Pagination should have failed")
+	assert.Contains(t, err.Error(), "page 2", "The error should identify the failed page")
+	assert.Contains(t, err.Error(), "cursor expired")
+	assert.Equal(t, 2, calls, "No pages should have been requested after the failure")
+}
+
+// TestPaginateCostWarning confirms that the cost of each page is asked for and totalled, and that
+// the warning is given once when the total passes the threshold.
+func TestPaginateCostWarning(t *testing.T) {
+
+	// Serve five pages of one item each, each costing three points
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q struct {
+			Query     string `json:"query"`
+			Variables struct {
+				After *string `json:"after"`
+			} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&q)
+		queries = append(queries, q.Query)
+		index := 0
+		if q.Variables.After != nil {
+			index, _ = strconv.Atoi(*q.Variables.After)
+			index++
+		}
+		fmt.Fprintf(w, `{"data":{"items":{"nodes":["item-%d"],"pageInfo":{"endCursor":"%d","hasNextPage":%t}},`+
+			`"rateLimit":{"cost":3,"remaining":%d}}}`, index, index, index < 4, 5000-3*(index+1))
+	}))
+	defer server.Close()
+
+	// Warn once the crawl costs more than seven points, i.e. on the third page
+	var warnings []int
+	client := CreateClient(server.URL, nil)
+	names, err := Paginate(context.Background(), client, PaginateRequest[string]{
+		QueryStr:      pagedQuery,
+		PageSize:      1,
+		ExtractPage:   extractNames,
+		CostThreshold: 7,
+		CostWarning:   func(total int) { warnings = append(warnings, total) },
+	})
+	assert.Nil(t, err, "Pagination should not have failed")
+	assert.Equal(t, 5, len(names), "Every page should have been fetched despite the warning")
+	assert.Equal(t, []int{9}, warnings, "The warning should have been given once, as the threshold was passed")
+	for _, query := range queries {
+		assert.Contains(t, query, "rateLimit { cost remaining }", "The cost of every page should have been asked for")
+	}
+
+	// A cheaper crawl should pass without comment, and the cost is left out of the query unless wanted
+	warnings, queries = nil, nil
+	_, err = Paginate(context.Background(), client, PaginateRequest[string]{
+		QueryStr:      pagedQuery,
+		PageSize:      1,
+		ExtractPage:   extractNames,
+		CostThreshold: 15,
+		CostWarning:   func(total int) { warnings = append(warnings, total) },
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, warnings, "A total of exactly the threshold should not have been warned of")
+	_, err = Paginate(context.Background(), client, PaginateRequest[string]{QueryStr: pagedQuery, PageSize: 1, ExtractPage: extractNames})
+	assert.Nil(t, err)
+	assert.NotContains(t, queries[len(queries)-1], "rateLimit", "No cost should have been asked for without a warning")
+}
+
+// TestStream confirms that the items of a multi-page connection are delivered one at a time.
+func TestStream(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(7, &maxInFlight)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	var names []string
+	for result := range Stream(context.Background(), client, pagedQuery, map[string]interface{}{"first": 3}, extractNames) {
+		assert.Nil(t, result.Err, "Streaming should not have failed")
+		names = append(names, result.Node)
+	}
+	assert.Equal(t, []string{"item-0", "item-1", "item-2", "item-3", "item-4", "item-5", "item-6"}, names)
+}
+
+// TestStreamFailure confirms that a failed page is delivered as a final error.
+func TestStreamFailure(t *testing.T) {
+
+	// Fail on the second page
+	var maxInFlight int32
+	pages := newPagedServer(7, &maxInFlight)
+	defer pages.Close()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 2 {
+			w.Write([]byte(`{"data":null,"errors":[{"message":"cursor expired"}]}`))
+			return
+		}
+		pages.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	var results []Result[string]
+	for result := range Stream(context.Background(), client, pagedQuery, map[string]interface{}{"first": 3}, extractNames) {
+		results = append(results, result)
+	}
+	assert.Equal(t, 4, len(results), "The first page and the error should have been delivered")
+	assert.Equal(t, "item-2", results[2].Node)
+	assert.NotNil(t, results[3].Err, "The last result should carry the error")
+	assert.Contains(t, results[3].Err.Error(), "cursor expired")
+}
+
+// TestStreamCancel confirms that cancelling the context closes the channel without fetching the
+// rest of the connection.
+func TestStreamCancel(t *testing.T) {
+
+	var maxInFlight int32
+	server := newPagedServer(100, &maxInFlight)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := CreateClient(server.URL, nil)
+	count := 0
+	for result := range Stream(ctx, client, pagedQuery, map[string]interface{}{"first": 3}, extractNames) {
+		assert.Nil(t, result.Err, "No error should be delivered on cancellation")
+		count++
+		if count == 2 {
+			cancel()
+		}
+	}
+	assert.True(t, count < 10, "Streaming should have stopped soon after cancellation, not after %d items", count)
+}
+
+// TestFindPageInfo confirms that page info can be found in generically decoded data, in each of the
+// forms that the data may take, and that unfollowable paths are reported.
+func TestFindPageInfo(t *testing.T) {
+
+	body := []byte(`{"repository":{"ref":{"target":{"history":{
+		"pageInfo":{"endCursor":"Y3Vyc29yOjEw","hasNextPage":true},
+		"nodes":[{"comments":{"pageInfo":{"endCursor":"abc","hasNextPage":false}}}]}}}}}`)
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal(body, &decoded))
+	raw := json.RawMessage(body)
+
+	expected := &PageInfo{EndCursor: "Y3Vyc29yOjEw", HasNextPage: true}
+	for _, data := range []interface{}{decoded, &decoded, raw, &raw} {
+		pageInfo, err := FindPageInfo(data, "repository.ref.target.history.pageInfo")
+		assert.Nil(t, err, "Page info should have been found in %T", data)
+		assert.Equal(t, expected, pageInfo, "Page info should have been decoded from %T", data)
+	}
+
+	// Lists can be indexed
+	pageInfo, err := FindPageInfo(decoded, "repository.ref.target.history.nodes.0.comments.pageInfo")
+	assert.Nil(t, err)
+	assert.Equal(t, &PageInfo{EndCursor: "abc"}, pageInfo)
+
+	// Paths that go astray are reported
+	failures := map[string]string{
+		"repository.ref.target.story.pageInfo":               `data.repository.ref.target has no field "story"`,
+		"repository.ref.target.history.nodes.1.x":            `data.repository.ref.target.history.nodes is a list of 1 items, which "1" does not index`,
+		"repository.ref.target.history.pageInfo.x":           `data.repository.ref.target.history.pageInfo has no field "x"`,
+		"repository.ref.target.history.nodes":                `data.repository.ref.target.history.nodes is not a page info object`,
+		"repository.ref.target.history.pageInfo.endCursor.z": `data.repository.ref.target.history.pageInfo.endCursor is not an object or list`,
+	}
+	for path, message := range failures {
+		_, err := FindPageInfo(decoded, path)
+		assert.EqualError(t, err, message, "Path %s", path)
+	}
+	_, err = FindPageInfo(map[string]interface{}{"repository": nil}, "repository.ref")
+	assert.EqualError(t, err, "data.repository is null")
+}
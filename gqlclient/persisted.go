@@ -0,0 +1,82 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoPersistenceStore is returned by QueryByID(...) if the client was not given a PersistenceStore
+// with WithQueryPersistenceStore(...).
+var ErrNoPersistenceStore = errors.New("no query persistence store has been configured")
+
+// ErrQueryNotRegistered is returned, wrapped in an ErrQueryNotFound naming the offending ID, by
+// QueryByID(...) if no query has been registered under the requested ID.
+var ErrQueryNotRegistered = errors.New("no query registered with ID")
+
+// PersistenceStore is a registry of query strings, keyed by ID, from which QueryByID(...) obtains
+// the queries it sends. Deployments in which queries must be registered in advance, e.g. behind an
+// edge proxy that only passes known queries, can share one store between the code that registers
+// the queries and the code that runs them.
+type PersistenceStore interface {
+	// GetQuery returns the query registered under the given ID, and whether there was one.
+	GetQuery(id string) (string, bool)
+
+	// RegisterQuery records the query under the given ID.
+	RegisterQuery(id, queryStr string) error
+}
+
+// WithQueryPersistenceStore is a ClientOption that supplies the PersistenceStore from which
+// QueryByID(...) obtains its queries.
+func WithQueryPersistenceStore(store PersistenceStore) ClientOption {
+	return func(gc *gqlClient) {
+		gc.persisted = store
+	}
+}
+
+// QueryByID looks up the query registered under the given ID in the client's PersistenceStore and
+// sends it as QueryContext(...) would. ErrNoPersistenceStore is returned if the client has no store,
+// and an ErrQueryNotFound wrapping ErrQueryNotRegistered if the ID is unknown to it.
+func (gc *gqlClient) QueryByID(ctx context.Context, queryID string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	if gc.persisted == nil {
+		return ErrNoPersistenceStore
+	}
+	return gc.sendStoredQuery(ctx, QueryStoreFunc(gc.persisted.GetQuery), queryID, ErrQueryNotRegistered, queryParms, response)
+}
+
+// memoryStore is the PersistenceStore returned by InMemoryPersistenceStore().
+type memoryStore struct {
+	mutex   sync.RWMutex      // Guards the map of queries
+	queries map[string]string // The registered queries, keyed by ID
+}
+
+// InMemoryPersistenceStore returns an empty PersistenceStore that holds its queries in memory. It is
+// safe for concurrent use. Registering a second, different, query under an ID that is already in use
+// is refused with an error; registering the same query again is not.
+func InMemoryPersistenceStore() PersistenceStore {
+	return &memoryStore{queries: make(map[string]string)}
+}
+
+// GetQuery returns the query registered under the given ID, and whether there was one.
+func (s *memoryStore) GetQuery(id string) (string, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	queryStr, ok := s.queries[id]
+	return queryStr, ok
+}
+
+// RegisterQuery records the query under the given ID, unless the ID is empty or already taken by a
+// different query.
+func (s *memoryStore) RegisterQuery(id, queryStr string) error {
+	if id == "" {
+		return errors.New("queries cannot be registered with an empty ID")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if existing, ok := s.queries[id]; ok && existing != queryStr {
+		return fmt.Errorf("a different query is already registered with ID %s", id)
+	}
+	s.queries[id] = queryStr
+	return nil
+}
@@ -0,0 +1,68 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for queries sent by their registered ID.
+
+// TestQueryByID confirms that a registered query is looked up and sent in full.
+func TestQueryByID(t *testing.T) {
+
+	// Record the body of each request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	store := InMemoryPersistenceStore()
+	assert.Nil(t, store.RegisterQuery("repo-info", SimpleRepoDataQuery), "Registration should have succeeded")
+	client := CreateClient(server.URL, nil, WithQueryPersistenceStore(store))
+
+	queryParms := map[string]interface{}{"owner": "mikebway", "name": "gogql"}
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	err := client.QueryByID(context.Background(), "repo-info", &queryParms, &response)
+	assert.Nil(t, err, "Query by ID should have succeeded")
+	assert.Contains(t, body, `"query":"`+packQuery(&SimpleRepoDataQuery)+`"`, "The registered query should have been sent")
+	assert.Contains(t, body, `"owner":"mikebway"`, "The variables should have been sent")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+
+	// An unknown ID goes nowhere
+	body = ""
+	err = client.QueryByID(context.Background(), "no-such-query", nil, &QueryResponse{})
+	assert.True(t, errors.Is(err, ErrQueryNotRegistered), "An unknown ID should have been reported")
+	assert.Contains(t, err.Error(), "no-such-query")
+	assert.Empty(t, body, "Nothing should have been sent")
+
+	// As does any ID without a store
+	client = CreateClient(server.URL, nil)
+	err = client.QueryByID(context.Background(), "repo-info", nil, &QueryResponse{})
+	assert.Equal(t, ErrNoPersistenceStore, err)
+}
+
+// TestInMemoryPersistenceStore confirms the registration rules of the in memory store.
+func TestInMemoryPersistenceStore(t *testing.T) {
+
+	store := InMemoryPersistenceStore()
+	_, ok := store.GetQuery("viewer")
+	assert.False(t, ok, "Nothing should be registered yet")
+
+	assert.Nil(t, store.RegisterQuery("viewer", "{ viewer { login } }"))
+	assert.Nil(t, store.RegisterQuery("viewer", "{ viewer { login } }"), "Registering the same query again is harmless")
+	assert.NotNil(t, store.RegisterQuery("viewer", "{ viewer { name } }"), "A conflicting registration should be refused")
+	assert.NotNil(t, store.RegisterQuery("", "{ viewer { name } }"), "An empty ID should be refused")
+
+	queryStr, ok := store.GetQuery("viewer")
+	assert.True(t, ok)
+	assert.Equal(t, "{ viewer { login } }", queryStr, "The original registration should stand")
+}
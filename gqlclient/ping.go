@@ -0,0 +1,108 @@
+package gqlclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// PingFailure is the category of problem that stopped Ping(...) from reaching a GraphQL server.
+type PingFailure string
+
+// The categories of problem reported by Ping(...).
+const (
+	PingDNSFailure        PingFailure = "host name could not be resolved"
+	PingConnectionFailure PingFailure = "could not connect to server"
+	PingTLSFailure        PingFailure = "TLS handshake failed"
+	PingUnauthorized      PingFailure = "authorization was refused"
+	PingServerFailure     PingFailure = "server reported an error"
+	PingNotGraphQL        PingFailure = "server is not a GraphQL endpoint"
+)
+
+// PingError is the error returned by Ping(...), saying what kind of problem was found as well as
+// giving the underlying error.
+type PingError struct {
+	URL     string      // The URL that was pinged
+	Failure PingFailure // The category of the problem
+	Err     error       // The error that revealed the problem
+}
+
+// Error describes the problem in terms a newcomer can act on.
+func (e PingError) Error() string {
+	return fmt.Sprintf("GraphQL endpoint %s: %s: %v", e.URL, e.Failure, e.Err)
+}
+
+// Unwrap returns the error that revealed the problem.
+func (e PingError) Unwrap() error {
+	return e.Err
+}
+
+// pingQuery is the least that can be asked of any GraphQL server.
+var pingQuery = "{ __typename }"
+
+// Ping checks, before any real queries are sent, that there is a GraphQL server at the given URL
+// that accepts the given authorization, which may be nil, by sending it the query "{ __typename }".
+// If anything is wrong, a PingError is returned saying whether the host name could not be resolved,
+// no connection could be made, the TLS handshake failed, the authorization was refused, the server
+// reported an error, or whatever answered was not a GraphQL server, e.g. because the URL has the
+// wrong path. GraphQL errors reported by a working server are returned as they are.
+func Ping(url string, authorization *string) error {
+
+	// Ask the server for the type name of its query root, which every GraphQL server can answer
+	var data struct {
+		Typename string `json:"__typename"`
+	}
+	response := QueryResponse{Data: &data}
+	err := CreateClient(url, authorization).Query(&pingQuery, nil, &response)
+	if err == nil {
+		if err := response.Err(); err != nil {
+			return err
+		}
+		if data.Typename == "" {
+			return PingError{URL: url, Failure: PingNotGraphQL, Err: errors.New("response did not answer the query")}
+		}
+		return nil
+	}
+
+	// Work out what went wrong, from the most specific problem to the most general
+	return PingError{URL: url, Failure: pingFailure(err), Err: err}
+}
+
+// pingFailure categorizes the error returned by a ping query.
+func pingFailure(err error) PingFailure {
+	var dnsErr *net.DNSError
+	var authErr AuthError
+	var statusErr StatusError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		return PingDNSFailure
+	case isTLSError(err):
+		return PingTLSFailure
+	case errors.As(err, &authErr):
+		return PingUnauthorized
+	case errors.As(err, &statusErr) && statusErr.StatusCode >= 500:
+		return PingServerFailure
+	case errors.As(err, &statusErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, ErrEmptyResponse):
+		return PingNotGraphQL
+	case errors.As(err, &netErr):
+		return PingConnectionFailure
+	}
+	return PingConnectionFailure
+}
+
+// isTLSError returns true if the error arose from a failed TLS handshake or certificate check.
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
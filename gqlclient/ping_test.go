@@ -0,0 +1,91 @@
+package gqlclient
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the Ping(...) preflight check.
+
+// TestPingReachable confirms that a working GraphQL server passes the check.
+func TestPingReachable(t *testing.T) {
+
+	// A server that answers the ping query, provided it is given the right token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+	}))
+	defer server.Close()
+
+	token := "Bearer good"
+	assert.Nil(t, Ping(server.URL, &token), "The server should have been reachable")
+
+	// The wrong token is reported as such
+	token = "Bearer bad"
+	err := Ping(server.URL, &token)
+	var pingErr PingError
+	assert.True(t, errors.As(err, &pingErr), "A PingError should have been returned")
+	assert.Equal(t, PingUnauthorized, pingErr.Failure, "The token should have been refused")
+	assert.Equal(t, server.URL, pingErr.URL, "The URL should have been reported")
+	var authErr AuthError
+	assert.True(t, errors.As(err, &authErr), "The underlying error should have been kept")
+}
+
+// TestPingUnreachable confirms that servers that cannot be reached, or that are not GraphQL servers,
+// are reported as such.
+func TestPingUnreachable(t *testing.T) {
+
+	// Find a port that nothing is listening on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	// A web server that is not a GraphQL server
+	webServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>Welcome</body></html>"))
+	}))
+	defer webServer.Close()
+
+	// A server at the wrong path
+	missingServer := httptest.NewServer(http.NotFoundHandler())
+	defer missingServer.Close()
+
+	// A GraphQL server with a certificate we do not trust
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+	}))
+	defer tlsServer.Close()
+
+	// A server that is failing
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failingServer.Close()
+
+	for _, test := range []struct {
+		url     string
+		failure PingFailure
+	}{
+		{closedURL, PingConnectionFailure},
+		{"http://gogql-ping-test.invalid", PingDNSFailure},
+		{webServer.URL, PingNotGraphQL},
+		{missingServer.URL, PingNotGraphQL},
+		{tlsServer.URL, PingTLSFailure},
+		{failingServer.URL, PingServerFailure},
+	} {
+		err := Ping(test.url, nil)
+		var pingErr PingError
+		assert.True(t, errors.As(err, &pingErr), "A PingError should have been returned for %s: %v", test.url, err)
+		assert.Equal(t, test.failure, pingErr.Failure, "Wrong failure reported for %s: %v", test.url, err)
+	}
+}
@@ -0,0 +1,117 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// ClientPool is a GqlClient that spreads queries across a fixed number of independent clients,
+// each with its own HTTP transport and therefore its own pool of connections. High throughput
+// services can use a ClientPool to overcome per-host connection limits. Because ClientPool
+// implements GqlClient, it can be used as a drop in replacement for a single client.
+type ClientPool struct {
+	clients []GqlClient // The pooled clients
+	next    uint32      // Counter used to select clients in round-robin order
+}
+
+// NewClientPool returns a ClientPool of size clients, all configured with the same target URL,
+// authorization and options as would be passed to CreateClient(...). A size of less than one is
+// treated as one.
+func NewClientPool(size int, targetURL string, authorization *string, opts ...ClientOption) *ClientPool {
+
+	if size < 1 {
+		size = 1
+	}
+	pool := &ClientPool{clients: make([]GqlClient, size)}
+	for i := range pool.clients {
+
+		// Give each client its own transport so that they do not share connections. Any
+		// options that replace the http.Client are applied afterwards and so take precedence.
+		isolate := func(gc *gqlClient) {
+			gc.httpClient = &http.Client{
+				Timeout:   httpClient.Timeout,
+				Transport: http.DefaultTransport.(*http.Transport).Clone(),
+			}
+		}
+		pool.clients[i] = CreateClient(targetURL, authorization, append([]ClientOption{isolate}, opts...)...)
+	}
+	return pool
+}
+
+// Get returns the next client from the pool in round-robin order.
+func (p *ClientPool) Get() GqlClient {
+	n := atomic.AddUint32(&p.next, 1) - 1
+	return p.clients[n%uint32(len(p.clients))]
+}
+
+// Query sends the query using the next client from the pool. See GqlClient.Query(...).
+func (p *ClientPool) Query(queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().Query(queryStr, queryParms, response)
+}
+
+// QueryContext sends the query using the next client from the pool. See GqlClient.QueryContext(...).
+func (p *ClientPool) QueryContext(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().QueryContext(ctx, queryStr, queryParms, response)
+}
+
+// UploadQuery sends the upload using the next client from the pool. See GqlClient.UploadQuery(...).
+func (p *ClientPool) UploadQuery(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().UploadQuery(ctx, queryStr, queryParms, response)
+}
+
+// QueryDeferred sends the query using the next client from the pool. See GqlClient.QueryDeferred(...).
+func (p *ClientPool) QueryDeferred(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, handler func(*QueryResponse) error) error {
+	return p.Get().QueryDeferred(ctx, queryStr, queryParms, handler)
+}
+
+// QueryByID sends the registered query using the next client from the pool. See GqlClient.QueryByID(...).
+func (p *ClientPool) QueryByID(ctx context.Context, queryID string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().QueryByID(ctx, queryID, queryParms, response)
+}
+
+// PrepareQuery prepares the query with every client in the pool, returning any errors they report.
+// See GqlClient.PrepareQuery(...).
+func (p *ClientPool) PrepareQuery(name, queryStr string) error {
+	var errs []error
+	for _, client := range p.clients {
+		if err := client.PrepareQuery(name, queryStr); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// QueryPrepared sends the prepared query using the next client from the pool. See
+// GqlClient.QueryPrepared(...).
+func (p *ClientPool) QueryPrepared(ctx context.Context, name string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().QueryPrepared(ctx, name, queryParms, response)
+}
+
+// NamedQuery sends the named query using the next client from the pool. See GqlClient.NamedQuery(...).
+func (p *ClientPool) NamedQuery(ctx context.Context, name string, store QueryStore, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return p.Get().NamedQuery(ctx, name, store, queryParms, response)
+}
+
+// Transaction runs the transaction using the next client from the pool for all of its mutations.
+// See GqlClient.Transaction(...).
+func (p *ClientPool) Transaction(ctx context.Context, fn func(tx TxClient) error) error {
+	return p.Get().Transaction(ctx, fn)
+}
+
+// Close closes every client in the pool, returning any errors they report. See GqlClient.Close().
+func (p *ClientPool) Close() error {
+	var errs []error
+	for _, client := range p.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetTargetURL returns the target API URL shared by all of the pooled clients.
+func (p *ClientPool) GetTargetURL() string {
+	return p.clients[0].GetTargetURL()
+}
@@ -0,0 +1,55 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the client pool.
+
+// TestClientPoolRoundRobin confirms that queries are spread evenly across the pooled clients.
+func TestClientPoolRoundRobin(t *testing.T) {
+
+	// Count the requests received from each client
+	var mutex sync.Mutex
+	counts := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		counts[r.Header.Get("X-Client-ID")]++
+		mutex.Unlock()
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// This option is applied once per pooled client, giving each a hook that tags its
+	// requests with a different ID
+	clientID := 0
+	tagClient := func(gc *gqlClient) {
+		clientID++
+		id := strconv.Itoa(clientID)
+		WithRequestHook(func(req *http.Request) error {
+			req.Header.Set("X-Client-ID", id)
+			return nil
+		})(gc)
+	}
+
+	pool := NewClientPool(3, server.URL, nil, tagClient)
+	assert.Equal(t, server.URL, pool.GetTargetURL(), "Pool should report the shared target URL")
+
+	// Each of the three clients should be used three times
+	for i := 0; i < 9; i++ {
+		err := pool.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+		assert.Nil(t, err, "Pooled query should not have failed")
+	}
+	assert.Equal(t, map[string]int{"1": 3, "2": 3, "3": 3}, counts, "Each client should have been used exactly three times")
+
+	// The clients should not share a transport
+	first := pool.clients[0].(*gqlClient).httpClient
+	second := pool.clients[1].(*gqlClient).httpClient
+	assert.False(t, first.Transport == second.Transport, "Pooled clients should have isolated transports")
+}
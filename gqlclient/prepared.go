@@ -0,0 +1,106 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQueryNotPrepared is returned, wrapped in an ErrQueryNotFound naming the offending name, by
+// QueryPrepared(...) if no query has been prepared under the requested name.
+var ErrQueryNotPrepared = errors.New("no query prepared with name")
+
+// preparedContextKey is the type of the context key under which QueryPrepared(...) hands the
+// preparedQuery it is sending to prepareQuery(...) and encodeQuery(...), so that they need neither
+// pack the query again nor work out its operations.
+type preparedContextKey struct{}
+
+// preparedQuery is a query prepared by PrepareQuery(...), packed and with its operations worked out.
+type preparedQuery struct {
+	packed string        // The packed query
+	info   *documentInfo // The names and types of the operations it defines
+}
+
+// preparedQueries is the cache of packed queries kept by a client for QueryPrepared(...).
+type preparedQueries struct {
+	mutex   sync.RWMutex              // Guards the map of queries
+	queries map[string]*preparedQuery // The prepared queries, keyed by name
+}
+
+// newPreparedQueries returns an empty cache of prepared queries.
+func newPreparedQueries() *preparedQueries {
+	return &preparedQueries{queries: make(map[string]*preparedQuery)}
+}
+
+// copy returns a new cache holding the same queries. The queries themselves are never changed once
+// prepared, so they are shared rather than copied.
+func (p *preparedQueries) copy() *preparedQueries {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	c := newPreparedQueries()
+	for name, prepared := range p.queries {
+		c.queries[name] = prepared
+	}
+	return c
+}
+
+// PrepareQuery packs a query once, for services that send the same queries over and over, and keeps
+// it under the given name, usually its operation name, to be sent by QueryPrepared(...) without the
+// work of packing it again. Preparing the same query under a name again does no harm, but an error
+// is returned if the name is empty or a different query has already been prepared under it.
+func (gc *gqlClient) PrepareQuery(name, queryStr string) error {
+
+	if name == "" {
+		return errors.New("prepared queries must be given a name")
+	}
+	packed := packQuery(&queryStr)
+
+	gc.prepared.mutex.Lock()
+	defer gc.prepared.mutex.Unlock()
+	if existing, ok := gc.prepared.queries[name]; ok {
+		if existing.packed != packed {
+			return fmt.Errorf("a different query has already been prepared as %q", name)
+		}
+		return nil
+	}
+
+	// Work out the operations now too, so that sending the query need not
+	gc.prepared.queries[name] = &preparedQuery{packed: packed, info: analyzeDocument(packed)}
+	return nil
+}
+
+// QueryPrepared sends the query prepared under the given name by PrepareQuery(...) as
+// QueryContext(...) would, but without packing it again. An ErrQueryNotFound wrapping
+// ErrQueryNotPrepared is returned if no query has been prepared under the name.
+func (gc *gqlClient) QueryPrepared(ctx context.Context, name string, queryParms *map[string]interface{}, response *QueryResponse) error {
+	return gc.sendStoredQuery(ctx, gc.prepared, name, ErrQueryNotPrepared, queryParms, response)
+}
+
+// LookupQuery returns the packed query prepared under the given name, and whether there was one,
+// making the cache a QueryStore.
+func (p *preparedQueries) LookupQuery(name string) (string, bool) {
+	prepared, ok := p.lookupPrepared(name)
+	if !ok {
+		return "", false
+	}
+	return prepared.packed, true
+}
+
+// lookupPrepared returns the query prepared under the given name, and whether there was one.
+func (p *preparedQueries) lookupPrepared(name string) (*preparedQuery, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	prepared, ok := p.queries[name]
+	return prepared, ok
+}
+
+// documentInfo returns what is known of the operations defined by the packed query document: that
+// worked out by PrepareQuery(...) if the document is a prepared query that is still as prepared, and
+// otherwise that found by documentInfoOf(...).
+func (gc *gqlClient) documentInfo(ctx context.Context, packed string) *documentInfo {
+	if prepared, ok := ctx.Value(preparedContextKey{}).(*preparedQuery); ok && prepared.packed == packed {
+		return prepared.info
+	}
+	return documentInfoOf(packed)
+}
@@ -0,0 +1,122 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests and benchmarks for prepared queries.
+
+// TestQueryPrepared confirms that a prepared query is sent packed, that names cannot be reused for
+// different queries, and that clones and pools have the queries too.
+func TestQueryPrepared(t *testing.T) {
+
+	// Record the body of each request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil)
+	assert.Nil(t, client.PrepareQuery("FetchRepoInfo", SimpleRepoDataQuery), "Preparation should have succeeded")
+	assert.Nil(t, client.PrepareQuery("FetchRepoInfo", SimpleRepoDataQuery), "Preparing the same query again should be harmless")
+	assert.NotNil(t, client.PrepareQuery("FetchRepoInfo", "{ viewer { login } }"), "A name should not be reused for a different query")
+	assert.NotNil(t, client.PrepareQuery("", SimpleRepoDataQuery), "A query should not be prepared without a name")
+
+	// The operations are worked out as the query is prepared, and used as long as it is sent unchanged
+	prepared, ok := client.(*gqlClient).prepared.lookupPrepared("FetchRepoInfo")
+	assert.True(t, ok, "The query should have been prepared")
+	assert.Equal(t, []string{"FetchRepoInfo"}, prepared.info.names)
+	ctx := context.WithValue(context.Background(), preparedContextKey{}, prepared)
+	assert.Same(t, prepared.info, client.(*gqlClient).documentInfo(ctx, prepared.packed), "The prepared operations should have been used")
+	assert.NotSame(t, prepared.info, client.(*gqlClient).documentInfo(ctx, "{ viewer { login } }"), "A swapped query should be worked out afresh")
+
+	queryParms := map[string]interface{}{"owner": "mikebway", "name": "gogql"}
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, client.QueryPrepared(context.Background(), "FetchRepoInfo", &queryParms, &response))
+	assert.Contains(t, body, `"query":"`+packQuery(&SimpleRepoDataQuery)+`"`, "The packed query should have been sent")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+
+	// An unknown name goes nowhere
+	body = ""
+	err := client.QueryPrepared(context.Background(), "FetchViewer", nil, &QueryResponse{})
+	assert.True(t, errors.Is(err, ErrQueryNotPrepared), "An unknown name should have been reported")
+	assert.Contains(t, err.Error(), "FetchViewer")
+	assert.Empty(t, body, "Nothing should have been sent")
+
+	// A clone starts with the queries of the original, but keeps any more to itself
+	clone, _ := Clone(client)
+	assert.Nil(t, clone.QueryPrepared(context.Background(), "FetchRepoInfo", &queryParms, &QueryResponse{}))
+	assert.Nil(t, clone.PrepareQuery("FetchViewer", "{ viewer { login } }"))
+	err = client.QueryPrepared(context.Background(), "FetchViewer", nil, &QueryResponse{})
+	assert.True(t, errors.Is(err, ErrQueryNotPrepared), "The clone's query should not have reached the original")
+
+	// A pool prepares the query with every client
+	pool := NewClientPool(3, server.URL, nil)
+	assert.Nil(t, pool.PrepareQuery("FetchRepoInfo", SimpleRepoDataQuery))
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, pool.QueryPrepared(context.Background(), "FetchRepoInfo", &queryParms, &QueryResponse{}))
+	}
+}
+
+// TestQueryPreparedMiddleware confirms that a query swapped by middleware is packed as usual.
+func TestQueryPreparedMiddleware(t *testing.T) {
+
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	replacement := "query Replacement {\n\tviewer {\n\t\tlogin\n\t}\n}"
+	client := CreateClient(server.URL, nil, WithMiddleware(func(next QueryFunc) QueryFunc {
+		return func(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+			return next(ctx, &replacement, queryParms, response)
+		}
+	}))
+	assert.Nil(t, client.PrepareQuery("FetchRepoInfo", SimpleRepoDataQuery))
+	assert.Nil(t, client.QueryPrepared(context.Background(), "FetchRepoInfo", nil, &QueryResponse{}))
+	assert.Contains(t, body, `"query":"query Replacement { viewer { login } }"`, "The replacement should have been packed")
+}
+
+// benchmarkQuery is a query large enough for the cost of packing it to show.
+var benchmarkQuery = "query Benchmark {\n" + strings.Repeat("\trepository(owner: \"mikebway\", name: \"gogql\") {\n\t\tname\n\t\tdescription\n\t}\n", 200) + "}"
+
+// BenchmarkQueryPrepared compares sending a prepared query with packing the query on every call. The
+// responses are supplied by a ResponseHook so that the network does not drown out the difference.
+func BenchmarkQueryPrepared(b *testing.B) {
+
+	client := CreateClient("http://localhost/graphql", nil, WithResponseHook(func(req *http.Request) (*http.Response, bool) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"data":{}}`))}, true
+	}))
+	if err := client.PrepareQuery("Benchmark", benchmarkQuery); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("prepared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := client.QueryPrepared(context.Background(), "Benchmark", nil, &QueryResponse{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("packed per call", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if err := client.QueryContext(context.Background(), &benchmarkQuery, nil, &QueryResponse{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
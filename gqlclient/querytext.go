@@ -0,0 +1,304 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// This file contains a deliberately small lexical analyser for GraphQL documents. It is not a
+// full parser, it knows just enough about the GraphQL grammar to find its way around operation
+// definitions and selection sets without being fooled by string literals, comments or the object
+// values that can appear in arguments.
+
+// tokenKind identifies the kind of a lexical token.
+type tokenKind int
+
+const (
+	punctuatorToken tokenKind = iota // One of ! $ & ( ) ... : = @ [ ] { | }
+	nameToken                        // A name, e.g. query, repository, or FetchRepoInfo
+	valueToken                       // A number or string literal
+)
+
+// token is a single lexical token within a GraphQL document.
+type token struct {
+	kind tokenKind // The kind of token
+	text string    // The text of the token as it appears in the document
+	pos  int       // The byte offset of the start of the token in the document
+}
+
+// tokenize splits a GraphQL document into lexical tokens, discarding whitespace, commas and
+// comments. An error is returned if the document contains an unterminated string or a character
+// that cannot appear in GraphQL.
+func tokenize(doc string) ([]token, error) {
+
+	var tokens []token
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+
+		// Whitespace and commas are insignificant
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+
+		// Comments run to the end of the line
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' && doc[i] != '\r' {
+				i++
+			}
+
+		// The spread operator is the only multi-character punctuator
+		case c == '.':
+			if !strings.HasPrefix(doc[i:], "...") {
+				return nil, fmt.Errorf("unexpected '.' at offset %d", i)
+			}
+			tokens = append(tokens, token{punctuatorToken, "...", i})
+			i += 3
+
+		case strings.IndexByte("!$&()[]{}:=@|", c) >= 0:
+			tokens = append(tokens, token{punctuatorToken, doc[i : i+1], i})
+			i++
+
+		// Block strings run until the closing triple quote
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := i + 3
+			for ; end < len(doc); end++ {
+				if strings.HasPrefix(doc[end:], `\"""`) {
+					end += 3
+				} else if strings.HasPrefix(doc[end:], `"""`) {
+					break
+				}
+			}
+			if end >= len(doc) {
+				return nil, fmt.Errorf("unterminated block string at offset %d", i)
+			}
+			tokens = append(tokens, token{valueToken, doc[i : end+3], i})
+			i = end + 3
+
+		// Ordinary strings may contain escaped quotes but not line breaks
+		case c == '"':
+			end := i + 1
+			for ; end < len(doc) && doc[end] != '"'; end++ {
+				if doc[end] == '\\' {
+					end++
+				} else if doc[end] == '\n' || doc[end] == '\r' {
+					break
+				}
+			}
+			if end >= len(doc) || doc[end] != '"' {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{valueToken, doc[i : end+1], i})
+			i = end + 1
+
+		case isNameStart(c):
+			end := i + 1
+			for end < len(doc) && (isNameStart(doc[end]) || isDigit(doc[end])) {
+				end++
+			}
+			tokens = append(tokens, token{nameToken, doc[i:end], i})
+			i = end
+
+		// Numbers are a loose affair: anything that starts like one and continues with digits,
+		// signs, decimal points or exponent markers
+		case c == '-' || isDigit(c):
+			end := i + 1
+			for end < len(doc) && (isDigit(doc[end]) || strings.IndexByte(".eE+-", doc[end]) >= 0) {
+				end++
+			}
+			tokens = append(tokens, token{valueToken, doc[i:end], i})
+			i = end
+
+		default:
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return tokens, nil
+}
+
+// isNameStart returns true if the character may begin a GraphQL name.
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit returns true for the characters 0 through 9.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// packDocument returns a GraphQL document packed, as by packQuery(...), but without its comments.
+// Comments can only appear in the gaps between tokens; they are cut out before packing, lest the
+// first one swallow everything after it once the line breaks are gone. An error is returned if the
+// document cannot be tokenized.
+func packDocument(doc string) (string, error) {
+
+	tokens, err := tokenize(doc)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	gapStart := 0
+	for _, t := range append(tokens, token{pos: len(doc)}) {
+		gap := doc[gapStart:t.pos]
+		if hash := strings.IndexByte(gap, '#'); hash >= 0 {
+			for _, line := range strings.Split(gap, "\n") {
+				if hash := strings.IndexByte(line, '#'); hash >= 0 {
+					line = line[:hash]
+				}
+				b.WriteString(line + "\n")
+			}
+		} else {
+			b.WriteString(gap)
+		}
+		b.WriteString(t.text)
+		gapStart = t.pos + len(t.text)
+	}
+	stripped := b.String()
+	return packQuery(&stripped), nil
+}
+
+// definition describes one top level definition, i.e. an operation or a fragment, found
+// within a GraphQL document.
+type definition struct {
+	keyword   string // query, mutation, subscription or fragment; query for the shorthand form
+	name      string // The operation or fragment name, empty for anonymous operations
+	start     int    // The index, within the token slice, of the first token of the definition
+	openBrace int    // The index, within the token slice, of the opening brace of the selection set
+	endBrace  int    // The index, within the token slice, of the closing brace of the selection set
+}
+
+// errUnbalanced is reported for documents with mismatched braces or parentheses.
+var errUnbalanced = errors.New("unbalanced braces or parentheses in GraphQL document")
+
+// definitions splits a tokenized GraphQL document into its top level definitions, returning an
+// error if the document is not at least superficially well formed.
+func definitions(tokens []token) ([]definition, error) {
+
+	var defs []definition
+	for i := 0; i < len(tokens); {
+
+		// Each definition begins with a keyword or, for shorthand queries, an opening brace
+		def := definition{keyword: "query", start: i}
+		t := tokens[i]
+		if t.kind == nameToken {
+			switch t.text {
+			case "query", "mutation", "subscription", "fragment":
+				def.keyword = t.text
+			default:
+				return nil, fmt.Errorf("unexpected %q at offset %d, expected an operation or fragment", t.text, t.pos)
+			}
+			i++
+			if i < len(tokens) && tokens[i].kind == nameToken {
+				def.name = tokens[i].text
+				i++
+			}
+		} else if t.text != "{" {
+			return nil, fmt.Errorf("unexpected %q at offset %d, expected an operation or fragment", t.text, t.pos)
+		}
+
+		// Skip over variable definitions, type conditions and directives to reach the selection
+		// set, paying attention to parentheses so that object values are not mistaken for it
+		parens := 0
+		for ; i < len(tokens); i++ {
+			switch tokens[i].text {
+			case "(":
+				parens++
+			case ")":
+				parens--
+				if parens < 0 {
+					return nil, errUnbalanced
+				}
+			}
+			if parens == 0 && tokens[i].text == "{" && tokens[i].kind == punctuatorToken {
+				break
+			}
+		}
+		if i >= len(tokens) {
+			return nil, errors.New("GraphQL definition has no selection set")
+		}
+		def.openBrace = i
+
+		// Find the matching closing brace
+		end, err := matchBrace(tokens, i)
+		if err != nil {
+			return nil, err
+		}
+		def.endBrace = end
+		defs = append(defs, def)
+		i = end + 1
+	}
+	if len(defs) == 0 {
+		return nil, errors.New("GraphQL document contains no definitions")
+	}
+	return defs, nil
+}
+
+// matchBrace returns the index of the punctuator token that closes the brace at tokens[open].
+func matchBrace(tokens []token, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].kind != punctuatorToken {
+			continue
+		}
+		switch tokens[i].text {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+			if depth == 0 {
+				if tokens[i].text != "}" {
+					return 0, errUnbalanced
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, errUnbalanced
+}
+
+// hasTopLevelField returns true if the selection set bounded by the given brace token indices
+// directly selects the named field.
+func hasTopLevelField(tokens []token, open, end int, field string) bool {
+	depth := 0
+	for i := open + 1; i < end; i++ {
+		t := tokens[i]
+		if t.kind == punctuatorToken {
+			switch t.text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				depth--
+			}
+		} else if depth == 0 && t.kind == nameToken && t.text == field {
+			return true
+		}
+	}
+	return false
+}
+
+// injectTopLevelField adds the given selection text to the top level selection set of every query
+// operation in the document that does not already select the named field. Mutations, subscriptions
+// and fragments are left untouched. If the document cannot be understood, it is returned unchanged
+// along with the error.
+func injectTopLevelField(doc, field, selection string) (string, error) {
+
+	tokens, err := tokenize(doc)
+	if err != nil {
+		return doc, err
+	}
+	defs, err := definitions(tokens)
+	if err != nil {
+		return doc, err
+	}
+
+	// Work from the end of the document back so that earlier offsets remain valid
+	for i := len(defs) - 1; i >= 0; i-- {
+		def := defs[i]
+		if def.keyword != "query" || hasTopLevelField(tokens, def.openBrace, def.endBrace, field) {
+			continue
+		}
+		at := tokens[def.openBrace].pos + 1
+		doc = doc[:at] + " " + selection + doc[at:]
+	}
+	return doc, nil
+}
@@ -0,0 +1,124 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// RedirectPolicy selects how a client configured with WithRedirectPolicy(...) handles 3xx redirect
+// responses from the GraphQL server.
+type RedirectPolicy int
+
+// The redirect policies
+const (
+	// RedirectSameHost follows redirects to the same host, whatever the status code, repeating the
+	// original POST with its body and Authorization header intact. A redirect to any other host is
+	// refused with a RedirectError, lest the credentials be sent somewhere unexpected.
+	RedirectSameHost RedirectPolicy = iota + 1
+
+	// RedirectRefuse follows no redirects at all, every one being refused with a RedirectError.
+	RedirectRefuse
+)
+
+// maxRedirects is the number of redirects that RedirectSameHost will follow for a single request.
+const maxRedirects = 10
+
+// RedirectError is the error returned when a redirect is refused by the client's RedirectPolicy. It
+// usually means that the GraphQL endpoint has moved and the client should be given the new URL.
+type RedirectError struct {
+	StatusCode int    // The status code of the redirect response, e.g. 301 or 308
+	Location   string // The URL that the server redirected to
+	Reason     string // Why the redirect was refused
+}
+
+// Error describes the refused redirect.
+func (e RedirectError) Error() string {
+	return fmt.Sprintf("GraphQL server responded with a %d redirect to %s, not followed: %s", e.StatusCode, e.Location, e.Reason)
+}
+
+// WithRedirectPolicy is a ClientOption that makes explicit how redirect responses are handled.
+//
+// Without this option, the standard behavior of Go's http.Client applies: 307 and 308 redirects are
+// followed with the original POST but 301, 302 and 303 redirects are turned into GET requests
+// without a body, which GraphQL servers will not understand. The Authorization header is dropped
+// when redirected to a different domain. The result can be a confusing failure a long way from its
+// cause.
+//
+// With RedirectSameHost, any redirect to the same host is followed with the original method, body
+// and Authorization header, while redirects elsewhere fail with a RedirectError. With RedirectRefuse
+// every redirect fails with a RedirectError. Either way, the request body must be replayable,
+// which is not the case for the streamed bodies of UploadQuery(...), so uploads that are redirected
+// also fail with a RedirectError.
+func WithRedirectPolicy(policy RedirectPolicy) ClientOption {
+	return func(gc *gqlClient) {
+
+		// Give the client its own http.Client if it is still using the shared one
+		if gc.httpClient == nil {
+			gc.httpClient = &http.Client{Timeout: httpClient.Timeout}
+		}
+		gc.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return checkRedirect(policy, req, via)
+		}
+	}
+}
+
+// checkRedirect applies the redirect policy to the upcoming redirected request, req, given the
+// requests that have led to it, adjusting it to repeat the original request if it is to be followed.
+func checkRedirect(policy RedirectPolicy, req *http.Request, via []*http.Request) error {
+
+	// Describe the redirect in case we have to refuse it
+	original := via[0]
+	refuse := func(reason string) error {
+		return RedirectError{StatusCode: req.Response.StatusCode, Location: req.URL.String(), Reason: reason}
+	}
+
+	// Decide whether the redirect is acceptable
+	switch {
+	case policy == RedirectRefuse:
+		return refuse("the client's redirect policy refuses all redirects")
+	case req.URL.Host != original.URL.Host:
+		return refuse("redirects to another host are not followed")
+	case len(via) >= maxRedirects:
+		return refuse(fmt.Sprintf("stopped after %d redirects", maxRedirects))
+	case original.GetBody == nil:
+		return refuse("the request body cannot be replayed")
+	}
+
+	// Restore the original method, body and credentials, which Go may have discarded
+	body, err := original.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Method = original.Method
+	req.Body = body
+	req.GetBody = original.GetBody
+	req.ContentLength = original.ContentLength
+	for _, header := range []string{"Authorization", "Content-Type"} {
+		if value := original.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	return nil
+}
+
+// unfollowedRedirect returns a RedirectError describing a redirect response that was returned by
+// the http.Client rather than followed, as happens when the request body cannot be replayed, or nil
+// if the response is not a redirect.
+func unfollowedRedirect(req *http.Request, resp *http.Response) error {
+	location, err := resp.Location()
+	if resp.StatusCode < 300 || resp.StatusCode > 399 || err != nil {
+		return nil
+	}
+	reason := "the redirect could not be followed"
+	if req.GetBody == nil && req.Body != nil {
+		reason = "the request body cannot be replayed"
+	}
+	return RedirectError{StatusCode: resp.StatusCode, Location: location.String(), Reason: reason}
+}
+
+// isRedirectError returns true if the error arose from a refused redirect.
+func isRedirectError(err error) bool {
+	var redirectErr RedirectError
+	return errors.As(err, &redirectErr)
+}
@@ -0,0 +1,89 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the handling of redirect responses.
+
+// newMovedServer returns a fake GraphQL server that redirects requests for /old to the given
+// location with the given status code, answering requests for /new only if they arrive as a POST
+// with a body and the expected authorization.
+func newMovedServer(status int, location func(r *http.Request) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, location(r), status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != "POST" || !strings.Contains(string(body), "FetchRepoInfo") || r.Header.Get("Authorization") != "token secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+}
+
+// TestRedirectSameHost confirms that same host redirects are followed with the original method,
+// body and credentials, whatever the status code.
+func TestRedirectSameHost(t *testing.T) {
+
+	auth := "token secret"
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		server := newMovedServer(status, func(r *http.Request) string { return "/new" })
+		client := CreateClient(server.URL+"/old", &auth, WithRedirectPolicy(RedirectSameHost))
+		response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+		err := client.Query(&SimpleRepoDataQuery, nil, &response)
+		assert.Nil(t, err, "The %d redirect should have been followed", status)
+		assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+		server.Close()
+	}
+}
+
+// TestRedirectCrossHost confirms that redirects to another host are refused with a clear error.
+func TestRedirectCrossHost(t *testing.T) {
+
+	elsewhere := newMovedServer(http.StatusFound, nil)
+	defer elsewhere.Close()
+	server := newMovedServer(http.StatusMovedPermanently, func(r *http.Request) string { return elsewhere.URL + "/new" })
+	defer server.Close()
+
+	auth := "token secret"
+	client := CreateClient(strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/old", &auth,
+		WithRetry(3), WithRedirectPolicy(RedirectSameHost))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	var redirectErr RedirectError
+	assert.True(t, errors.As(err, &redirectErr), "A RedirectError should have been returned")
+	assert.Equal(t, http.StatusMovedPermanently, redirectErr.StatusCode)
+	assert.Equal(t, elsewhere.URL+"/new", redirectErr.Location)
+	assert.Contains(t, err.Error(), "another host")
+}
+
+// TestRedirectRefuse confirms that no redirects at all are followed under RedirectRefuse, and that
+// uploads, whose bodies cannot be replayed, are not followed under RedirectSameHost.
+func TestRedirectRefuse(t *testing.T) {
+
+	server := newMovedServer(http.StatusPermanentRedirect, func(r *http.Request) string { return "/new" })
+	defer server.Close()
+
+	auth := "token secret"
+	client := CreateClient(server.URL+"/old", &auth, WithRedirectPolicy(RedirectRefuse))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	var redirectErr RedirectError
+	assert.True(t, errors.As(err, &redirectErr), "A RedirectError should have been returned")
+	assert.Equal(t, server.URL+"/new", redirectErr.Location)
+
+	client = CreateClient(server.URL+"/old", &auth, WithRedirectPolicy(RedirectSameHost))
+	queryParms := map[string]interface{}{"file": &Upload{Filename: "a.txt", Reader: strings.NewReader("a")}}
+	err = client.UploadQuery(context.Background(), &SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.True(t, errors.As(err, &redirectErr), "A redirected upload should have been refused")
+	assert.Contains(t, err.Error(), "cannot be replayed")
+}
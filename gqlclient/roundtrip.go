@@ -0,0 +1,49 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RoundTripObserver is a function that is told how long each HTTP round trip to the GraphQL server
+// took: the URL the request was sent to, the name of the first operation in the query document,
+// empty for anonymous operations, and the time from sending the request to receiving the response
+// headers.
+type RoundTripObserver func(url, operationName string, d time.Duration)
+
+// roundTripContextKey is the type of the context key under which the operation name of a request is
+// carried to the round trip observers.
+type roundTripContextKey struct{}
+
+// WithRoundTripTime is a ClientOption that registers an observer to be told the duration of every
+// HTTP round trip, e.g. to feed a Prometheus histogram, without the ceremony of a MetricsRecorder.
+// Unlike the durations recorded by WithMetrics(...), which cover the whole query, each attempt of a
+// retried query is observed separately and the time taken to read and decode the response body is
+// not included. Round trips that fail are observed too. Responses supplied by a ResponseHook involve
+// no round trip and are not observed. If the option is given more than once, every observer is
+// called, in the order they were registered.
+func WithRoundTripTime(observer RoundTripObserver) ClientOption {
+	return func(gc *gqlClient) {
+		gc.roundTrip = append(gc.roundTrip, observer)
+	}
+}
+
+// roundTripContext returns the context with which the packed query should be delivered: one carrying
+// the name of its operation if there are round trip observers to be told it, or the given context
+// otherwise.
+func (gc *gqlClient) roundTripContext(ctx context.Context, packed string) context.Context {
+	if len(gc.roundTrip) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, roundTripContextKey{}, firstOperationName(&packed))
+}
+
+// observeRoundTrip tells the round trip observers how long the request took.
+func (gc *gqlClient) observeRoundTrip(req *http.Request, d time.Duration) {
+	operation, _ := req.Context().Value(roundTripContextKey{}).(string)
+	url := req.URL.String()
+	for _, observer := range gc.roundTrip {
+		observer(url, operation, d)
+	}
+}
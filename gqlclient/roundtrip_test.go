@@ -0,0 +1,63 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for round trip time observers.
+
+// roundTrip is a single observation made by a RoundTripObserver.
+type roundTrip struct {
+	url       string
+	operation string
+	d         time.Duration
+}
+
+// TestRoundTripTime confirms that every observer is told of every round trip, retries included.
+func TestRoundTripTime(t *testing.T) {
+
+	// Dawdle a little so that there is a duration worth measuring, and fail the first request
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		time.Sleep(5 * time.Millisecond)
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	// Register two observers, each keeping its own record
+	var first, second []roundTrip
+	client := CreateClient(server.URL, nil, WithRetry(1), WithBackoff(FixedBackoff{Delay: time.Millisecond}),
+		WithRoundTripTime(func(url, operationName string, d time.Duration) {
+			first = append(first, roundTrip{url, operationName, d})
+		}),
+		WithRoundTripTime(func(url, operationName string, d time.Duration) {
+			second = append(second, roundTrip{url, operationName, d})
+		}))
+
+	// Both attempts should have been observed by both observers
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), "The retry should have succeeded")
+	assert.Equal(t, 2, len(first), "Both attempts should have been observed")
+	assert.Equal(t, first, second, "Both observers should have been told the same")
+	for _, rt := range first {
+		assert.Equal(t, server.URL, rt.url, "The URL should have been reported")
+		assert.Equal(t, "FetchRepoInfo", rt.operation, "The operation name should have been reported")
+		assert.GreaterOrEqual(t, rt.d, 5*time.Millisecond, "The duration should cover the server's delay")
+	}
+
+	// Anonymous operations are observed without a name
+	first, second = nil, nil
+	anonymous := "{ viewer { login } }"
+	assert.Nil(t, client.Query(&anonymous, nil, &QueryResponse{}))
+	assert.Equal(t, 1, len(first))
+	assert.Equal(t, "", first[0].operation)
+}
@@ -0,0 +1,45 @@
+package gqlclient
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// WithErrorSampler is a ClientOption that logs the errors reported by the GraphQL service in query
+// responses, at LevelWarn, but only for a sample of the responses: each is logged with probability
+// rate, from 0.0 for none to 1.0 for all. This keeps log volume down in busy services where some
+// errors are expected and frequent. Sampling affects only the logging; the errors are always left in
+// the QueryResponse for the caller to deal with.
+//
+// The sample is logged through the client's Logger, so one must also be given, either with
+// WithBackgroundLogger(...) or, if every query is to be logged as well, WithLogger(...). Without a
+// logger nothing is logged. The order of the options does not matter.
+func WithErrorSampler(rate float64) ClientOption {
+	return func(gc *gqlClient) {
+		gc.errorSampler = &errorSampler{rate: rate, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	}
+}
+
+// logSampledErrors logs the GraphQL errors in the response, if there are any and the sampler picks
+// this response.
+func (gc *gqlClient) logSampledErrors(queryStr *string, response *QueryResponse) {
+	if gc.errorSampler != nil && gc.logger != nil && response.HasErrors() && gc.errorSampler.sample() {
+		gc.logger.Log(LevelWarn, "GraphQL response reported errors", "operation", firstOperationName(queryStr), "error", response.Err())
+	}
+}
+
+// errorSampler makes the random choices of WithErrorSampler(...).
+type errorSampler struct {
+	rate float64 // The probability that any given response should be logged
+
+	mutex sync.Mutex // Guards the source of randomness, which is not safe for concurrent use
+	rand  *rand.Rand // Our own source of randomness
+}
+
+// sample returns true if the next response should be logged.
+func (s *errorSampler) sample() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.rand.Float64() < s.rate
+}
@@ -0,0 +1,62 @@
+package gqlclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for GraphQL error log sampling.
+
+// sampledErrors returns the number of sampled GraphQL error entries that a logger has received.
+func sampledErrors(logger *capturingLogger) int {
+	count := 0
+	for _, entry := range logger.snapshot() {
+		if entry.msg == "GraphQL response reported errors" {
+			count++
+		}
+	}
+	return count
+}
+
+// TestErrorSampler confirms that the sampling rate controls how many GraphQL errors are logged but
+// not whether they are returned.
+func TestErrorSampler(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"Could not resolve to a Repository"}]}`))
+	}))
+	defer server.Close()
+
+	for _, test := range []struct {
+		rate     float64
+		expected int
+	}{
+		{0.0, 0},
+		{1.0, 10},
+	} {
+		logger := &capturingLogger{}
+		client := CreateClient(server.URL, nil, WithErrorSampler(test.rate), WithLogger(logger))
+		for i := 0; i < 10; i++ {
+			response := QueryResponse{}
+			assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response))
+			assert.NotNil(t, response.Err(), "The GraphQL error should still reach the caller")
+		}
+		assert.Equal(t, test.expected, sampledErrors(logger), "Unexpected number of errors logged at rate %v", test.rate)
+	}
+
+	// A background logger hears of the sampled errors without every query being logged too
+	logger := &capturingLogger{}
+	client := CreateClient(server.URL, nil, WithBackgroundLogger(logger), WithErrorSampler(1.0))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, 1, sampledErrors(logger), "The sampled error should have been logged")
+	assert.Len(t, logger.snapshot(), 1, "Nothing but the sampled error should have been logged")
+
+	// Without a logger there is nowhere for the sample to go, but no harm should come of it
+	client = CreateClient(server.URL, nil, WithErrorSampler(1.0))
+	response := QueryResponse{}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response))
+	assert.True(t, response.HasErrors())
+}
@@ -0,0 +1,133 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// VariablesSchemaError is the error returned when the variables of a query do not conform to the
+// JSON Schema given to WithVariablesSchema(...). Every violation found is listed, each prefixed with
+// the JSON path of the offending value, e.g. "$.owner: expected string, but got number".
+type VariablesSchemaError struct {
+	Violations []string // Descriptions of each way in which the variables fail the schema
+}
+
+// Error lists the schema violations.
+func (e VariablesSchemaError) Error() string {
+	return "GraphQL variables do not match schema: " + strings.Join(e.Violations, "; ")
+}
+
+// WithVariablesSchema is a ClientOption that has the variables of every query, including those sent
+// by UploadQuery(...) and QueryDeferred(...), checked against the given JSON Schema before the query
+// is sent, catching type mismatches without a round trip to the server. A query whose variables do
+// not conform fails with a VariablesSchemaError. A query without variables is checked as if its
+// variables were an empty object.
+//
+// The schema is applied to the variables as they will be marshalled into the request body, so for
+// example time.Time values are checked as strings, and the files of an UploadQuery(...) as nulls.
+// The whole of the JSON Schema specification is supported, up to draft 2020-12, which is assumed
+// unless the schema names another with $schema; format keywords are asserted rather than treated as
+// annotations. If the schema is not valid JSON, or is not a valid schema, every query fails with an
+// error saying so.
+func WithVariablesSchema(schema []byte) ClientOption {
+	return func(gc *gqlClient) {
+		gc.varSchema = compileSchema(schema)
+	}
+}
+
+// variablesSchemaURL is the name under which a variables schema is given to the schema compiler.
+const variablesSchemaURL = "gqlclient-variables.json"
+
+// variablesSchema is a JSON Schema ready to be applied to query variables.
+type variablesSchema struct {
+	schema *jsonschema.Schema // The compiled schema
+	err    error              // Set if the schema could not be understood
+}
+
+// compileSchema compiles a JSON Schema, recording why if it cannot be compiled.
+func compileSchema(schema []byte) *variablesSchema {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	vs := &variablesSchema{}
+	if err := compiler.AddResource(variablesSchemaURL, bytes.NewReader(schema)); err != nil {
+		vs.err = fmt.Errorf("invalid GraphQL variables schema: %w", err)
+		return vs
+	}
+	if vs.schema, vs.err = compiler.Compile(variablesSchemaURL); vs.err != nil {
+		vs.err = fmt.Errorf("invalid GraphQL variables schema: %w", vs.err)
+	}
+	return vs
+}
+
+// checkVariables returns a VariablesSchemaError if the client has a variables schema and the query
+// variables do not conform to it.
+func (gc *gqlClient) checkVariables(queryParms *map[string]interface{}) error {
+
+	// Nothing to do unless we have been given a schema that we can use
+	vs := gc.varSchema
+	if vs == nil {
+		return nil
+	}
+	if vs.err != nil {
+		return vs.err
+	}
+
+	// See the variables as the server will see them, then hold them up against the schema
+	vars := map[string]interface{}{}
+	if queryParms != nil && *queryParms != nil {
+		vars = *queryParms
+	}
+	marshalled, err := json.Marshal(vars)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(marshalled, &value); err != nil {
+		return err
+	}
+	err = vs.schema.Validate(value)
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	// List the violations in a predictable order so that they are always reported alike
+	violations := schemaViolations(validationErr, nil)
+	sort.Strings(violations)
+	return VariablesSchemaError{Violations: violations}
+}
+
+// schemaViolations appends a description of each of the most specific causes of a validation error to
+// the list of violations, and returns the list.
+func schemaViolations(ve *jsonschema.ValidationError, violations []string) []string {
+	if len(ve.Causes) == 0 {
+		return append(violations, instancePath(ve.InstanceLocation)+": "+ve.Message)
+	}
+	for _, cause := range ve.Causes {
+		violations = schemaViolations(cause, violations)
+	}
+	return violations
+}
+
+// instancePath converts a JSON pointer into the variables, e.g. "/states/1", into the form used in
+// violation descriptions, e.g. "$.states[1]".
+func instancePath(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		if token != "" && strings.Trim(token, "0123456789") == "" {
+			path += "[" + token + "]"
+		} else {
+			path += "." + token
+		}
+	}
+	return path
+}
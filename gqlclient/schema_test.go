@@ -0,0 +1,144 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for validating query variables against a JSON Schema.
+
+// repoVariablesSchema describes the variables of a repository query.
+var repoVariablesSchema = []byte(`{
+	"type": "object",
+	"required": ["owner", "name"],
+	"additionalProperties": false,
+	"properties": {
+		"owner": {"type": "string", "minLength": 1, "pattern": "^[A-Za-z0-9-]+$"},
+		"name":  {"type": "string", "maxLength": 100},
+		"first": {"type": "integer", "minimum": 1, "maximum": 100},
+		"states": {"type": "array", "maxItems": 3, "items": {"enum": ["OPEN", "CLOSED", "MERGED"]}}
+	}
+}`)
+
+// TestVariablesSchema confirms that conforming variables are sent and that wrongly typed ones are
+// refused before anything is sent.
+func TestVariablesSchema(t *testing.T) {
+
+	// Count the requests that reach the server
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	client := CreateClient(server.URL, nil, WithVariablesSchema(repoVariablesSchema))
+
+	// Good variables pass
+	queryParms := map[string]interface{}{"owner": "mikebway", "name": "gogql", "first": 10, "states": []string{"OPEN"}}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{}), "Conforming variables should have been sent")
+	assert.Equal(t, 1, requests)
+
+	// A number where a string belongs does not
+	queryParms = map[string]interface{}{"owner": 42, "name": "gogql"}
+	err := client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	var schemaErr VariablesSchemaError
+	assert.True(t, errors.As(err, &schemaErr), "A schema error should have been returned")
+	assert.Equal(t, []string{"$.owner: expected string, but got number"}, schemaErr.Violations)
+	assert.Equal(t, "GraphQL variables do not match schema: $.owner: expected string, but got number", err.Error())
+	assert.Equal(t, 1, requests, "The query should not have been sent")
+
+	// Every violation is reported
+	queryParms = map[string]interface{}{"owner": "mike broadway", "first": 2.5, "states": []string{"OPEN", "DRAFT"}, "extra": true}
+	err = client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.Equal(t, VariablesSchemaError{Violations: []string{
+		`$.first: expected integer, but got number`,
+		`$.owner: does not match pattern '^[A-Za-z0-9-]+$'`,
+		`$.states[1]: value must be one of "OPEN", "CLOSED", "MERGED"`,
+		`$: additionalProperties 'extra' not allowed`,
+		`$: missing properties: 'name'`,
+	}}, err)
+
+	// No variables at all are checked as an empty object
+	err = client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Equal(t, VariablesSchemaError{Violations: []string{
+		`$: missing properties: 'owner', 'name'`,
+	}}, err)
+	assert.Equal(t, 1, requests, "None of the bad queries should have been sent")
+}
+
+// TestVariablesSchemaKeywords confirms that references, combinators and formats are applied rather
+// than ignored.
+func TestVariablesSchemaKeywords(t *testing.T) {
+
+	schema := []byte(`{
+		"$defs": {"login": {"type": "string", "minLength": 1}},
+		"type": "object",
+		"properties": {
+			"owner": {"$ref": "#/$defs/login"},
+			"id":    {"anyOf": [{"type": "integer"}, {"type": "string", "pattern": "^R_"}]},
+			"since": {"type": "string", "format": "date-time"},
+			"state": {"not": {"const": "DELETED"}}
+		}
+	}`)
+	client := CreateClient("http://localhost:1", nil, WithVariablesSchema(schema))
+
+	for _, queryParms := range []map[string]interface{}{
+		{"owner": 42},
+		{"id": "gogql"},
+		{"since": "yesterday"},
+		{"state": "DELETED"},
+	} {
+		err := client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+		var schemaErr VariablesSchemaError
+		assert.True(t, errors.As(err, &schemaErr), "Variables %v should have been refused: %v", queryParms, err)
+	}
+}
+
+// TestVariablesSchemaUploadAndDeferred confirms that the variables of uploads and deferred queries are
+// checked too, and that the files of an upload are seen as nulls.
+func TestVariablesSchemaUploadAndDeferred(t *testing.T) {
+
+	// Count the requests that reach the server
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	schema := []byte(`{
+		"type": "object",
+		"required": ["owner"],
+		"properties": {"owner": {"type": "string"}, "file": {"type": "null"}}
+	}`)
+	client := CreateClient(server.URL, nil, WithVariablesSchema(schema))
+
+	// Bad variables go nowhere
+	queryParms := map[string]interface{}{"owner": 42}
+	err := client.UploadQuery(context.Background(), &SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.True(t, errors.As(err, &VariablesSchemaError{}), "The upload variables should have been refused: %v", err)
+	err = client.QueryDeferred(context.Background(), &SimpleRepoDataQuery, &queryParms, func(*QueryResponse) error { return nil })
+	assert.True(t, errors.As(err, &VariablesSchemaError{}), "The deferred variables should have been refused: %v", err)
+	assert.Equal(t, 0, requests, "None of the bad queries should have been sent")
+
+	// Good ones, including a file, are sent
+	queryParms = map[string]interface{}{"owner": "mikebway", "file": &Upload{Filename: "a.txt", Reader: strings.NewReader("a")}}
+	assert.Nil(t, client.UploadQuery(context.Background(), &SimpleRepoDataQuery, &queryParms, &QueryResponse{}))
+	assert.Equal(t, 1, requests, "The upload should have been sent")
+}
+
+// TestVariablesSchemaInvalid confirms that a schema that cannot be understood fails every query.
+func TestVariablesSchemaInvalid(t *testing.T) {
+
+	for _, schema := range []string{`{"type": "object"`, `{"properties": {"owner": {"pattern": "(unclosed"}}}`, `{"type": "text"}`} {
+		client := CreateClient("http://localhost:1", nil, WithVariablesSchema([]byte(schema)))
+		err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+		assert.NotNil(t, err, "Schema %s should have been refused", schema)
+		assert.Contains(t, err.Error(), "GraphQL variables schema")
+	}
+}
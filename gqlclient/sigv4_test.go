@@ -0,0 +1,131 @@
+package gqlclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for AWS SigV4 request signing.
+
+// fakeAWSCredentials is an aws.CredentialsProvider that hands out fixed credentials, or a fixed error.
+type fakeAWSCredentials struct {
+	creds aws.Credentials
+	err   error
+	calls int
+}
+
+// Retrieve returns the fake credentials or error.
+func (p *fakeAWSCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	p.calls++
+	return p.creds, p.err
+}
+
+// exampleAWSCredentials are the credentials used in the AWS documentation's SigV4 examples.
+var exampleAWSCredentials = aws.Credentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// TestSigV4Auth confirms that the hook signs the AWS SigV4 test suite's vanilla POST request correctly,
+// and that a JSON body is hashed into the signature.
+func TestSigV4Auth(t *testing.T) {
+
+	// Sign at the time used by the test suite
+	defer func(now func() time.Time) { sigV4Now = now }(sigV4Now)
+	sigV4Now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	hook := SigV4Auth(&fakeAWSCredentials{creds: exampleAWSCredentials}, "service", "us-east-1")
+
+	req, _ := http.NewRequest("POST", "https://example.amazonaws.com/", nil)
+	assert.Nil(t, hook(req))
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		req.Header.Get("Authorization"))
+
+	// A JSON body should be signed as though its hash had been given to the signer directly
+	body := `{"query":"{ viewer { login } }","variables":null}`
+	req, _ = http.NewRequest("POST", "https://example.amazonaws.com/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	assert.Nil(t, hook(req))
+	expected, _ := http.NewRequest("POST", "https://example.amazonaws.com/graphql", strings.NewReader(body))
+	expected.Header.Set("Content-Type", "application/json")
+	hash := sha256.Sum256([]byte(body))
+	assert.Nil(t, v4.NewSigner().SignHTTP(context.Background(), exampleAWSCredentials, expected,
+		hex.EncodeToString(hash[:]), "service", "us-east-1", sigV4Now()))
+	assert.Equal(t, expected.Header.Get("Authorization"), req.Header.Get("Authorization"), "The body should have been signed")
+	b, _ := io.ReadAll(req.Body)
+	assert.Equal(t, body, string(b), "The body should have been left unread")
+
+	// A body that cannot be read twice cannot be signed
+	req, _ = http.NewRequest("POST", "https://example.amazonaws.com/", io.NopCloser(strings.NewReader("{}")))
+	assert.NotNil(t, hook(req))
+}
+
+// TestNewClientFromAWSConfig confirms that every request is signed, that the body is left intact for
+// the server, and that temporary credentials send their session token.
+func TestNewClientFromAWSConfig(t *testing.T) {
+
+	// Record the headers and body of each request
+	var headers http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// Sign at a known time
+	defer func(now func() time.Time) { sigV4Now = now }(sigV4Now)
+	sigV4Now = func() time.Time { return time.Date(2024, 7, 1, 9, 30, 0, 0, time.UTC) }
+
+	provider := &fakeAWSCredentials{creds: aws.Credentials{AccessKeyID: "AKIDTEMP", SecretAccessKey: "secret", SessionToken: "session"}}
+	client, err := NewClientFromAWSConfig(aws.Config{Credentials: provider, Region: "eu-west-2"}, "appsync", "", server.URL)
+	assert.Nil(t, err, "Client should have been created")
+	assert.Equal(t, server.URL, client.GetTargetURL())
+
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response), "Signed query should have succeeded")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	assert.Contains(t, body, "FetchRepoInfo", "The body should still have reached the server")
+	assert.Equal(t, 1, provider.calls, "The credentials should have been retrieved for the request")
+
+	assert.Equal(t, "20240701T093000Z", headers.Get("X-Amz-Date"), "The signing time should have been sent")
+	assert.Equal(t, "session", headers.Get("X-Amz-Security-Token"), "The session token should have been sent")
+	assert.True(t, strings.HasPrefix(headers.Get("Authorization"),
+		"AWS4-HMAC-SHA256 Credential=AKIDTEMP/20240701/eu-west-2/appsync/aws4_request, "),
+		"The request should have been signed: %s", headers.Get("Authorization"))
+
+	// A credentials failure should abandon the request
+	provider.err = errors.New("no EC2 role")
+	err = client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.NotNil(t, err, "The credentials failure should have been reported")
+	assert.Contains(t, err.Error(), "no EC2 role")
+}
+
+// TestNewClientFromAWSConfigErrors confirms that incomplete configurations are rejected.
+func TestNewClientFromAWSConfigErrors(t *testing.T) {
+
+	_, err := NewClientFromAWSConfig(aws.Config{Region: "us-east-1"}, "appsync", "", "https://example.com/graphql")
+	assert.NotNil(t, err, "A configuration without credentials should be rejected")
+
+	provider := &fakeAWSCredentials{creds: exampleAWSCredentials}
+	_, err = NewClientFromAWSConfig(aws.Config{Credentials: provider}, "appsync", "", "https://example.com/graphql")
+	assert.NotNil(t, err, "A configuration without a region should be rejected")
+
+	_, err = NewClientFromAWSConfig(aws.Config{Credentials: provider}, "appsync", "us-east-1", "https://example.com/graphql")
+	assert.Nil(t, err, "An explicit region should suffice")
+}
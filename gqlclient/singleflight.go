@@ -0,0 +1,112 @@
+package gqlclient
+
+import (
+	"context"
+	"sync"
+)
+
+// WithSingleFlight is a ClientOption that coalesces identical concurrent queries: if a query is made
+// while an identical one, with the same query text, variables, endpoint and authorization, is still
+// awaiting its response, no second request is sent. Instead, the second caller waits for the
+// response to the first and its QueryResponse is populated by decoding the same response body into
+// its own Data object. This saves time and rate limit quota when many goroutines want the same data
+// at the same moment, e.g. as a cache warms up on startup.
+//
+// Only documents made up entirely of query operations are coalesced. Mutations and subscriptions are
+// always sent on their own, since merging two identical mutations would silently lose the side effect
+// of one of them. Queries given different keys with ContextWithIdempotencyKey(...) are not coalesced.
+//
+// The request is made with the context of the first caller, so cancelling that context fails every
+// caller waiting on the request. Other callers may still give up waiting, without affecting the
+// request, by cancelling their own contexts. Middleware, being wrapped around the query, runs for
+// every caller.
+func WithSingleFlight() ClientOption {
+	return func(gc *gqlClient) {
+		gc.flights = newFlightGroup()
+	}
+}
+
+// flightGroup keeps track of the queries that are in flight.
+type flightGroup struct {
+	mutex sync.Mutex         // Guards the map of flights
+	calls map[string]*flight // The flights in progress, keyed on everything that identifies the query
+}
+
+// flight is a single query in progress, whose outcome can be waited for.
+type flight struct {
+	done chan struct{} // Closed once the query has completed
+	body []byte        // The body of the response, if a 200 response was received
+	err  error         // The error that the query failed with, if any
+}
+
+// newFlightGroup returns an empty flightGroup.
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: make(map[string]*flight)}
+}
+
+// singleFlight delivers the JSON encoded query to the GraphQL server, unless an identical query is
+// already in flight, in which case its response is waited for and decoded into our response.
+func (gc *gqlClient) singleFlight(ctx context.Context, queryBytes []byte, response *QueryResponse) error {
+
+	// The same query sent to a different server, or with different credentials, may well get a
+	// different answer
+	key := gc.endpoint() + "\n" + string(queryBytes)
+	if authorization := gc.authHeader(); authorization != nil {
+		key = *authorization + "\n" + key
+	}
+
+	// And a caller who has given their own idempotency key means their request to be seen as distinct
+	if idempotencyKey, ok := ctx.Value(idempotencyContextKey{}).(string); ok && idempotencyKey != "" {
+		key = idempotencyKey + "\n" + key
+	}
+
+	// If someone else is already asking, wait for their answer
+	g := gc.flights
+	g.mutex.Lock()
+	if f, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// If they got a response, decode it for ourselves, otherwise share their misfortune
+		if f.body == nil {
+			return f.err
+		}
+		response.bodySize = int64(len(f.body))
+		return gc.decode(f.body, response)
+	}
+
+	// Otherwise it's up to us, letting anyone who comes along in the meantime know when we are done
+	f := &flight{done: make(chan struct{})}
+	g.calls[key] = f
+	g.mutex.Unlock()
+
+	response.sharedBody = &f.body
+	f.err = gc.deliver(ctx, queryBytes, response)
+	response.sharedBody = nil
+
+	g.mutex.Lock()
+	delete(g.calls, key)
+	g.mutex.Unlock()
+	close(f.done)
+	return f.err
+}
+
+// queriesOnly returns true if every operation in the packed query document is a query, and so has
+// no side effects that coalescing could lose. Documents that cannot be understood are assumed not to
+// be safe to coalesce.
+func queriesOnly(packed string) bool {
+	info := documentInfoOf(packed)
+	if info.err != nil {
+		return false
+	}
+	for _, opType := range info.types {
+		if opType != "query" {
+			return false
+		}
+	}
+	return true
+}
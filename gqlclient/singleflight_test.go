@@ -0,0 +1,131 @@
+package gqlclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for coalescing identical concurrent queries.
+
+// newSlowServer returns a fake server that counts its calls and holds each response until released.
+func newSlowServer(calls *int32, release chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		<-release
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+}
+
+// TestSingleFlight confirms that concurrent identical queries make only one request, with every
+// caller's response populated.
+func TestSingleFlight(t *testing.T) {
+
+	var calls int32
+	release := make(chan struct{})
+	server := newSlowServer(&calls, release)
+	defer server.Close()
+
+	// Fire off a crowd of identical queries, releasing the server once they are all waiting
+	const n = 10
+	client := CreateClient(server.URL, nil, WithSingleFlight())
+	responses := make([]QueryResponse, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		responses[i].Data = new(SimpleRepoDataResponse)
+		go func(i int) {
+			defer wg.Done()
+			queryParms := map[string]interface{}{"owner": "mikebway", "name": "gogql"}
+			errs[i] = client.Query(&SimpleRepoDataQuery, &queryParms, &responses[i])
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	// Only one request should have been made, but everyone should have an answer of their own
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Only one request should have been made")
+	for i := 0; i < n; i++ {
+		assert.Nil(t, errs[i], "Query %d should have succeeded", i)
+		data := responses[i].Data.(*SimpleRepoDataResponse)
+		assert.Equal(t, "gogql", data.Repository.Name, "Response %d should have been populated", i)
+		for j := 0; j < i; j++ {
+			assert.True(t, data != responses[j].Data.(*SimpleRepoDataResponse), "Responses should not share data")
+		}
+	}
+
+	// Once the flight has landed, the next query makes a request of its own
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: new(SimpleRepoDataResponse)})
+	assert.Nil(t, err, "Later query should have succeeded")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "A later query should have made its own request")
+}
+
+// TestSingleFlightDistinctQueries confirms that queries with different variables are not coalesced.
+func TestSingleFlightDistinctQueries(t *testing.T) {
+
+	var calls int32
+	release := make(chan struct{})
+	server := newSlowServer(&calls, release)
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithSingleFlight())
+	var wg sync.WaitGroup
+	for _, name := range []string{"gogql", "other"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			queryParms := map[string]interface{}{"owner": "mikebway", "name": name}
+			client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+		}(name)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "Different queries should each have made a request")
+}
+
+// TestSingleFlightMutations confirms that identical concurrent mutations are each sent, as are queries
+// given different idempotency keys.
+func TestSingleFlightMutations(t *testing.T) {
+
+	var calls int32
+	release := make(chan struct{})
+	server := newSlowServer(&calls, release)
+	defer server.Close()
+	client := CreateClient(server.URL, nil, WithSingleFlight())
+
+	// Two identical mutations and two queries that differ only in their idempotency keys
+	mutation := "mutation AddStar($id: ID!) { addStar(input: {starrableId: $id}) { clientMutationId } }"
+	queryParms := map[string]interface{}{"id": "R_1"}
+	errs := make([]error, 4)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i < 2 {
+				errs[i] = client.Query(&mutation, &queryParms, &QueryResponse{})
+				return
+			}
+			ctx := ContextWithIdempotencyKey(context.Background(), fmt.Sprint("key-", i))
+			errs[i] = client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &QueryResponse{})
+		}(i)
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "Every mutation and keyed query should have been sent")
+	for i, err := range errs {
+		assert.Nil(t, err, "Request %d should have succeeded", i)
+	}
+}
@@ -0,0 +1,71 @@
+package gqlclient
+
+import "fmt"
+
+// ErrRequestTooLarge is the error returned by a client configured with WithMaxRequestSize(...) when
+// the JSON encoded body of a request, i.e. the query and its variables, is larger than the limit.
+// Nothing is sent to the server when this error is returned.
+type ErrRequestTooLarge struct {
+	Size  int64 // The size of the encoded request body in bytes
+	Limit int64 // The configured limit in bytes
+}
+
+// Error returns a description of the oversized request.
+func (e ErrRequestTooLarge) Error() string {
+	return fmt.Sprintf("request body of %d bytes exceeds the maximum request size of %d bytes", e.Size, e.Limit)
+}
+
+// WithMaxRequestSize is a ClientOption that refuses to send any request whose JSON encoded body is
+// larger than the given number of bytes, returning an ErrRequestTooLarge error instead. This catches
+// pathologically large variable values before the round trip rather than leaving the server to
+// reject them, often with an unhelpful error. For UploadQuery(...) requests the limit applies to the
+// query and variables but not to the files. By default, or if the limit is zero or less, request
+// size is unlimited.
+func WithMaxRequestSize(limit int64) ClientOption {
+	return func(gc *gqlClient) {
+		gc.maxRequestSize = limit
+	}
+}
+
+// checkRequestSize returns an ErrRequestTooLarge error if the given request body exceeds the
+// configured maximum size.
+func (gc *gqlClient) checkRequestSize(body []byte) error {
+	if gc.maxRequestSize > 0 && int64(len(body)) > gc.maxRequestSize {
+		return ErrRequestTooLarge{Size: int64(len(body)), Limit: gc.maxRequestSize}
+	}
+	return nil
+}
+
+// ErrQueryTooLarge is the error returned by a client configured with WithMaxQueryLength(...) when
+// the packed query text is longer than the limit. Nothing is sent to the server when this error is
+// returned.
+type ErrQueryTooLarge struct {
+	Length int // The length of the packed query in bytes
+	Limit  int // The configured limit in bytes
+}
+
+// Error returns a description of the oversized query.
+func (e ErrQueryTooLarge) Error() string {
+	return fmt.Sprintf("packed query of %d bytes exceeds the maximum query length of %d bytes", e.Length, e.Limit)
+}
+
+// WithMaxQueryLength is a ClientOption that refuses to send any query whose text, once packed, is
+// longer than the given number of bytes, returning an ErrQueryTooLarge error instead. This catches
+// runaway output from query builders or templates before it is sent. The limit applies to the query
+// as given by the caller, before any query transforms are applied, and does not include the
+// variables; see WithMaxRequestSize(...) for those. By default, or if the limit is zero or less,
+// query length is unlimited.
+func WithMaxQueryLength(n int) ClientOption {
+	return func(gc *gqlClient) {
+		gc.maxQueryLength = n
+	}
+}
+
+// checkQueryLength returns an ErrQueryTooLarge error if the given packed query exceeds the
+// configured maximum length.
+func (gc *gqlClient) checkQueryLength(packed string) error {
+	if gc.maxQueryLength > 0 && len(packed) > gc.maxQueryLength {
+		return ErrQueryTooLarge{Length: len(packed), Limit: gc.maxQueryLength}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the maximum request size and query length guards.
+
+// TestMaxRequestSize confirms that oversized requests are refused without being sent.
+func TestMaxRequestSize(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithMaxRequestSize(1024))
+
+	// A modest query should go through
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "A small request should have been sent")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// A big blob of a variable should not
+	queryParms := map[string]interface{}{"blob": strings.Repeat("x", 2048)}
+	err = client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	tooLarge, ok := err.(ErrRequestTooLarge)
+	assert.True(t, ok, "An ErrRequestTooLarge error should have been returned")
+	assert.Equal(t, int64(1024), tooLarge.Limit)
+	assert.True(t, tooLarge.Size > 2048, "The size should have included the blob")
+	assert.Contains(t, err.Error(), "exceeds the maximum request size of 1024 bytes")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "The oversized request should not have been sent")
+
+	// Nor should an upload with oversized variables
+	queryParms["file"] = &Upload{Filename: "a.txt", Reader: strings.NewReader("a")}
+	err = client.UploadQuery(context.Background(), &SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	_, ok = err.(ErrRequestTooLarge)
+	assert.True(t, ok, "An oversized upload should have been refused")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "The oversized upload should not have been sent")
+
+	// Without the option there is no limit
+	delete(queryParms, "file")
+	client = CreateClient(server.URL, nil)
+	err = client.Query(&SimpleRepoDataQuery, &queryParms, &QueryResponse{})
+	assert.Nil(t, err, "Request size should be unlimited by default")
+}
+
+// TestMaxQueryLength confirms that overlong queries are refused without being sent.
+func TestMaxQueryLength(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithMaxQueryLength(50))
+
+	// A 60 character query, even with its whitespace packed, should not be sent
+	queryStr := "query Long {\n  " + strings.Repeat("a", 38) + " { id }\n}"
+	assert.Equal(t, 60, len(packQuery(&queryStr)), "The packed test query should be 60 characters long")
+	err := client.Query(&queryStr, nil, &QueryResponse{})
+	tooLarge, ok := err.(ErrQueryTooLarge)
+	assert.True(t, ok, "An ErrQueryTooLarge error should have been returned")
+	assert.Equal(t, ErrQueryTooLarge{Length: 60, Limit: 50}, tooLarge)
+	assert.Equal(t, "packed query of 60 bytes exceeds the maximum query length of 50 bytes", err.Error())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls), "The overlong query should not have been sent")
+
+	// A short one should
+	queryStr = "{ viewer { login } }"
+	err = client.Query(&queryStr, nil, &QueryResponse{})
+	assert.Nil(t, err, "A short query should have been sent")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
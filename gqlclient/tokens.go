@@ -0,0 +1,148 @@
+package gqlclient
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultTokenRest is how long a rate limited token is rested when the server does not say when
+// its limit resets.
+const defaultTokenRest = time.Minute
+
+// CreateClientWithTokens returns a GqlClient that spreads its requests across several authorization
+// values, e.g. the tokens of several github accounts, to stretch the rate limits that apply to each.
+// Each value is given in the same form as the authorization of CreateClient(...), e.g.
+// "token f69acf817105a9e024f3e94a80bbf09e2879abef", and, like it, cannot be read back from the client.
+//
+// The values are used in turn, one per request. A value whose request is refused with a 429 TOO
+// MANY REQUESTS, or with a 403 FORBIDDEN that github uses to report an exhausted rate limit, is
+// rested until the limit resets, as reported by the X-RateLimit-Reset or Retry-After headers of the
+// response, or for a minute if neither is present, and the request is sent again straight away with
+// the next value that is not resting. Only if every value is resting is the refusal returned, to be
+// retried or not as the client has been configured. Multipart UploadQuery(...) requests cannot be
+// sent again, but a refused upload still rests its value. Any ClientOption values are applied as for
+// CreateClient(...).
+func CreateClientWithTokens(targetURL string, tokens []string, opts ...ClientOption) GqlClient {
+	if len(tokens) > 0 {
+		ring := &tokenRing{tokens: append([]string(nil), tokens...), restUntil: make([]time.Time, len(tokens))}
+		opts = append([]ClientOption{func(gc *gqlClient) { gc.tokens = ring }}, opts...)
+	}
+	return CreateClient(targetURL, nil, opts...)
+}
+
+// tokenRing is the set of authorization values used in turn by a client created with
+// CreateClientWithTokens(...).
+type tokenRing struct {
+	mutex     sync.Mutex  // Guards everything else
+	tokens    []string    // The authorization values
+	restUntil []time.Time // The time until which each value is resting, zero if it is not
+	next      int         // The index of the value to be tried first for the next request
+}
+
+// copy returns a new ring with the same values, none of them resting.
+func (r *tokenRing) copy() *tokenRing {
+	return &tokenRing{tokens: r.tokens, restUntil: make([]time.Time, len(r.tokens))}
+}
+
+// pick returns the index and value of the next authorization value that is not resting, or, if all
+// of them are, the one whose rest ends soonest.
+func (r *tokenRing) pick(now time.Time) (int, string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	chosen := -1
+	for n := 0; n < len(r.tokens); n++ {
+		i := (r.next + n) % len(r.tokens)
+		if !r.restUntil[i].After(now) {
+			chosen = i
+			break
+		}
+		if chosen < 0 || r.restUntil[i].Before(r.restUntil[chosen]) {
+			chosen = i
+		}
+	}
+	r.next = (chosen + 1) % len(r.tokens)
+	return chosen, r.tokens[chosen]
+}
+
+// rest stops the value at the given index being used until the given time, returning true if
+// another value is available for use in the meantime.
+func (r *tokenRing) rest(i int, until time.Time, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.restUntil[i] = until
+	for _, t := range r.restUntil {
+		if !t.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenRateLimit returns the time until which the authorization value that obtained the response
+// should be rested, and true, if the response reports that its rate limit has been exhausted.
+func tokenRateLimit(resp *http.Response, now time.Time) (time.Time, bool) {
+
+	// github reports exhausted limits with a 403 as often as a 429, but a 403 may also be a plain
+	// refusal, so only take it as a rate limit if the headers say so
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusForbidden:
+		if resp.Header.Get("X-RateLimit-Remaining") != "0" && resp.Header.Get("Retry-After") == "" {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	// Rest the value for as long as the server asks, or until its limit resets
+	if delay := parseRetryAfter(resp.Header.Get("Retry-After"), now); delay > 0 {
+		return now.Add(delay), true
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if until := time.Unix(reset, 0); until.After(now) {
+			return until, true
+		}
+	}
+	return now.Add(defaultTokenRest), true
+}
+
+// switchTokens examines the response to a request sent with the authorization value at the given
+// index and, for as long as the value used has been rate limited and there is another available,
+// rests it and sends the request again with the next. The last response received is returned.
+func (gc *gqlClient) switchTokens(req *http.Request, resp *http.Response, index int) (*http.Response, error) {
+	for tries := 1; tries < len(gc.tokens.tokens); tries++ {
+
+		// Nothing to do unless the value has been rate limited and we have another to try
+		now := gc.clock.Now()
+		until, limited := tokenRateLimit(resp, now)
+		if !limited || !gc.tokens.rest(index, until, now) || req.GetBody == nil {
+			return resp, nil
+		}
+
+		// Send the request again, with a fresh copy of its body and the next value
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = body
+		var token string
+		index, token = gc.tokens.pick(now)
+		req.Header.Set("Authorization", token)
+		if resp, err = gc.sendRequest(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// Even if we have run out of values to try, the last one should still be rested if it was refused
+	now := gc.clock.Now()
+	if until, limited := tokenRateLimit(resp, now); limited {
+		gc.tokens.rest(index, until, now)
+	}
+	return resp, nil
+}
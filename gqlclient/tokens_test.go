@@ -0,0 +1,87 @@
+package gqlclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for clients that use several tokens in turn.
+
+// TestCreateClientWithTokens confirms that a rate limited token is skipped until its limit resets,
+// the request being sent again with the next token.
+func TestCreateClientWithTokens(t *testing.T) {
+
+	// Rate limit the first token the first time it is used, until an hour from the start
+	clock := &fakeClock{now: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}
+	reset := clock.now.Add(time.Hour).Unix()
+	var mutex sync.Mutex
+	var seen []string
+	limited := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "token first" && !limited {
+			limited = true
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+	client := CreateClientWithTokens(server.URL, []string{"token first", "token second"}, WithClock(clock))
+
+	// The refusal should have been hidden from us by sending the query again with the second token
+	response := QueryResponse{Data: new(SimpleRepoDataResponse)}
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &response), "The second token should have been used")
+	assert.Equal(t, "gogql", response.Data.(*SimpleRepoDataResponse).Repository.Name)
+	assert.Equal(t, []string{"token first", "token second"}, seen)
+
+	// The first token should be left to rest until its limit resets, then used again
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "token second", seen[2], "The rate limited token should have been skipped")
+	<-clock.After(time.Hour)
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "token first", seen[3], "The token should have been used again once its limit reset")
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, "token second", seen[4], "The tokens should have been used in turn")
+}
+
+// TestCreateClientWithTokensExhausted confirms that github's 403 rate limit responses are recognized,
+// that the refusal is returned once every token is resting, and that other 403s are not rate limits.
+func TestCreateClientWithTokensExhausted(t *testing.T) {
+
+	// Refuse everything, as a rate limit or not as asked
+	var seen []string
+	rateLimit := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		if rateLimit {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	// Both tokens should be tried before the refusal is returned
+	client := CreateClientWithTokens(server.URL, []string{"token a", "token b"})
+	var statusErr StatusError
+	assert.True(t, errors.As(client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), &statusErr))
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	assert.Equal(t, []string{"token a", "token b"}, seen)
+
+	// A plain refusal is not a reason to try another token
+	seen, rateLimit = nil, false
+	client = CreateClientWithTokens(server.URL, []string{"token a", "token b"})
+	assert.NotNil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	assert.Equal(t, []string{"token a"}, seen)
+}
@@ -0,0 +1,122 @@
+package gqlclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RollbackMutation is a mutation that undoes the effect of another, registered with TxClient.Mutate(...)
+// to be sent should the transaction fail.
+type RollbackMutation struct {
+	Mutation string                 // The compensating mutation
+	Params   map[string]interface{} // The variables of the mutation, may be nil
+}
+
+// TxClient is the client given to the function run by GqlClient.Transaction(...), through which it
+// sends the mutations of the transaction.
+type TxClient interface {
+	// Mutate sends a mutation, waiting for its response before returning, and if it succeeds records
+	// the rollback mutation, if not nil, to be sent should the transaction fail. GraphQL errors in the
+	// response are returned as by response.Err() and, like any other failure, leave the rollback
+	// unrecorded, on the basis that a failed mutation has nothing to undo.
+	Mutate(mutation *string, mutationParms *map[string]interface{}, response *QueryResponse, rollback *RollbackMutation) error
+
+	// Rollback sends the recorded rollback mutations immediately, most recent first, and forgets them.
+	// Transaction(...) calls it when the function fails, so there is rarely any need to call it
+	// directly.
+	Rollback()
+}
+
+// TransactionError is the error returned by Transaction(...) when the function failed and one or
+// more of the rollback mutations also failed, leaving the changes made by the transaction at least
+// partially in place.
+type TransactionError struct {
+	Err            error   // The error returned by the transaction function
+	RollbackErrors []error // The errors of the rollback mutations that failed, in the order they were sent
+}
+
+// Error describes the original failure and the number of rollbacks that failed.
+func (e TransactionError) Error() string {
+	return fmt.Sprintf("%v (and %d of the rollback mutations failed)", e.Err, len(e.RollbackErrors))
+}
+
+// Unwrap returns the error of the transaction function.
+func (e TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// Transaction runs the given function, which sends a sequence of mutations through the TxClient it
+// is given, registering alongside each a rollback mutation that undoes it. Should the function return
+// an error, the rollbacks of the mutations that succeeded are sent, most recent first, and the error
+// is returned. If any of the rollbacks fail, the error is returned as a TransactionError listing their
+// failures too.
+//
+// This is not a database transaction: the mutations are applied as they are sent, other clients can
+// see them before the transaction completes, and rollbacks are made on a best effort basis. It is the
+// saga pattern, with the rollbacks as compensating actions. The rollbacks are sent even if ctx has
+// been cancelled, since that is often the reason for the failure.
+func (gc *gqlClient) Transaction(ctx context.Context, fn func(tx TxClient) error) error {
+
+	tx := &txClient{client: gc, ctx: ctx}
+	err := fn(tx)
+	if err == nil {
+		return nil
+	}
+	tx.Rollback()
+	if len(tx.rollbackErrs) > 0 {
+		return TransactionError{Err: err, RollbackErrors: tx.rollbackErrs}
+	}
+	return err
+}
+
+// txClient is the TxClient implementation given to transaction functions.
+type txClient struct {
+	client       GqlClient           // The client through which the mutations are sent
+	ctx          context.Context     // The context of the transaction
+	mutex        sync.Mutex          // Keeps the mutations in order should the function send them concurrently
+	rollbacks    []*RollbackMutation // The rollbacks of the mutations that have succeeded, in the order they were sent
+	rollbackErrs []error             // The errors of any rollbacks that have failed
+}
+
+// Mutate sends a mutation and, if it succeeds, records its rollback.
+func (tx *txClient) Mutate(mutation *string, mutationParms *map[string]interface{}, response *QueryResponse, rollback *RollbackMutation) error {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	if err := tx.client.QueryContext(tx.ctx, mutation, mutationParms, response); err != nil {
+		return err
+	}
+	if err := response.Err(); err != nil {
+		return err
+	}
+	if rollback != nil {
+		tx.rollbacks = append(tx.rollbacks, rollback)
+	}
+	return nil
+}
+
+// Rollback sends the recorded rollbacks in reverse order, recording any that fail.
+func (tx *txClient) Rollback() {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
+
+	// Undo everything, carrying on past failures to undo as much as we can
+	ctx := context.WithoutCancel(tx.ctx)
+	for i := len(tx.rollbacks) - 1; i >= 0; i-- {
+		rb := tx.rollbacks[i]
+		var parms *map[string]interface{}
+		if rb.Params != nil {
+			parms = &rb.Params
+		}
+		response := QueryResponse{}
+		err := tx.client.QueryContext(ctx, &rb.Mutation, parms, &response)
+		if err == nil {
+			err = response.Err()
+		}
+		if err != nil {
+			tx.rollbackErrs = append(tx.rollbackErrs, err)
+		}
+	}
+	tx.rollbacks = nil
+}
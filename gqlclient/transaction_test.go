@@ -0,0 +1,120 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for mutation transactions.
+
+// newMutationServer returns a fake GraphQL server that records the operation names of the mutations
+// that it receives, failing those whose names contain "Fail" with a GraphQL error.
+func newMutationServer(received *[]string) *httptest.Server {
+	var mutex sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		json.NewDecoder(r.Body).Decode(&q)
+		name := firstOperationName(&q.Query)
+		mutex.Lock()
+		*received = append(*received, name)
+		mutex.Unlock()
+		if strings.Contains(name, "Fail") {
+			w.Write([]byte(`{"data":null,"errors":[{"message":"` + name + ` refused"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+}
+
+// TestTransaction confirms that the rollbacks of the successful mutations are sent in reverse order
+// when a transaction fails, and that nothing is rolled back when it succeeds.
+func TestTransaction(t *testing.T) {
+
+	var received []string
+	server := newMutationServer(&received)
+	defer server.Close()
+	client := CreateClient(server.URL, nil)
+
+	addLabel := "mutation AddLabel { addLabel { id } }"
+	addComment := "mutation AddComment { addComment { id } }"
+	failStatus := "mutation FailStatus { setStatus { id } }"
+	undoLabel := &RollbackMutation{Mutation: "mutation UndoLabel { removeLabel { id } }"}
+	undoComment := &RollbackMutation{Mutation: "mutation UndoComment($id: ID!) { removeComment(id: $id) { id } }", Params: map[string]interface{}{"id": "C1"}}
+
+	// Two mutations succeed, the third does not
+	err := client.Transaction(context.Background(), func(tx TxClient) error {
+		if err := tx.Mutate(&addLabel, nil, &QueryResponse{}, undoLabel); err != nil {
+			return err
+		}
+		if err := tx.Mutate(&addComment, nil, &QueryResponse{}, undoComment); err != nil {
+			return err
+		}
+		return tx.Mutate(&failStatus, nil, &QueryResponse{}, nil)
+	})
+	var gqlErr *GraphQLError
+	assert.True(t, errors.As(err, &gqlErr), "The failure should have been returned")
+	assert.Equal(t, "FailStatus refused", gqlErr.Message)
+	assert.Equal(t, []string{"AddLabel", "AddComment", "FailStatus", "UndoComment", "UndoLabel"}, received,
+		"The rollbacks should have been sent in reverse order")
+
+	// Where the second mutation fails, only the first is rolled back
+	received = nil
+	failComment := "mutation FailComment { addComment { id } }"
+	err = client.Transaction(context.Background(), func(tx TxClient) error {
+		if err := tx.Mutate(&addLabel, nil, &QueryResponse{}, undoLabel); err != nil {
+			return err
+		}
+		return tx.Mutate(&failComment, nil, &QueryResponse{}, undoComment)
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"AddLabel", "FailComment", "UndoLabel"}, received, "Only the first mutation should have been rolled back")
+
+	// And when all is well, nothing is rolled back
+	received = nil
+	err = client.Transaction(context.Background(), func(tx TxClient) error {
+		if err := tx.Mutate(&addLabel, nil, &QueryResponse{}, undoLabel); err != nil {
+			return err
+		}
+		return tx.Mutate(&addComment, nil, &QueryResponse{}, undoComment)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AddLabel", "AddComment"}, received, "Nothing should have been rolled back")
+}
+
+// TestTransactionRollbackFailure confirms that failed rollbacks are reported alongside the original
+// error, that the remaining rollbacks are still sent, and that they are sent despite a cancelled context.
+func TestTransactionRollbackFailure(t *testing.T) {
+
+	var received []string
+	server := newMutationServer(&received)
+	defer server.Close()
+	client := CreateClient(server.URL, nil)
+
+	first := "mutation First { a { id } }"
+	second := "mutation Second { b { id } }"
+	ctx, cancel := context.WithCancel(context.Background())
+	errGaveUp := errors.New("gave up")
+	err := client.Transaction(ctx, func(tx TxClient) error {
+		tx.Mutate(&first, nil, &QueryResponse{}, &RollbackMutation{Mutation: "mutation UndoFirst { x { id } }"})
+		tx.Mutate(&second, nil, &QueryResponse{}, &RollbackMutation{Mutation: "mutation FailUndoSecond { y { id } }"})
+		cancel()
+		return errGaveUp
+	})
+
+	var txErr TransactionError
+	assert.True(t, errors.As(err, &txErr), "A transaction error should have been returned")
+	assert.True(t, errors.Is(err, errGaveUp), "The original error should be unwrappable")
+	assert.Equal(t, 1, len(txErr.RollbackErrors))
+	assert.Equal(t, "gave up (and 1 of the rollback mutations failed)", err.Error())
+	assert.Contains(t, txErr.RollbackErrors[0].Error(), "FailUndoSecond refused")
+	assert.Equal(t, []string{"First", "Second", "FailUndoSecond", "UndoFirst"}, received,
+		"Every rollback should have been attempted")
+}
@@ -0,0 +1,271 @@
+package gqlclient
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// lookupHost resolves host names for the DNS cache of WithDNSCacheTimeout(...). It is a variable so
+// that unit tests can count the lookups made. net.DefaultResolver is consulted afresh for each lookup,
+// so that an application that replaces it, before or after creating clients, has its resolver used.
+var lookupHost = func(ctx context.Context, host string) ([]string, error) {
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+// WithDialTimeout is a ClientOption that limits the time allowed to establish each network
+// connection to the GraphQL server, independently of the overall request timeout. A short dial
+// timeout allows a client to fail fast when a server is unreachable while still allowing slow
+// responses to be read in full.
+func WithDialTimeout(d time.Duration) ClientOption {
+	return func(gc *gqlClient) {
+		gc.transport().DialContext = (&net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+}
+
+// WithResponseHeaderTimeout is a ClientOption that limits the time allowed, once a request has been
+// sent, for the server to start its response. The time taken to read the response body is not
+// included.
+func WithResponseHeaderTimeout(d time.Duration) ClientOption {
+	return func(gc *gqlClient) {
+		gc.transport().ResponseHeaderTimeout = d
+	}
+}
+
+// ErrBodyReadTimeout is the error returned from a query when the response body was not read in full
+// within the time allowed by WithBodyReadTimeout(...).
+var ErrBodyReadTimeout = errors.New("timed out reading GraphQL response body")
+
+// WithBodyReadTimeout is a ClientOption that limits the time allowed to read each response body, once
+// the response headers have arrived, independently of the time allowed to connect and for the server
+// to start its response. This allows a large response that trickles in slowly to be read in full
+// without leaving a stalled one to hang forever. The overall timeout of the client's http.Client would
+// otherwise cut the read short, so it is lifted and instead limits the wait for the response headers,
+// unless WithResponseHeaderTimeout(...) has already set a limit of its own. A query whose body read
+// times out fails with ErrBodyReadTimeout and may be retried.
+//
+// The parts of a QueryDeferred(...) response arrive over time by design and are not subject to the
+// limit.
+func WithBodyReadTimeout(d time.Duration) ClientOption {
+	return func(gc *gqlClient) {
+		t := gc.transport()
+		if t.ResponseHeaderTimeout == 0 {
+			t.ResponseHeaderTimeout = gc.httpClient.Timeout
+		}
+		gc.httpClient.Timeout = 0
+		gc.bodyTimeout = d
+	}
+}
+
+// readBody reads the whole of a response body. If a body read timeout has been set and time runs out
+// before the read is done, the body is closed from under the reader and ErrBodyReadTimeout is returned.
+// Any other read error is left for the decoding of the partial body to report.
+func (gc *gqlClient) readBody(body io.ReadCloser) ([]byte, error) {
+	if gc.bodyTimeout <= 0 {
+		content, _ := ioutil.ReadAll(body)
+		return content, nil
+	}
+
+	// Whichever of the reader and the timer finishes first settles the outcome
+	var state atomic.Int32
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-gc.clock.After(gc.bodyTimeout):
+			if state.CompareAndSwap(0, 1) {
+				body.Close()
+			}
+		case <-done:
+		}
+	}()
+	content, _ := ioutil.ReadAll(body)
+	if !state.CompareAndSwap(0, 2) {
+		return nil, ErrBodyReadTimeout
+	}
+	return content, nil
+}
+
+// WithConnReuseCallback is a ClientOption that registers a function to be told, for every request
+// sent to the GraphQL server, whether the request was sent over a pooled keep-alive connection
+// (reused is true) or over a freshly established one. A steady stream of fresh connections can
+// point to responses not being fully read, or to a connection pool that is too small for the load.
+// Any httptrace.ClientTrace already present in the query context continues to be called as well.
+func WithConnReuseCallback(callback func(reused bool)) ClientOption {
+	return func(gc *gqlClient) {
+		gc.connReuse = callback
+	}
+}
+
+// traceConnReuse returns a context that reports whether the connection obtained for a request was
+// reused, composed with any client trace that the given context already carries.
+func traceConnReuse(ctx context.Context, callback func(reused bool)) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			callback(info.Reused)
+		},
+	})
+}
+
+// WithConnectionTracing is a ClientOption that logs the progress of each request through DNS
+// resolution, TCP connection and TLS handshake at LevelDebug, with the time elapsed since the request
+// was submitted, to help tell where the time goes when queries are slow. Nothing is logged for
+// requests that reuse a pooled connection, other than that fact. The events are logged to the Logger
+// given with WithLogger(...) or WithBackgroundLogger(...); without one, this option has no effect.
+func WithConnectionTracing() ClientOption {
+	return func(gc *gqlClient) {
+		gc.connTracing = true
+	}
+}
+
+// traceConnection returns a context that logs the connection events of a request, composed with any
+// client trace that the given context already carries.
+func traceConnection(ctx context.Context, logger Logger) context.Context {
+	start := time.Now()
+	log := func(msg string, keyvals ...interface{}) {
+		logger.Log(LevelDebug, msg, append(keyvals, "elapsed", time.Since(start))...)
+	}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			log("GraphQL DNS lookup started", "host", info.Host)
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			log("GraphQL DNS lookup done", "addrs", info.Addrs, "error", info.Err)
+		},
+		ConnectStart: func(network, addr string) {
+			log("GraphQL connect started", "network", network, "addr", addr)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			log("GraphQL connect done", "network", network, "addr", addr, "error", err)
+		},
+		TLSHandshakeStart: func() {
+			log("GraphQL TLS handshake started")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			log("GraphQL TLS handshake done", "version", tls.VersionName(state.Version), "error", err)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			log("GraphQL connection obtained", "reused", info.Reused)
+		},
+	})
+}
+
+// WithDNSCacheTimeout is a ClientOption that has the client remember the addresses that the GraphQL
+// server's host name resolves to for the given duration, rather than looking them up afresh for every
+// new connection. This saves time where connections are frequently established, e.g. in serverless
+// functions, at the cost of being slow to notice DNS changes. Failed lookups are not cached. Each
+// client has a cache of its own, and a clone starts with an empty one. Entries expire by the client's
+// clock, so a fake clock given with WithClock(...) governs them too.
+//
+// The cache wraps whatever dialer the client's transport has when the option is applied, so it should
+// be given after WithDialTimeout(...) if both are used.
+func WithDNSCacheTimeout(d time.Duration) ClientOption {
+	return func(gc *gqlClient) {
+		t := gc.transport()
+		dial := t.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		gc.dnsCache = &dnsCache{ttl: d, connect: dial, entries: make(map[string]dnsEntry)}
+		t.DialContext = gc.cachedDial
+	}
+}
+
+// cachedDial is the DialContext function of a client with a DNS cache, resolving host names through
+// the cache and measuring the age of its entries against the client's clock.
+func (gc *gqlClient) cachedDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return gc.dnsCache.dial(ctx, gc.clock, network, addr)
+}
+
+// dnsCache holds the addresses that host names have resolved to until they expire.
+type dnsCache struct {
+	ttl     time.Duration                                                     // How long addresses are remembered
+	connect func(ctx context.Context, network, addr string) (net.Conn, error) // Connects to the resolved addresses
+	mutex   sync.Mutex                                                        // Guards the entries
+	entries map[string]dnsEntry                                               // The cached addresses, keyed by host name
+}
+
+// fresh returns a new, empty cache with the same timeout and dialer.
+func (c *dnsCache) fresh() *dnsCache {
+	return &dnsCache{ttl: c.ttl, connect: c.connect, entries: make(map[string]dnsEntry)}
+}
+
+// dnsEntry is the cached result of a single host name lookup.
+type dnsEntry struct {
+	addrs   []string  // The addresses that the host resolved to
+	expires time.Time // When the addresses must be looked up again
+}
+
+// dial connects to the given host:port address, resolving the host through the cache and trying
+// each of its addresses in turn until one accepts the connection.
+func (c *dnsCache) dial(ctx context.Context, clock Clock, network, addr string) (net.Conn, error) {
+
+	// Addresses that are already IP addresses have nothing to resolve
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return c.connect(ctx, network, addr)
+	}
+	addrs, err := c.lookup(ctx, clock, host)
+	if err != nil {
+		return nil, err
+	}
+
+	// Connect to the first address that will have us
+	for _, ip := range addrs {
+		var conn net.Conn
+		if conn, err = c.connect(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// lookup returns the addresses of the host, from the cache if they have not expired.
+func (c *dnsCache) lookup(ctx context.Context, clock Clock, host string) ([]string, error) {
+
+	c.mutex.Lock()
+	entry, ok := c.entries[host]
+	c.mutex.Unlock()
+	if ok && clock.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: clock.Now().Add(c.ttl)}
+	c.mutex.Unlock()
+	return addrs, nil
+}
+
+// transport returns the client's own HTTP transport, giving the client an http.Client and transport
+// of its own, copied from the package defaults, if it does not already have them. Options that
+// configure the transport use this so as not to disturb other clients.
+func (gc *gqlClient) transport() *http.Transport {
+
+	// Give the client its own http.Client if it is still using the shared one
+	if gc.httpClient == nil {
+		gc.httpClient = &http.Client{Timeout: httpClient.Timeout}
+	}
+
+	// And its own transport, if it does not have one we can adjust
+	t, ok := gc.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t = http.DefaultTransport.(*http.Transport).Clone()
+		gc.httpClient.Transport = t
+	}
+	return t
+}
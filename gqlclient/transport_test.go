@@ -0,0 +1,236 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the transport options.
+
+// TestDialTimeout confirms that a short dial timeout fails fast against an unreachable host.
+func TestDialTimeout(t *testing.T) {
+
+	// 10.255.255.1 is a private address that should never answer
+	client := CreateClient("http://10.255.255.1:81/graphql", nil, WithDialTimeout(100*time.Millisecond))
+	start := time.Now()
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.NotNil(t, err, "Query to an unreachable host should have failed")
+	assert.True(t, time.Since(start) < 2*time.Second, "Query should have failed fast, not after %v", time.Since(start))
+
+	// The shared client should not have been touched
+	assert.Nil(t, httpClient.Transport, "The package http client should not have been changed")
+}
+
+// TestResponseHeaderTimeout confirms that a slow server is abandoned once the header timeout expires.
+func TestResponseHeaderTimeout(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithResponseHeaderTimeout(50*time.Millisecond))
+	start := time.Now()
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.NotNil(t, err, "Query to a slow server should have timed out")
+	assert.True(t, time.Since(start) < 400*time.Millisecond, "Query should have timed out quickly, not after %v", time.Since(start))
+
+	// Both options together should share the one transport
+	gc := CreateClient(server.URL, nil, WithDialTimeout(time.Second), WithResponseHeaderTimeout(time.Second)).(*gqlClient)
+	transport := gc.httpClient.Transport.(*http.Transport)
+	assert.Equal(t, time.Second, transport.ResponseHeaderTimeout)
+	assert.NotNil(t, transport.DialContext)
+}
+
+// TestCloneTransport confirms that transport options applied to a clone do not alter the original.
+func TestCloneTransport(t *testing.T) {
+
+	original := CreateClient("http://localhost", nil, WithResponseHeaderTimeout(time.Second))
+	clone, err := Clone(original, WithResponseHeaderTimeout(time.Minute))
+	assert.Nil(t, err, "Clone should have succeeded")
+
+	originalTransport := original.(*gqlClient).httpClient.Transport.(*http.Transport)
+	cloneTransport := clone.(*gqlClient).httpClient.Transport.(*http.Transport)
+	assert.Equal(t, time.Second, originalTransport.ResponseHeaderTimeout, "The original should keep its timeout")
+	assert.Equal(t, time.Minute, cloneTransport.ResponseHeaderTimeout, "The clone should have its own timeout")
+}
+
+// TestConnReuseCallback confirms that the callback is told of a fresh connection for the first
+// query and a reused one for the second, and that a trace already in the context still works.
+func TestConnReuseCallback(t *testing.T) {
+
+	// httptest servers keep connections alive by default
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	var reuses []bool
+	client := CreateClient(server.URL, nil, WithConnReuseCallback(func(reused bool) {
+		reuses = append(reuses, reused)
+	}))
+
+	// Run two queries in turn, the second with a trace of its own
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: new(SimpleRepoDataResponse)})
+	assert.Nil(t, err, "First query should have succeeded")
+	callerTraced := false
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			callerTraced = true
+		},
+	})
+	err = client.QueryContext(ctx, &SimpleRepoDataQuery, nil, &QueryResponse{Data: new(SimpleRepoDataResponse)})
+	assert.Nil(t, err, "Second query should have succeeded")
+
+	assert.Equal(t, []bool{false, true}, reuses, "The second query should have reused the connection")
+	assert.True(t, callerTraced, "The caller's own trace should still have been called")
+}
+
+// TestConnectionTracing confirms that DNS, connect and TLS events are logged for a fresh connection.
+func TestConnectionTracing(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"repository":{"name":"gogql","owner":{"login":"mikebway"}}}}`))
+	}))
+	defer server.Close()
+
+	// Address the server by name, so that there is something to look up, trusting its certificate
+	// under one of the names it was issued for
+	logger := &capturingLogger{}
+	serverURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	gc := CreateClient(serverURL, nil, WithLogger(logger), WithConnectionTracing()).(*gqlClient)
+	gc.httpClient = server.Client()
+	gc.httpClient.Transport.(*http.Transport).TLSClientConfig.ServerName = "example.com"
+
+	err := gc.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: new(SimpleRepoDataResponse)})
+	assert.Nil(t, err, "Query should have succeeded")
+
+	// Every stage of the connection should have been logged, in order
+	var messages []string
+	for _, entry := range logger.snapshot() {
+		if entry.level == LevelDebug {
+			messages = append(messages, entry.msg)
+		}
+	}
+	assert.Equal(t, []string{
+		"GraphQL DNS lookup started",
+		"GraphQL DNS lookup done",
+		"GraphQL connect started",
+		"GraphQL connect done",
+		"GraphQL TLS handshake started",
+		"GraphQL TLS handshake done",
+		"GraphQL connection obtained",
+	}, messages)
+
+	// Without a logger, tracing has nothing to do and should do no harm
+	gc = CreateClient(server.URL, nil, WithConnectionTracing()).(*gqlClient)
+	gc.httpClient = server.Client()
+	err = gc.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query without a logger should have succeeded")
+}
+
+// TestDNSCacheTimeout confirms that host names are looked up once per cache timeout, however many
+// connections are made, and that failed lookups are not remembered.
+func TestDNSCacheTimeout(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// Resolve our made up host name to the test server, counting the lookups, and failing the
+	// first lookup of another
+	defer func(original func(ctx context.Context, host string) ([]string, error)) { lookupHost = original }(lookupHost)
+	lookups := map[string]int{}
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		lookups[host]++
+		if host == "flaky.gogql.test" && lookups[host] == 1 {
+			return nil, errors.New("temporary DNS failure")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	// Two queries within the timeout, each on a fresh connection, need only one lookup
+	clock := &fakeClock{now: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)}
+	client := CreateClient("http://cached.gogql.test:"+port, nil, WithDNSCacheTimeout(time.Minute), WithClock(clock))
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	client.Close()
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	client.Close()
+	assert.Equal(t, 1, lookups["cached.gogql.test"], "The second connection should have used the cached address")
+
+	// Once the timeout has passed by the client's clock, the name is looked up again
+	clock.After(90 * time.Second)
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	client.Close()
+	assert.Equal(t, 2, lookups["cached.gogql.test"], "The expired address should have been looked up again")
+
+	// A clone has a cache of its own, starting empty, and leaves the original's alone
+	clone, _ := Clone(client)
+	assert.Nil(t, clone.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	clone.Close()
+	assert.Equal(t, 3, lookups["cached.gogql.test"], "The clone should have looked the name up for itself")
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+	client.Close()
+	assert.Equal(t, 3, lookups["cached.gogql.test"], "The original should still have had the address cached")
+
+	// A failed lookup is reported, but not remembered
+	client = CreateClient("http://flaky.gogql.test:"+port, nil, WithDNSCacheTimeout(time.Minute))
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.NotNil(t, err, "The failed lookup should have been reported")
+	assert.Contains(t, err.Error(), "temporary DNS failure")
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}), "The lookup should have been tried again")
+	assert.Equal(t, 2, lookups["flaky.gogql.test"])
+}
+
+// TestBodyReadTimeout confirms that a slowly streamed body is read in full within the body read
+// timeout, even if it takes longer than the client's overall timeout, and that one which takes too
+// long is abandoned.
+func TestBodyReadTimeout(t *testing.T) {
+
+	// A server that sends its headers at once and then trickles out the body
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range []string{`{"data":`, `{"repository":`, `{"name":"gogql"}`, `}}`} {
+			w.Write([]byte(chunk))
+			flusher.Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	// Shorten the overall timeout so that it would cut the read short if it still applied
+	savedClient := httpClient
+	httpClient = &http.Client{Timeout: 100 * time.Millisecond}
+	defer func() { httpClient = savedClient }()
+	assert.NotNil(t, CreateClient(server.URL, nil).Query(&SimpleRepoDataQuery, nil, &QueryResponse{}),
+		"The overall timeout should have cut the read short")
+
+	// A generous body read timeout lets the whole body arrive, keeping the overall timeout for the headers
+	gc := CreateClient(server.URL, nil, WithBodyReadTimeout(2*time.Second)).(*gqlClient)
+	assert.Equal(t, time.Duration(0), gc.httpClient.Timeout, "The overall timeout should have been lifted")
+	assert.Equal(t, 100*time.Millisecond, gc.httpClient.Transport.(*http.Transport).ResponseHeaderTimeout,
+		"The overall timeout should have been kept for the headers")
+	data := SimpleRepoDataResponse{}
+	assert.Nil(t, gc.Query(&SimpleRepoDataQuery, nil, &QueryResponse{Data: &data}), "The body should have been read in full")
+	assert.Equal(t, "gogql", data.Repository.Name)
+
+	// A short one gives up on the body part way through
+	client := CreateClient(server.URL, nil, WithBodyReadTimeout(75*time.Millisecond))
+	start := time.Now()
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.True(t, errors.Is(err, ErrBodyReadTimeout), "The body read should have timed out: %v", err)
+	assert.True(t, time.Since(start) < 180*time.Millisecond, "Query should have timed out quickly, not after %v", time.Since(start))
+}
@@ -0,0 +1,75 @@
+package gqlclient
+
+// WithAutoInjectTypename is a ClientOption that adds __typename to every selection set of every query
+// sent by the client, other than the root selection set of a subscription where only a single field
+// is allowed. This lets callers unmarshalling union and interface types tell which concrete type
+// each object is without remembering to ask for it. Selection sets that already select __typename
+// are left alone, and documents that cannot be understood are sent unchanged for the server to judge.
+func WithAutoInjectTypename() ClientOption {
+	return func(gc *gqlClient) {
+		gc.transforms = append(gc.transforms, injectTypenameTransform)
+	}
+}
+
+// injectTypenameTransform is the QueryTransform that does the work of WithAutoInjectTypename().
+func injectTypenameTransform(packed string) (string, error) {
+	result, _ := injectTypename(packed)
+	return result, nil
+}
+
+// injectTypename adds __typename to every selection set in the document that does not already
+// select it, except for the root selection sets of subscriptions. If the document cannot be
+// understood, it is returned unchanged along with the error.
+func injectTypename(doc string) (string, error) {
+
+	tokens, err := tokenize(doc)
+	if err != nil {
+		return doc, err
+	}
+	defs, err := definitions(tokens)
+	if err != nil {
+		return doc, err
+	}
+
+	// Subscriptions may only select a single root field
+	skip := make(map[int]bool)
+	for _, def := range defs {
+		if def.keyword == "subscription" {
+			skip[def.openBrace] = true
+		}
+	}
+
+	// Every brace outside of parentheses opens a selection set; those inside parentheses belong
+	// to object values in arguments or variable defaults
+	var inserts []int
+	parens := 0
+	for i, t := range tokens {
+		if t.kind != punctuatorToken {
+			continue
+		}
+		switch t.text {
+		case "(":
+			parens++
+		case ")":
+			parens--
+		case "{":
+			if parens > 0 || skip[i] {
+				continue
+			}
+			end, err := matchBrace(tokens, i)
+			if err != nil {
+				return doc, err
+			}
+			if !hasTopLevelField(tokens, i, end, "__typename") {
+				inserts = append(inserts, t.pos+1)
+			}
+		}
+	}
+
+	// Work from the end of the document back so that earlier offsets remain valid
+	for i := len(inserts) - 1; i >= 0; i-- {
+		at := inserts[i]
+		doc = doc[:at] + " __typename" + doc[at:]
+	}
+	return doc, nil
+}
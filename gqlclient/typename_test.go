@@ -0,0 +1,57 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for __typename injection.
+
+// TestInjectTypename exercises the injection directly over nested selection sets, fragments, object
+// arguments, string literals and selection sets that already have __typename.
+func TestInjectTypename(t *testing.T) {
+
+	// Nested selection sets, one of which already has __typename
+	result, err := injectTypename(`query { repository(owner: "a { b }") { owner { __typename login } issues { nodes { title } } } }`)
+	assert.Nil(t, err, "Injection should have succeeded")
+	assert.Equal(t, `query { __typename repository(owner: "a { b }") { __typename owner { __typename login } issues { __typename nodes { __typename title } } } }`, result)
+
+	// Object values in arguments and variable defaults are not selection sets
+	result, err = injectTypename(`query Q($f: Filter = {a: 1}) { search(filter: {b: {c: 2}}) { ... on Repo { name } ...F } } fragment F on User { login }`)
+	assert.Nil(t, err, "Injection should have succeeded")
+	assert.Equal(t, `query Q($f: Filter = {a: 1}) { __typename search(filter: {b: {c: 2}}) { __typename ... on Repo { __typename name } ...F } } `+
+		`fragment F on User { __typename login }`, result)
+
+	// The root of a subscription must be left with its single field
+	result, err = injectTypename(`subscription { updates { id } }`)
+	assert.Nil(t, err, "Injection should have succeeded")
+	assert.Equal(t, `subscription { updates { __typename id } }`, result)
+
+	// A malformed document is returned as is along with an error
+	result, err = injectTypename(`query { viewer { login }`)
+	assert.NotNil(t, err, "Unbalanced document should be reported")
+	assert.Equal(t, `query { viewer { login }`, result)
+}
+
+// TestAutoInjectTypename confirms that the option injects __typename into the queries sent.
+func TestAutoInjectTypename(t *testing.T) {
+
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q query
+		json.NewDecoder(r.Body).Decode(&q)
+		sent = q.Query
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := CreateClient(server.URL, nil, WithAutoInjectTypename())
+	err := client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{})
+	assert.Nil(t, err, "Query should have succeeded")
+	assert.Equal(t, `query FetchRepoInfo($owner: String!, $name: String!) { __typename repository(owner: $owner, name: $name) `+
+		`{ __typename name owner { __typename login } } }`, sent)
+}
@@ -0,0 +1,251 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Upload is a file to be sent to a GraphQL server that supports the GraphQL multipart request
+// specification (https://github.com/jaydenseric/graphql-multipart-request-spec). An *Upload may be
+// given as the value of any query variable, including within nested maps and slices, when the query
+// is made with UploadQuery(...).
+type Upload struct {
+	Filename    string    // The file name to report to the server
+	ContentType string    // The MIME type of the file, application/octet-stream if empty
+	Reader      io.Reader // The source of the file content
+}
+
+// UploadProgressFunc is a function that is told how many bytes of an upload request have been sent
+// so far and the total size of the request, or -1 if the total cannot be known in advance.
+type UploadProgressFunc func(bytesSent, totalBytes int64)
+
+// WithUploadProgress is a ClientOption that registers a function to be called repeatedly as the
+// body of an UploadQuery(...) request is streamed to the server. The total is only known if the
+// size of every Upload reader can be determined, i.e. if each is a *bytes.Reader, *strings.Reader,
+// *bytes.Buffer, *os.File or similar; otherwise -1 is reported as the total.
+func WithUploadProgress(progress UploadProgressFunc) ClientOption {
+	return func(gc *gqlClient) {
+		gc.uploadProgress = progress
+	}
+}
+
+// UploadQuery sends a GraphQL query or mutation whose variables include one or more *Upload values
+// as a multipart request, parsing the response into the provided object reference. The file
+// content is streamed rather than buffered, so uploads are not retried even if WithRetry(...) has
+// been configured.
+func (gc *gqlClient) UploadQuery(ctx context.Context, queryStr *string, queryParms *map[string]interface{}, response *QueryResponse) error {
+
+	// Separate the files from the rest of the variables
+	packed, err := gc.prepareQuery(ctx, queryStr)
+	if err != nil {
+		return err
+	}
+	operationName, err := gc.operationName(ctx, packed)
+	if err != nil {
+		return err
+	}
+	ctx = gc.roundTripContext(ctx, packed)
+	q := query{Query: packed, OperationName: operationName}
+	var uploads []*Upload
+	fileMap := make(map[string][]string)
+	if queryParms != nil {
+		q.Variables = extractUploads(*queryParms, "variables", &uploads, fileMap).(map[string]interface{})
+	}
+	if err := gc.checkVariables(&q.Variables); err != nil {
+		return err
+	}
+	operations, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	if err := gc.checkRequestSize(operations); err != nil {
+		return err
+	}
+	mapping, err := json.Marshal(fileMap)
+	if err != nil {
+		return err
+	}
+
+	// Work out how big the request will be, if we can, by writing all but the file content to
+	// a counter using the same boundary that the real request will use
+	counter := &countingWriter{}
+	mw := multipart.NewWriter(counter)
+	total := int64(0)
+	if err := writeUploadParts(mw, operations, mapping, uploads, false); err != nil {
+		return err
+	}
+	for _, u := range uploads {
+		size := readerSize(u.Reader)
+		if size < 0 {
+			total = -1
+			break
+		}
+		total += size
+	}
+	if total >= 0 {
+		total += counter.n
+	}
+
+	// Stream the real request body through a pipe, reporting progress as the transport reads it
+	pr, pw := io.Pipe()
+	boundary := mw.Boundary()
+	go func() {
+		mw := multipart.NewWriter(pw)
+		mw.SetBoundary(boundary)
+		pw.CloseWithError(writeUploadParts(mw, operations, mapping, uploads, true))
+	}()
+	var body io.Reader = pr
+	if gc.uploadProgress != nil {
+		body = &progressReader{reader: pr, total: total, progress: gc.uploadProgress}
+	}
+
+	// Form up the HTTP POST request and send it
+	req, err := http.NewRequest("POST", gc.endpoint(), body)
+	if err != nil {
+		pr.Close()
+		return err
+	}
+	req = req.WithContext(ctx)
+	if total >= 0 {
+		req.ContentLength = total
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	authorization := gc.authHeader()
+	_, err = gc.send(req, response)
+	pr.Close()
+
+	// The upload cannot be replayed but, if our token was rejected, we can at least make sure that
+	// the next request goes out with a fresh one
+	var authErr AuthError
+	if gc.authRefresh != nil && errors.As(err, &authErr) {
+		if refreshErr := gc.refreshAuth(ctx, authorization); refreshErr != nil {
+			return refreshErr
+		}
+	}
+	return err
+}
+
+// extractUploads returns a copy of the given variable value with any *Upload values replaced by
+// nil, appending the uploads to the list and recording their paths in the file map as required
+// by the multipart request specification.
+func extractUploads(value interface{}, path string, uploads *[]*Upload, fileMap map[string][]string) interface{} {
+	switch v := value.(type) {
+	case *Upload:
+		key := strconv.Itoa(len(*uploads))
+		*uploads = append(*uploads, v)
+		fileMap[key] = []string{path}
+		return nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			result[k] = extractUploads(item, path+"."+k, uploads, fileMap)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = extractUploads(item, path+"."+strconv.Itoa(i), uploads, fileMap)
+		}
+		return result
+	case []*Upload:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = extractUploads(item, path+"."+strconv.Itoa(i), uploads, fileMap)
+		}
+		return result
+	}
+	return value
+}
+
+// writeUploadParts writes the parts of a multipart request: the operations, the file map and then
+// the files themselves. If withContent is false, the file parts are written without their content.
+func writeUploadParts(mw *multipart.Writer, operations, mapping []byte, uploads []*Upload, withContent bool) error {
+	if err := mw.WriteField("operations", string(operations)); err != nil {
+		return err
+	}
+	if err := mw.WriteField("map", string(mapping)); err != nil {
+		return err
+	}
+	for i, u := range uploads {
+		contentType := u.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, escapeQuotes(u.Filename)))
+		header.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if withContent {
+			if _, err := io.Copy(part, u.Reader); err != nil {
+				return err
+			}
+		}
+	}
+	return mw.Close()
+}
+
+// escapeQuotes escapes backslashes and double quotes for use in a Content-Disposition header.
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// readerSize returns the number of bytes remaining to be read from the reader, or -1 if that
+// cannot be determined.
+func readerSize(r io.Reader) int64 {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return int64(v.Len())
+	case *os.File:
+		info, err := v.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := v.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	}
+	return -1
+}
+
+// countingWriter is an io.Writer that discards what it is given, counting the bytes.
+type countingWriter struct {
+	n int64
+}
+
+// Write counts and discards the bytes.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// progressReader is an io.Reader that reports progress as its underlying reader is consumed.
+type progressReader struct {
+	reader   io.Reader          // The source of the bytes
+	sent     int64              // The number of bytes read so far
+	total    int64              // The total number of bytes expected, or -1
+	progress UploadProgressFunc // The function to report progress to
+}
+
+// Read reads from the underlying reader, reporting any progress made.
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.progress(r.sent, r.total)
+	}
+	return n, err
+}
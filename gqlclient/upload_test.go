@@ -0,0 +1,116 @@
+package gqlclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for multipart uploads.
+
+// The mutation used to exercise uploads
+var uploadMutation = `mutation Attach($id: ID!, $file: Upload!, $extras: [Upload!]!) {
+	attach(id: $id, file: $file, extras: $extras) {
+		ok
+	}
+}`
+
+// newUploadServer returns a fake server that checks that uploads arrive according to the multipart
+// request specification, echoing the content of each file back in the response data.
+func newUploadServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data; boundary="))
+		assert.Nil(t, r.ParseMultipartForm(1<<20), "Request should have been valid multipart form data")
+
+		// The operations should have nulls in place of the files
+		var q query
+		assert.Nil(t, json.Unmarshal([]byte(r.FormValue("operations")), &q))
+		assert.Equal(t, "mutation Attach($id: ID!, $file: Upload!, $extras: [Upload!]!) { attach(id: $id, file: $file, extras: $extras) { ok } }", q.Query)
+		assert.Equal(t, map[string]interface{}{"id": "abc", "file": nil, "extras": []interface{}{nil}}, q.Variables)
+
+		// And the map should tell us where they go
+		var fileMap map[string][]string
+		assert.Nil(t, json.Unmarshal([]byte(r.FormValue("map")), &fileMap))
+
+		// Echo back what we got
+		data := make(map[string]string)
+		for key, paths := range fileMap {
+			f, header, err := r.FormFile(key)
+			assert.Nil(t, err, "File %s should have been present", key)
+			content, _ := ioutil.ReadAll(f)
+			data[paths[0]] = header.Filename + ":" + string(content)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+// TestUploadProgress confirms that files are uploaded and that progress is reported with a known total.
+func TestUploadProgress(t *testing.T) {
+
+	server := newUploadServer(t)
+	defer server.Close()
+
+	// Record the progress reports
+	var sent, totals []int64
+	client := CreateClient(server.URL, nil, WithUploadProgress(func(bytesSent, totalBytes int64) {
+		sent = append(sent, bytesSent)
+		totals = append(totals, totalBytes)
+	}))
+
+	// Make the first file big enough to need several reads
+	big := strings.Repeat("0123456789", 10000)
+	parms := map[string]interface{}{
+		"id":     "abc",
+		"file":   &Upload{Filename: "big.txt", Reader: strings.NewReader(big)},
+		"extras": []*Upload{{Filename: "small.txt", ContentType: "text/plain", Reader: strings.NewReader("tiny")}},
+	}
+	data := make(map[string]string)
+	response := QueryResponse{Data: &data}
+	err := client.UploadQuery(context.Background(), &uploadMutation, &parms, &response)
+	assert.Nil(t, err, "Upload should have succeeded")
+	assert.Equal(t, "big.txt:"+big, data["variables.file"], "The big file should have arrived intact")
+	assert.Equal(t, "small.txt:tiny", data["variables.extras.0"], "The small file should have arrived intact")
+
+	// Progress should have increased steadily to the known total
+	assert.True(t, len(sent) > 1, "Progress should have been reported more than once")
+	for i := 1; i < len(sent); i++ {
+		assert.True(t, sent[i] > sent[i-1], "Progress should always increase")
+	}
+	total := totals[0]
+	assert.True(t, total > int64(len(big)), "Total should include the file content and multipart overhead")
+	assert.Equal(t, total, sent[len(sent)-1], "Final progress should equal the total")
+}
+
+// TestUploadProgressUnknownTotal confirms that -1 is reported when a file size cannot be known.
+func TestUploadProgressUnknownTotal(t *testing.T) {
+
+	server := newUploadServer(t)
+	defer server.Close()
+
+	var totals []int64
+	client := CreateClient(server.URL, nil, WithUploadProgress(func(bytesSent, totalBytes int64) {
+		totals = append(totals, totalBytes)
+	}))
+
+	// A MultiReader hides the size of what it wraps
+	parms := map[string]interface{}{
+		"id":     "abc",
+		"file":   &Upload{Filename: "stream.txt", Reader: io.MultiReader(strings.NewReader("streamed"))},
+		"extras": []interface{}{&Upload{Filename: "small.txt", Reader: strings.NewReader("tiny")}},
+	}
+	data := make(map[string]string)
+	err := client.UploadQuery(context.Background(), &uploadMutation, &parms, &QueryResponse{Data: &data})
+	assert.Nil(t, err, "Upload should have succeeded")
+	assert.Equal(t, "stream.txt:streamed", data["variables.file"])
+	assert.True(t, len(totals) > 0, "Progress should have been reported")
+	for _, total := range totals {
+		assert.Equal(t, int64(-1), total, "Total should be reported as unknown")
+	}
+}
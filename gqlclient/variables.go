@@ -0,0 +1,54 @@
+package gqlclient
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// VariableConflictError is the error returned by MergeVariables(...) when two or more of the maps
+// give different values for the same variable.
+type VariableConflictError struct {
+	Names []string // The names of the conflicting variables, sorted
+}
+
+// Error lists the conflicting variables.
+func (e VariableConflictError) Error() string {
+	return "conflicting values given for GraphQL variables: " + strings.Join(e.Names, ", ")
+}
+
+// MergeVariables combines the given maps of query variables into a new map, for queries whose
+// variables are assembled from several sources. Unlike copying the maps over one another, merging
+// never loses a value silently: should two maps give the same variable different values, a
+// VariableConflictError naming every such variable is returned along with the merged map, in which
+// the first value given for each conflicting variable is kept. Values are compared with
+// reflect.DeepEqual, so a variable given the same value by more than one map is not a conflict.
+//
+// Nil maps are skipped, and the maps themselves are left untouched.
+func MergeVariables(maps ...map[string]interface{}) (map[string]interface{}, error) {
+
+	// Copy every variable across, noting those given a different value to the one we already have
+	merged := make(map[string]interface{})
+	conflicts := make(map[string]bool)
+	for _, vars := range maps {
+		for name, value := range vars {
+			existing, present := merged[name]
+			if !present {
+				merged[name] = value
+			} else if !reflect.DeepEqual(existing, value) {
+				conflicts[name] = true
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return merged, nil
+	}
+
+	// List the conflicts in a predictable order
+	names := make([]string, 0, len(conflicts))
+	for name := range conflicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return merged, VariableConflictError{Names: names}
+}
@@ -0,0 +1,40 @@
+package gqlclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for merging query variables.
+
+// TestMergeVariables confirms that compatible maps merge cleanly and that conflicting values are
+// reported rather than overwritten.
+func TestMergeVariables(t *testing.T) {
+
+	// Maps that agree wherever they overlap should merge without complaint
+	paging := map[string]interface{}{"first": 10, "after": nil}
+	repo := map[string]interface{}{"owner": "mikebway", "name": "gogql", "first": 10}
+	merged, err := MergeVariables(paging, nil, repo)
+	assert.Nil(t, err, "Compatible maps should have merged")
+	assert.Equal(t, map[string]interface{}{"first": 10, "after": nil, "owner": "mikebway", "name": "gogql"}, merged)
+
+	// Nested values are compared by content
+	filter := func() map[string]interface{} {
+		return map[string]interface{}{"filter": map[string]interface{}{"states": []interface{}{"OPEN"}}}
+	}
+	_, err = MergeVariables(filter(), filter())
+	assert.Nil(t, err, "Equal nested values should not conflict")
+
+	// Differing values should be reported, keeping the first
+	override := map[string]interface{}{"first": 50, "name": "other", "owner": "mikebway"}
+	merged, err = MergeVariables(paging, repo, override)
+	var conflict VariableConflictError
+	assert.True(t, errors.As(err, &conflict), "The conflicts should have been reported")
+	assert.Equal(t, []string{"first", "name"}, conflict.Names)
+	assert.Equal(t, "conflicting values given for GraphQL variables: first, name", err.Error())
+	assert.Equal(t, 10, merged["first"], "The first value given should have been kept")
+	assert.Equal(t, "gogql", merged["name"], "The first value given should have been kept")
+	assert.Equal(t, 10, repo["first"], "The maps given should have been left untouched")
+}
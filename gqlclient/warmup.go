@@ -0,0 +1,62 @@
+package gqlclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// warmupTimeout is the time allowed for all of the warmup queries of a client to complete.
+const warmupTimeout = 5 * time.Second
+
+// WarmupQuery is a query to be sent when a client is created by a client configured with WithWarmup(...).
+type WarmupQuery struct {
+	QueryStr string                 // The query to send
+	Params   map[string]interface{} // The variables of the query, may be nil
+}
+
+// WithWarmup is a ClientOption that has the client send the given queries as soon as it has been
+// created, to prime the caches of GraphQL servers that are slow to answer their first queries. The
+// queries are sent concurrently in the background so that CreateClient(...) returns without waiting
+// for them, and are abandoned if they have not all completed within five seconds. Their results are
+// discarded. Failures are reported at LevelWarn to the client's Logger, if it has been given one with
+// WithLogger(...) or WithBackgroundLogger(...), but otherwise ignored.
+//
+// Warmup queries bypass any middleware, so they are not logged, measured or traced as ordinary
+// queries would be.
+func WithWarmup(queries []WarmupQuery) ClientOption {
+	return func(gc *gqlClient) {
+		gc.warmup = append(gc.warmup, queries...)
+	}
+}
+
+// warmUp sends all of the warmup queries concurrently, waiting for them all to complete, for the
+// warmup timeout to expire, or for the given context to be cancelled by Close().
+func (gc *gqlClient) warmUp(ctx context.Context) {
+
+	ctx, cancel := context.WithTimeout(ctx, warmupTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, q := range gc.warmup {
+		wg.Add(1)
+		go func(q WarmupQuery) {
+			defer wg.Done()
+
+			// Send the query, taking any GraphQL reported errors as failures too
+			var queryParms *map[string]interface{}
+			if q.Params != nil {
+				queryParms = &q.Params
+			}
+			response := QueryResponse{}
+			err := gc.query(ctx, &q.QueryStr, queryParms, &response)
+			if err == nil {
+				err = response.Err()
+			}
+			if err != nil && gc.logger != nil {
+				gc.logger.Log(LevelWarn, "GraphQL warmup query failed", "operation", firstOperationName(&q.QueryStr), "error", err)
+			}
+		}(q)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,68 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for warmup queries.
+
+// TestWarmup confirms that warmup queries are sent in the background when a client is created and
+// that their failures are logged rather than preventing the client from being returned.
+func TestWarmup(t *testing.T) {
+
+	// Record the queries received, failing the one that asks for it. The warmup queries are held
+	// up until the client has been returned to prove that they run in the background.
+	var mutex sync.Mutex
+	var received []string
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mutex.Lock()
+		received = append(received, body.Query)
+		mutex.Unlock()
+		if body.Variables["fail"] == true {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer server.Close()
+
+	logger := &capturingLogger{}
+	client := CreateClient(server.URL, nil, WithLogger(logger), WithWarmup([]WarmupQuery{
+		{QueryStr: "query WarmA { viewer { login } }"},
+		{QueryStr: "query WarmB { viewer { name } }", Params: map[string]interface{}{"fail": true}},
+	}))
+	assert.NotNil(t, client, "The client should have been returned without waiting for the warmup")
+	close(release)
+
+	// Both queries should arrive and the failure should be logged
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == 2 && len(logger.snapshot()) == 1
+	}, 5*time.Second, 10*time.Millisecond, "Both warmup queries should have been sent and the failure logged")
+	mutex.Lock()
+	assert.ElementsMatch(t, []string{"query WarmA { viewer { login } }", "query WarmB { viewer { name } }"}, received)
+	mutex.Unlock()
+
+	entry := logger.snapshot()[0]
+	assert.Equal(t, LevelWarn, entry.level)
+	assert.Equal(t, "GraphQL warmup query failed", entry.msg)
+	assert.Equal(t, []interface{}{"operation", "WarmB"}, entry.keyvals[:2])
+
+	// The client should work normally
+	assert.Nil(t, client.Query(&SimpleRepoDataQuery, nil, &QueryResponse{}))
+}
@@ -0,0 +1,30 @@
+/*
+Package gqlscalar provides helpers for formatting Go values as the strings expected by common
+GraphQL custom scalar types, such as github's DateTime and Date.
+*/
+package gqlscalar
+
+import "time"
+
+// now is the source of the current time, a variable so that unit tests can fix it.
+var now = time.Now
+
+// GraphQLNow returns the current time, in UTC, formatted as an RFC3339 DateTime scalar string,
+// e.g. "2019-06-01T19:07:06Z".
+func GraphQLNow() string {
+	return now().UTC().Format(time.RFC3339)
+}
+
+// GraphQLDate returns the date of the given time, in UTC, formatted as an ISO-8601 Date scalar
+// string, e.g. "2019-06-01". Note that the conversion to UTC may change the date: 9pm on the first
+// of June in New York is already the second of June in UTC.
+func GraphQLDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// GraphQLDateTimeMidnight returns the very start of the UTC day of the given time formatted as an
+// RFC3339 DateTime scalar string, e.g. "2019-06-01T00:00:00Z". This is handy for the bounds of
+// date range filters on fields that only accept a DateTime.
+func GraphQLDateTimeMidnight(t time.Time) string {
+	return t.UTC().Truncate(24 * time.Hour).Format(time.RFC3339)
+}
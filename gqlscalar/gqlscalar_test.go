@@ -0,0 +1,39 @@
+package gqlscalar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This file defines unit tests for the gqlscalar package
+
+// newYork is a time zone well behind UTC, so that UTC conversion changes the date in the evening
+var newYork = time.FixedZone("EDT", -4*60*60)
+
+// TestGraphQLNow confirms that the current time is formatted in UTC.
+func TestGraphQLNow(t *testing.T) {
+
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time {
+		return time.Date(2019, 6, 1, 21, 7, 6, 123456789, newYork)
+	}
+	assert.Equal(t, "2019-06-02T01:07:06Z", GraphQLNow())
+}
+
+// TestGraphQLDate confirms that dates are taken in UTC.
+func TestGraphQLDate(t *testing.T) {
+
+	assert.Equal(t, "2019-06-01", GraphQLDate(time.Date(2019, 6, 1, 19, 7, 6, 0, time.UTC)))
+	assert.Equal(t, "2019-06-01", GraphQLDate(time.Date(2019, 6, 1, 19, 59, 59, 0, newYork)))
+	assert.Equal(t, "2019-06-02", GraphQLDate(time.Date(2019, 6, 1, 20, 0, 0, 0, newYork)), "The UTC date should be used")
+}
+
+// TestGraphQLDateTimeMidnight confirms that times are truncated to the start of the UTC day.
+func TestGraphQLDateTimeMidnight(t *testing.T) {
+
+	assert.Equal(t, "2019-06-01T00:00:00Z", GraphQLDateTimeMidnight(time.Date(2019, 6, 1, 19, 7, 6, 999, time.UTC)))
+	assert.Equal(t, "2019-06-01T00:00:00Z", GraphQLDateTimeMidnight(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2019-06-02T00:00:00Z", GraphQLDateTimeMidnight(time.Date(2019, 6, 1, 21, 0, 0, 0, newYork)), "The UTC day should be used")
+}